@@ -22,45 +22,195 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"strings"
 
+	"github.com/luca-arch/instaman/grpcserver"
 	"github.com/luca-arch/instaman/internal"
 	"github.com/luca-arch/instaman/service"
 	"github.com/luca-arch/instaman/webserver"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 )
 
-// Boot sets up the api webserver and its dependencies.
-func Boot(ctx context.Context, devMode bool) (*http.Server, *slog.Logger) {
+// closers is an io.Closer that closes all of its items.
+type closers []io.Closer
+
+// Close closes all the items, even if any fails, and returns their errors joined.
+func (c closers) Close() error {
+	errs := make([]error, 0, len(c))
+
+	for _, closer := range c {
+		errs = append(errs, closer.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Boot sets up the api webserver, the gRPC server, and their dependencies.
+// Both servers share the same service layer.
+// The returned io.Closer releases the dependencies and must be closed when the servers are shut down.
+func Boot(ctx context.Context, devMode bool) (*http.Server, *grpc.Server, *slog.Logger, io.Closer) {
 	isDocker := os.Getenv("ISDOCKER") == "1"
 	logger := internal.Logger(devMode)
 
 	// Set up dependencies.
-	db := internal.Database(ctx, logger, isDocker)
+	redisClient, err := redisClient()
+	if err != nil {
+		logger.Error("could not set up the redis client", "error", err)
+		panic(err)
+	}
+
+	db, err := internal.Database(ctx, logger, isDocker)
+	if err != nil {
+		logger.Error("could not set up the database", "error", err)
+		panic(err)
+	}
+
+	deps := closers{db}
+
+	// The pictures cache is shared through Redis when it is configured, otherwise it is kept in memory.
+	var relayOpts []webserver.RelayOption
+	if redisClient != nil {
+		deps = append(deps, redisClient)
+		relayOpts = append(relayOpts,
+			webserver.WithPictureCache(webserver.NewRedisCache(redisClient, webserver.DefaultCacheTTL, logger)))
+	}
+
 	igService := service.NewInstagramService(internal.Instaproxy(logger, isDocker))
-	jobService := service.NewJobsService(db)
+	jobService := service.NewJobsService(db).WithEventEmitter(service.NewLogEventEmitter(logger))
 
 	// Init server with routes.
-	server, err := webserver.Create(ctx, jobService, igService, logger)
+	webhooks := webserver.NewWebhookManager(db, logger)
+
+	// The log level can only be changed at runtime in dev mode.
+	var logLevels interface{ SetLogLevel(level slog.Level) }
+	if devMode {
+		logLevels = db
+	}
+
+	opts := []webserver.ServerOption{
+		webserver.WithAddr(internal.OptEnv("SERVER_ADDR", webserver.DefaultAddr)),
+		// CORS wraps authentication, so that preflight requests are answered without an API key.
+		webserver.WithMiddleware(webserver.WithCORS(corsOrigins()), webserver.WithAPIKey(internal.APIKey())),
+	}
+
+	// HTTP/2 is only enabled when a TLS certificate is configured.
+	if certFile, keyFile := tlsFiles(); certFile != "" && keyFile != "" {
+		opts = append(opts, webserver.WithTLS(certFile, keyFile))
+	}
+
+	server, err := webserver.Create(ctx, jobService, igService, webhooks, logLevels, picturesRelay(ctx, logger, relayOpts...), logger, opts...)
 	if err != nil {
 		logger.Error("could not bootstrap api-server", "error", err)
 		panic(err)
 	}
 
-	return server, logger
+	grpcServer := grpcserver.Create(jobService, igService, logger)
+
+	return server, grpcServer, logger, deps
 }
 
 func main() {
 	devMode := flag.Bool("dev", false, "enable debug logger")
 	flag.Parse()
 
-	server, logger := Boot(context.Background(), *devMode)
+	server, grpcServer, logger, closer := Boot(context.Background(), *devMode)
+	defer closer.Close()
 
-	logger.Info("api-server listening on " + server.Addr)
+	grpcAddr := internal.OptEnv("INSTAMAN_GRPC_ADDR", grpcserver.DefaultAddr)
 
-	if err := server.ListenAndServe(); err != nil {
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
 		panic(err)
 	}
+
+	defer grpcServer.GracefulStop()
+
+	go func() {
+		logger.Info("grpc-server listening on " + grpcAddr)
+
+		if err := grpcServer.Serve(lis); err != nil {
+			logger.Error("grpc-server stopped", "error", err)
+		}
+	}()
+
+	if certFile, keyFile := tlsFiles(); certFile != "" && keyFile != "" {
+		logger.Info("api-server listening on " + server.Addr + " (TLS)")
+
+		err = server.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		logger.Info("api-server listening on " + server.Addr)
+
+		err = server.ListenAndServe()
+	}
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// corsOrigins returns the origins that cross-origin requests are allowed from, read from the environment as a comma
+// separated list. Cross-origin requests are not allowed when it is empty.
+func corsOrigins() []string {
+	var origins []string
+
+	for _, origin := range strings.Split(internal.OptEnv("INSTAMAN_CORS_ORIGINS", ""), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	return origins
+}
+
+// picturesRelay returns the relay that serves Instagram pictures. When INSTAMAN_PICTURES_CACHE_DIR is set, the cached
+// pictures are persisted into that directory, and the ones that have not expired yet are loaded back at startup.
+func picturesRelay(ctx context.Context, logger *slog.Logger, opts ...webserver.RelayOption) *webserver.PicturesRelay {
+	relay := webserver.DefaultPicturesRelay(logger, opts...)
+
+	dir := internal.OptEnv("INSTAMAN_PICTURES_CACHE_DIR", "")
+	if dir == "" {
+		return relay
+	}
+
+	loaded, err := relay.WithDiskCache(dir).LoadDiskCache(ctx)
+	if err != nil {
+		logger.Warn("could not load the pictures cache from disk", "error", err, "dir", dir)
+
+		return relay
+	}
+
+	logger.Info("pictures cache loaded from disk", "count", loaded, "dir", dir)
+
+	return relay
+}
+
+// redisClient returns a client for the Redis server at INSTAMAN_REDIS_URL (eg: "redis://:secret@redis:6379/0"), which
+// the api-server replicas share the pictures cache through. It returns nil when the variable is not set.
+// It returns an error if the URL is invalid.
+func redisClient() (*redis.Client, error) {
+	rawURL := internal.OptEnv("INSTAMAN_REDIS_URL", "")
+	if rawURL == "" {
+		return nil, nil //nolint:nilnil // Redis is optional.
+	}
+
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Logged as is
+	}
+
+	return redis.NewClient(opts), nil
+}
+
+// tlsFiles returns the paths of the TLS certificate and key files, read from the environment.
+// Plain HTTP is served when either is empty.
+func tlsFiles() (string, string) {
+	return internal.OptEnv("INSTAMAN_TLS_CERT_FILE", ""), internal.OptEnv("INSTAMAN_TLS_KEY_FILE", "")
 }
@@ -34,9 +34,28 @@ func TestBoot(t *testing.T) {
 
 	ctx := context.TODO()
 
-	_, logger := apiserver.Boot(ctx, false)
+	_, grpcServer, logger, closer := apiserver.Boot(ctx, false)
+	assert.NotNil(t, grpcServer)
 	assert.False(t, logger.Handler().Enabled(ctx, slog.LevelDebug))
+	assert.NoError(t, closer.Close())
 
-	_, logger = apiserver.Boot(ctx, true)
+	_, _, logger, closer = apiserver.Boot(ctx, true)
 	assert.True(t, logger.Handler().Enabled(ctx, slog.LevelDebug))
+	assert.NoError(t, closer.Close())
+}
+
+// Redis connections are lazy, so the client is set up even though no server is listening.
+func TestBootRedis(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Setenv("INSTAMAN_PICTURES_CACHE_DIR", t.TempDir())
+	t.Setenv("INSTAMAN_REDIS_URL", "redis://127.0.0.1:6379/0")
+
+	server, _, _, closer := apiserver.Boot(ctx, false)
+	assert.NotNil(t, server)
+	assert.NoError(t, closer.Close())
+
+	t.Setenv("INSTAMAN_REDIS_URL", "http://127.0.0.1:6379")
+
+	assert.Panics(t, func() { apiserver.Boot(ctx, false) })
 }
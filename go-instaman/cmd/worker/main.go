@@ -22,38 +22,95 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/luca-arch/instaman/internal"
 	"github.com/luca-arch/instaman/service"
+	"github.com/luca-arch/instaman/webserver"
 )
 
+const metricsTimeout = 10 * time.Second // How long the metrics server waits for request headers.
+
 // Boot sets up the worker and its dependencies.
-func Boot(ctx context.Context, devMode bool) (*service.Worker, *slog.Logger) {
+// The returned io.Closer releases the dependencies and must be closed when the worker is stopped.
+func Boot(ctx context.Context, devMode bool, opts ...service.WorkerOption) (*service.Worker, *slog.Logger, io.Closer) {
 	isDocker := os.Getenv("ISDOCKER") == "1"
 	logger := internal.Logger(devMode)
 
 	// Set up dependencies.
-	db := internal.Database(ctx, logger, isDocker)
+	db, err := internal.Database(ctx, logger, isDocker)
+	if err != nil {
+		logger.Error("could not set up the database", "error", err)
+		panic(err)
+	}
+
 	instaproxy := internal.Instaproxy(logger, isDocker)
 
-	// Init worker.
-	worker := service.NewWorkerService(db, logger, instaproxy)
+	// Init worker. Lifecycle events are logged, unless opts set a different emitter.
+	opts = append([]service.WorkerOption{service.WithWorkerEventEmitter(service.NewLogEventEmitter(logger))}, opts...)
+	worker := service.NewWorkerService(db, logger, instaproxy, opts...).
+		WithNotifier(webserver.NewWebhookManager(db, logger))
 
-	return worker, logger
+	return worker, logger, db
 }
 
 func main() {
 	devMode := flag.Bool("dev", false, "enable debug logger")
+	concurrency := flag.Int("concurrency", 1, "how many jobs to execute in parallel")
+	metricsAddr := flag.String("metrics-addr", "", "address to serve the metrics at (disabled when empty)")
 	flag.Parse()
 
-	ctx := context.Background()
+	// Stop the worker gracefully on SIGINT/SIGTERM so the deferred cleanup runs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := []service.WorkerOption{service.WithWorkerConcurrency(*concurrency)}
 
-	worker, logger := Boot(ctx, *devMode)
+	var metrics *service.Metrics
+	if *metricsAddr != "" {
+		metrics = service.NewMetrics()
+		opts = append(opts, service.WithWorkerMetrics(metrics))
+	}
+
+	worker, logger, closer := Boot(ctx, *devMode, opts...)
+	defer closer.Close()
+
+	if metrics != nil {
+		go serveMetrics(ctx, logger, *metricsAddr, metrics)
+	}
 
 	logger.Info("starting worker...")
 
 	worker.StartCopying(ctx)
 }
+
+// serveMetrics serves the worker's metrics at addr until ctx is done.
+// The endpoint requires an API key when INSTAMAN_METRICS_KEY is set.
+func serveMetrics(ctx context.Context, logger *slog.Logger, addr string, metrics http.Handler) {
+	server := &http.Server{ //nolint:exhaustruct // Defaults are ok
+		Addr:              addr,
+		Handler:           http.NotFoundHandler(),
+		ReadHeaderTimeout: metricsTimeout,
+	}
+
+	_ = webserver.WithMetrics(metrics, webserver.WithAPIKey(internal.MetricsKey()))(server) // It never fails.
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background()) //nolint:errcheck,contextcheck // The worker is stopping anyway.
+	}()
+
+	logger.Info("metrics listening on " + addr)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("metrics server stopped", "error", err)
+	}
+}
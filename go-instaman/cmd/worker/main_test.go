@@ -34,9 +34,11 @@ func TestBoot(t *testing.T) {
 
 	ctx := context.TODO()
 
-	_, logger := worker.Boot(ctx, false)
+	_, logger, closer := worker.Boot(ctx, false)
 	assert.False(t, logger.Handler().Enabled(ctx, slog.LevelDebug))
+	assert.NoError(t, closer.Close())
 
-	_, logger = worker.Boot(ctx, true)
+	_, logger, closer = worker.Boot(ctx, true)
 	assert.True(t, logger.Handler().Enabled(ctx, slog.LevelDebug))
+	assert.NoError(t, closer.Close())
 }
@@ -25,9 +25,13 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"math/rand/v2"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/luca-arch/instaman/internal/retry"
 )
 
 const (
@@ -35,97 +39,408 @@ const (
 	OrderDesc = "DESC"
 )
 
-var ErrDatabaseFailure = errors.New("postgresql error") // Wrapper for pgx/pgxpool errors.
+const (
+	retryDelay                   = 10 * time.Millisecond // Minimum pause before retrying a query, plus as much jitter.
+	sqlStateSerializationFailure = "40001"               // PostgreSQL could not serialize access due to concurrent updates.
+)
+
+var (
+	ErrDatabaseFailure = errors.New("postgresql error") // Wrapper for pgx/pgxpool errors.
+	ErrNoRows          = errors.New("no rows")          // SelectOne found no rows.
+)
+
+var _ io.Closer = (*Database)(nil)
 
 // Database wraps a PostgreSQL connection pool.
 type Database struct {
-	cnx     *pgxpool.Pool
-	logger  *slog.Logger
-	querier querier
+	cnx      *pgxpool.Pool
+	logLevel *slog.LevelVar // Minimum level of the records that reach the logger.
+	logger   *slog.Logger
+	querier  querier
+	retries  int    // How many times queries that failed to serialize are retried.
+	tx       pgx.Tx // Optional, queries are executed within this transaction when set.
+}
+
+// dbConn is implemented by both the connection pool and a transaction.
+type dbConn interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// levelHandler is a slog.Handler that filters records by a level that can be changed at runtime, regardless of the
+// level of the wrapped handler.
+type levelHandler struct {
+	slog.Handler
+	level *slog.LevelVar
+}
+
+// Enabled reports whether the level is at least the configured one.
+func (h *levelHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// WithAttrs returns a new levelHandler whose wrapped handler has the given attributes.
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithAttrs(attrs), level: h.level}
+}
+
+// WithGroup returns a new levelHandler whose wrapped handler has the given group.
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{Handler: h.Handler.WithGroup(name), level: h.level}
+}
+
+// Close closes all the connections in the pool.
+// It blocks until all of them are returned to the pool and closed, and always returns nil.
+func (d *Database) Close() error {
+	d.cnx.Close()
+
+	return nil
 }
 
-// WithQuerier sets the querier helper. This is only ever useful for testing.
+// Ping checks that the database can be reached, by acquiring a connection from the pool.
+func (d *Database) Ping(ctx context.Context) error {
+	if err := d.cnx.Ping(ctx); err != nil {
+		return errors.Join(ErrDatabaseFailure, err)
+	}
+
+	return nil
+}
+
+// WithTransaction calls fn with a Database whose queries are all executed within a single transaction, which is
+// committed if fn returns nil and rolled back otherwise. Transactions started from within fn are nested as savepoints.
+func (d *Database) WithTransaction(ctx context.Context, fn func(*Database) error) error {
+	return d.querier.Tx(ctx, d, fn) //nolint:wrapcheck // Error from the same package
+}
+
+// WithQuerier sets the querier helper. This is useful for testing, and to execute queries via a PreparedQuerier
+// when the pool is created with NewPoolWithConfig.
 func (d *Database) WithQuerier(q querier) *Database {
 	d.querier = q
 
 	return d
 }
 
+// WithMaxRetries makes Execute, Select and SelectOne retry up to n times the queries that PostgreSQL fails with a
+// serialization failure (SQLSTATE 40001), which can happen under high concurrency. By default queries are not retried.
+func (d *Database) WithMaxRetries(n int) *Database {
+	d.retries = max(n, 0)
+
+	return d
+}
+
 // WithLogger sets the logger.
+// The log level is reset to the lowest level that the logger's handler has enabled, and can then be changed with
+// SetLogLevel.
 func (d *Database) WithLogger(logger *slog.Logger) *Database {
-	d.logger = logger
+	h := logger.Handler()
+
+	level := slog.LevelError
+	for _, l := range []slog.Level{slog.LevelWarn, slog.LevelInfo, slog.LevelDebug} {
+		if h.Enabled(context.Background(), l) {
+			level = l
+		}
+	}
+
+	d.logLevel.Set(level)
+	d.logger = slog.New(&levelHandler{Handler: h, level: d.logLevel})
 
 	return d
 }
 
+// SetLogLevel changes the minimum level of the database logs. It is safe to call while the database is in use.
+func (d *Database) SetLogLevel(level slog.Level) {
+	d.logLevel.Set(level)
+}
+
+// newDatabase returns a Database with a no-op logger.
+func newDatabase(cnx *pgxpool.Pool, q querier) *Database {
+	d := &Database{
+		cnx:      cnx,
+		logLevel: new(slog.LevelVar),
+		logger:   nil,
+		querier:  q,
+		retries:  0,
+		tx:       nil,
+	}
+
+	return d.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// conn returns the transaction the queries must be executed within, or the connection pool if there is none.
+func (d *Database) conn() dbConn {
+	if d.tx != nil {
+		return d.tx
+	}
+
+	return d.cnx
+}
+
+// StartStatsLogger starts a goroutine that periodically logs the connection pool's statistics at debug level.
+// The goroutine terminates when the context is cancelled.
+func (d *Database) StartStatsLogger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stat := d.cnx.Stat()
+
+				d.logger.Debug("Pool stats",
+					"pool.acquired_conns", stat.AcquiredConns(),
+					"pool.idle_conns", stat.IdleConns(),
+					"pool.total_conns", stat.TotalConns(),
+					"pool.max_conns", stat.MaxConns(),
+					"pool.acquire_count", stat.AcquireCount(),
+					"pool.empty_acquire_count", stat.EmptyAcquireCount(),
+					"pool.acquire_duration_ms", stat.AcquireDuration().Milliseconds(),
+				)
+			}
+		}
+	}()
+}
+
 // NewPool instantiates a new connection pool from the provided DSN string.
-func NewPool(ctx context.Context, dsn string) *Database {
-	cnx, err := pgxpool.New(ctx, dsn)
+// The pool connects lazily, so it returns an error only if dsn is malformed.
+func NewPool(ctx context.Context, dsn string) (*Database, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		// Lazily panic here because it happens only with malformed dsn strings.
-		panic(err)
+		return nil, errors.Join(ErrDatabaseFailure, err)
 	}
 
-	return &Database{
-		cnx:     cnx,
-		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
-		querier: &Querier{},
+	return NewPoolWithConfig(ctx, cfg)
+}
+
+// NewPoolWithConfig instantiates a new connection pool from a fully constructed configuration.
+// Queries are executed via a Querier; to use prepared statements, set cfg.AfterConnect to a PreparedQuerier's
+// AfterConnect method and pass the same PreparedQuerier to WithQuerier.
+func NewPoolWithConfig(ctx context.Context, cfg *pgxpool.Config) (*Database, error) {
+	cnx, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseFailure, err)
 	}
+
+	return newDatabase(cnx, &Querier{}), nil
 }
 
-// Count executes the provided SQL expecting a COUNT.
-func Count(ctx context.Context, db *Database, sql string, args ...any) (int32, error) {
-	db.logger.Debug("Query", "sql", sql, "args", args)
+// NewPreparedPool instantiates a new connection pool from the provided DSN string.
+// The most frequent queries are prepared on each new connection and executed via a PreparedQuerier.
+func NewPreparedPool(ctx context.Context, dsn string) (*Database, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, errors.Join(ErrDatabaseFailure, err)
+	}
+
+	q := NewPreparedQuerier()
+	cfg.AfterConnect = q.AfterConnect
 
-	res, err := db.cnx.Query(ctx, sql, args...)
+	db, err := NewPoolWithConfig(ctx, cfg)
 	if err != nil {
-		return -1, errors.Join(ErrDatabaseFailure, err)
+		return nil, err
 	}
 
-	defer res.Close()
+	return db.WithQuerier(q), nil
+}
+
+// Batch sends all the queued queries in a single network round trip.
+// The queries are implicitly executed within a transaction, so either all or none are applied.
+func Batch(ctx context.Context, db *Database, batch *pgx.Batch) error {
+	for _, q := range batch.QueuedQueries {
+		db.logger.Debug("Batch query", "sql", q.SQL, "args", q.Arguments)
+	}
+
+	res := db.conn().SendBatch(ctx, batch)
+
+	for range batch.Len() {
+		if _, err := res.Exec(); err != nil {
+			res.Close()
+
+			return errors.Join(ErrDatabaseFailure, err)
+		}
+	}
+
+	if err := res.Close(); err != nil {
+		return errors.Join(ErrDatabaseFailure, err)
+	}
+
+	return nil
+}
+
+// CopyUpsert describes a bulk upsert: rows are sent with the COPY protocol into a temporary table, which is then
+// merged into the target table with a single statement.
+type CopyUpsert struct {
+	CreateSQL string   // Creates the temporary table, which must be dropped on commit.
+	TempTable string   // The temporary table's name.
+	Columns   []string // The temporary table's columns, in the same order as the values of each row.
+	Rows      [][]any  // The values to copy.
+	MergeSQL  string   // Merges the temporary table into the target one.
+	MergeArgs []any    // MergeSQL's arguments.
+}
+
+// CopyFrom executes a CopyUpsert in a transaction, and returns the number of rows affected by its MergeSQL.
+func CopyFrom(ctx context.Context, db *Database, c *CopyUpsert) (int64, error) {
+	db.logger.Debug("Copy", "table", c.TempTable, "rows", len(c.Rows), "sql", c.MergeSQL, "args", c.MergeArgs)
 
-	count, err := pgx.CollectExactlyOneRow(res, pgx.RowTo[int32])
+	tx, err := db.conn().Begin(ctx)
 	if err != nil {
-		return -1, errors.Join(ErrDatabaseFailure, err)
+		return 0, errors.Join(ErrDatabaseFailure, err)
 	}
 
-	// Rows MUST be closed prior to reading the error.
-	// CollectExactlyOneRow does that already.
-	if err := res.Err(); err != nil {
-		return -1, errors.Join(ErrDatabaseFailure, err)
+	defer tx.Rollback(ctx) //nolint:errcheck // No-op once committed
+
+	if _, err := tx.Exec(ctx, c.CreateSQL); err != nil {
+		return 0, errors.Join(ErrDatabaseFailure, err)
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{c.TempTable}, c.Columns, pgx.CopyFromRows(c.Rows)); err != nil {
+		return 0, errors.Join(ErrDatabaseFailure, err)
 	}
 
-	return count, nil
+	tag, err := tx.Exec(ctx, c.MergeSQL, c.MergeArgs...)
+	if err != nil {
+		return 0, errors.Join(ErrDatabaseFailure, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, errors.Join(ErrDatabaseFailure, err)
+	}
+
+	return tag.RowsAffected(), nil
 }
 
-// Execute executes the provided SQL string without expecting anything to return.
-func Execute(ctx context.Context, db *Database, sql string, args ...any) error {
-	db.logger.Debug("Query", "sql", sql, "args", args)
+// Transaction begins a transaction, or a savepoint if db is already within one, and calls fn with a copy of db whose
+// queries are executed within it. The transaction is committed if fn returns nil, and rolled back otherwise.
+// Queries that fail to serialize are not retried within the transaction, as PostgreSQL aborts it anyway.
+func Transaction(ctx context.Context, db *Database, fn func(*Database) error) error {
+	var (
+		tx  pgx.Tx
+		err error
+	)
+
+	db.logger.Debug("Begin transaction")
+
+	if db.tx != nil {
+		tx, err = db.tx.Begin(ctx)
+	} else {
+		tx, err = db.cnx.BeginTx(ctx, pgx.TxOptions{}) //nolint:exhaustruct // Defaults are ok
+	}
 
-	res, err := db.cnx.Query(ctx, sql, args...)
 	if err != nil {
 		return errors.Join(ErrDatabaseFailure, err)
 	}
 
-	res.Close()
+	defer tx.Rollback(ctx) //nolint:errcheck // No-op once committed
+
+	txDB := &Database{
+		cnx:      db.cnx,
+		logLevel: db.logLevel,
+		logger:   db.logger,
+		querier:  db.querier,
+		retries:  0,
+		tx:       tx,
+	}
+
+	if err := fn(txDB); err != nil {
+		db.logger.Debug("Rollback transaction", "error", err)
+
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Join(ErrDatabaseFailure, err)
+	}
 
 	return nil
 }
 
+// Count executes the provided SQL expecting a COUNT.
+func Count(ctx context.Context, db *Database, sql string, args ...any) (int32, error) {
+	count, err := SelectOneMapped(ctx, db, sql, pgx.RowTo[int32], args...)
+	if err != nil {
+		return -1, err
+	}
+
+	return *count, nil
+}
+
+// Execute executes the provided SQL string without expecting anything to return.
+func Execute(ctx context.Context, db *Database, sql string, args ...any) error {
+	_, err := Retry(ctx, db, func() (struct{}, error) {
+		db.logger.Debug("Query", "sql", sql, "args", args)
+
+		res, err := db.conn().Query(ctx, sql, args...)
+		if err != nil {
+			return struct{}{}, errors.Join(ErrDatabaseFailure, err)
+		}
+
+		// Rows MUST be closed prior to reading the error.
+		res.Close()
+
+		if err := res.Err(); err != nil {
+			return struct{}{}, errors.Join(ErrDatabaseFailure, err)
+		}
+
+		return struct{}{}, nil
+	})
+
+	return err
+}
+
+// Retry calls fn, and calls it again while it fails with a serialization failure, up to the number of times set with
+// WithMaxRetries. Each retry is preceded by a short pause with jitter, so that concurrent transactions don't collide
+// again. It returns early when ctx is cancelled. The last value and error returned by fn are returned.
+func Retry[T any](ctx context.Context, db *Database, fn func() (T, error)) (T, error) {
+	//nolint:wrapcheck // fn's error is returned as is
+	return retry.Do(ctx, retry.Policy{
+		Attempts: db.retries + 1,
+		Delay: func(int) time.Duration {
+			return retryDelay + rand.N(retryDelay) //nolint:gosec // Jitter
+		},
+		Retryable: isSerializationFailure,
+		OnRetry: func(attempt int, _ time.Duration, err error) {
+			db.logger.Debug("Retrying query", "error", err, "attempt", attempt+1)
+		},
+	}, fn)
+}
+
+// isSerializationFailure reports whether err was caused by PostgreSQL failing to serialize a transaction.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == sqlStateSerializationFailure
+}
+
 // Select executes the provided SQL and returns the whole resultset.
+// Columns are mapped to the fields of T by name.
 func Select[T any](ctx context.Context, db *Database, sql string, args ...any) ([]T, error) {
+	return SelectMapped(ctx, db, sql, pgx.RowToStructByNameLax[T], args...)
+}
+
+// SelectMapped executes the provided SQL and returns the whole resultset, of which each row is converted by mapper.
+// It allows selecting aggregates and projections that do not map cleanly to a struct.
+func SelectMapped[T any](ctx context.Context, db *Database, sql string, mapper pgx.RowToFunc[T], args ...any) ([]T, error) {
+	return Retry(ctx, db, func() ([]T, error) {
+		return selectMapped(ctx, db, sql, mapper, args...)
+	})
+}
+
+func selectMapped[T any](ctx context.Context, db *Database, sql string, mapper pgx.RowToFunc[T], args ...any) ([]T, error) {
 	db.logger.Debug("Query", "sql", sql, "args", args)
 
 	var out []T
 
-	res, err := db.cnx.Query(ctx, sql, args...)
+	res, err := db.conn().Query(ctx, sql, args...)
 	if err != nil {
 		return nil, errors.Join(ErrDatabaseFailure, err)
 	}
 
 	defer res.Close()
 
-	out, err = pgx.CollectRows(res, pgx.RowToStructByNameLax[T])
+	out, err = pgx.CollectRows(res, mapper)
 	if err != nil {
 		return nil, errors.Join(ErrDatabaseFailure, err)
 	}
@@ -139,20 +454,67 @@ func Select[T any](ctx context.Context, db *Database, sql string, args ...any) (
 	return out, nil
 }
 
-// Select executes the provided SQL and return the found row.
-// It returns an error if none, or if more than one rows are found.
+// Stream executes the provided SQL and calls fn for each row of the resultset, without buffering them.
+// Iteration stops at the first error returned by fn.
+func Stream[T any](ctx context.Context, db *Database, fn func(T) error, sql string, args ...any) error {
+	db.logger.Debug("Query", "sql", sql, "args", args)
+
+	res, err := db.conn().Query(ctx, sql, args...)
+	if err != nil {
+		return errors.Join(ErrDatabaseFailure, err)
+	}
+
+	defer res.Close()
+
+	for res.Next() {
+		row, err := pgx.RowToStructByNameLax[T](res)
+		if err != nil {
+			return errors.Join(ErrDatabaseFailure, err)
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := res.Err(); err != nil {
+		return errors.Join(ErrDatabaseFailure, err)
+	}
+
+	return nil
+}
+
+// SelectOne executes the provided SQL and return the found row.
+// Columns are mapped to the fields of T by name.
+// It returns ErrNoRows if none is found, or an error if more than one rows are found.
 func SelectOne[T any](ctx context.Context, db *Database, sql string, args ...any) (*T, error) {
+	return SelectOneMapped(ctx, db, sql, pgx.RowToStructByNameLax[T], args...)
+}
+
+// SelectOneMapped executes the provided SQL and returns the found row, converted by mapper.
+// It returns ErrNoRows if none is found, or an error if more than one rows are found.
+func SelectOneMapped[T any](ctx context.Context, db *Database, sql string, mapper pgx.RowToFunc[T], args ...any) (*T, error) {
+	return Retry(ctx, db, func() (*T, error) {
+		return selectOneMapped(ctx, db, sql, mapper, args...)
+	})
+}
+
+func selectOneMapped[T any](ctx context.Context, db *Database, sql string, mapper pgx.RowToFunc[T], args ...any) (*T, error) {
 	db.logger.Debug("Query", "sql", sql, "args", args)
 
-	res, err := db.cnx.Query(ctx, sql, args...)
+	res, err := db.conn().Query(ctx, sql, args...)
 	if err != nil {
 		return nil, errors.Join(ErrDatabaseFailure, err)
 	}
 
 	defer res.Close()
 
-	out, err := pgx.CollectExactlyOneRow(res, pgx.RowToStructByNameLax[T])
-	if err != nil {
+	out, err := pgx.CollectExactlyOneRow(res, mapper)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrNoRows
+	case err != nil:
 		return nil, errors.Join(ErrDatabaseFailure, err)
 	}
 
@@ -0,0 +1,302 @@
+//go:build integration
+
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package database_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// integrationDSN is the environment variable holding the DSN of the PostgreSQL database the integration tests run
+// against. The database must be seeded with postgres-seed.sql.
+const integrationDSN = "INSTAMAN_TEST_POSTGRES_DSN"
+
+func integrationPool(t *testing.T) *database.Database {
+	t.Helper()
+
+	dsn := os.Getenv(integrationDSN)
+	if dsn == "" {
+		t.Skip(integrationDSN + " is not set")
+	}
+
+	db, err := database.NewPool(context.TODO(), dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func TestIntegrationUpdateJobFrequency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	db := integrationPool(t)
+
+	// Use an account ID that is unlikely to clash with existing jobs.
+	userID := time.Now().UnixNano()
+
+	params := database.NewCopyJobParams{ //nolint:exhaustruct
+		Label: "Integration test",
+		Type:  models.JobTypeCopyFollowers,
+	}
+	params.Metadata.Frequency = models.JobFrequencyDaily
+	params.Metadata.UserID = userID
+
+	cj, err := db.NewCopyJob(ctx, params)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = database.Execute(ctx, db, `DELETE FROM jobs WHERE id = $1`, cj.ID)
+	})
+
+	err = db.UpdateJob(ctx, database.UpdateJobParams{ //nolint:exhaustruct
+		Frequency: models.JobFrequencyWeekly,
+		ID:        cj.ID,
+	})
+	require.NoError(t, err)
+
+	updated, err := db.FindCopyJob(ctx, database.FindCopyJobParams{ //nolint:exhaustruct
+		Direction: "followers",
+		UserID:    userID,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, models.JobFrequencyWeekly, updated.Metadata.Frequency)
+	assert.Equal(t, userID, updated.Metadata.UserID)
+	assert.Equal(t, "Integration test", updated.Label)
+}
+
+func TestIntegrationGetJobStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	db := integrationPool(t)
+
+	params := database.NewCopyJobParams{ //nolint:exhaustruct
+		Label: "Integration test",
+		Type:  models.JobTypeCopyFollowing,
+	}
+	params.Metadata.Frequency = models.JobFrequencyDaily
+	params.Metadata.UserID = time.Now().UnixNano()
+
+	cj, err := db.NewCopyJob(ctx, params)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = database.Execute(ctx, db, `DELETE FROM jobs WHERE id = $1`, cj.ID)
+	})
+
+	stats, err := db.GetJobStats(ctx)
+	require.NoError(t, err)
+
+	assert.Positive(t, stats.ByType[models.JobTypeCopyFollowing])
+	assert.Positive(t, stats.ByState[cj.State])
+	assert.GreaterOrEqual(t, stats.TotalFollowers, int64(0))
+	assert.GreaterOrEqual(t, stats.TotalFollowing, int64(0))
+}
+
+func TestIntegrationSoftDeleteJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	db := integrationPool(t)
+
+	params := database.NewCopyJobParams{ //nolint:exhaustruct
+		Label: "Integration test",
+		Type:  models.JobTypeCopyFollowers,
+	}
+	params.Metadata.Frequency = models.JobFrequencyDaily
+	params.Metadata.UserID = time.Now().UnixNano()
+
+	cj, err := db.NewCopyJob(ctx, params)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = database.Execute(ctx, db, `DELETE FROM jobs WHERE checksum = $1`, cj.Checksum)
+	})
+
+	// Tag the job with a unique owner, so that FindJobs only lists this one.
+	owner := "integration-" + cj.Checksum
+	require.NoError(t, database.Execute(ctx, db, `UPDATE jobs SET created_by = $1 WHERE id = $2`, owner, cj.ID))
+	require.NoError(t, db.InsertJobEvent(ctx, cj.ID, "Integration test event", ""))
+
+	jobs, err := db.FindJobs(ctx, database.FindJobsParams{CreatedBy: owner}) //nolint:exhaustruct
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	// The states filter is passed as a single array parameter.
+	states := []string{models.JobStateError, cj.State}
+
+	jobs, err = db.FindJobs(ctx, database.FindJobsParams{CreatedBy: owner, States: states}) //nolint:exhaustruct
+	require.NoError(t, err)
+	assert.Len(t, jobs, 1)
+
+	jobs, err = db.FindJobs(ctx, database.FindJobsParams{CreatedBy: owner, States: states[:1]}) //nolint:exhaustruct
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	require.NoError(t, db.DeleteJob(ctx, cj.ID))
+
+	jobs, err = db.FindJobs(ctx, database.FindJobsParams{CreatedBy: owner}) //nolint:exhaustruct
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+
+	_, err = db.FindJob(ctx, database.FindJobParams{ID: cj.ID}) //nolint:exhaustruct
+	require.ErrorIs(t, err, database.ErrJobNotFound)
+
+	// Deleted jobs can't be updated.
+	err = db.UpdateJob(ctx, database.UpdateJobParams{ID: cj.ID, Label: "Updated"}) //nolint:exhaustruct
+	require.ErrorIs(t, err, database.ErrJobNotFound)
+	require.ErrorIs(t, db.ScheduleJob(ctx, cj.ID, time.Minute), database.ErrJobNotFound)
+	require.ErrorIs(t, db.TouchJob(ctx, cj.ID), database.ErrJobNotFound)
+
+	// The audit logs are kept.
+	events, err := db.FindJobEvents(ctx, cj.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// The same job can be created again.
+	again, err := db.NewCopyJob(ctx, params)
+	require.NoError(t, err)
+	assert.NotEqual(t, cj.ID, again.ID)
+}
+
+func TestIntegrationArchiveJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	db := integrationPool(t)
+
+	params := database.NewCopyJobParams{ //nolint:exhaustruct
+		Label: "Integration test",
+		Type:  models.JobTypeCopyFollowing,
+	}
+	params.Metadata.Frequency = models.JobFrequencyDaily
+	params.Metadata.UserID = time.Now().UnixNano()
+
+	cj, err := db.NewCopyJob(ctx, params)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = database.Execute(ctx, db, `DELETE FROM jobs_archive WHERE id = $1`, cj.ID)
+		_ = database.Execute(ctx, db, `DELETE FROM jobs_events WHERE job_id = $1`, cj.ID)
+	})
+
+	owner := "integration-" + cj.Checksum
+	require.NoError(t, database.Execute(ctx, db, `UPDATE jobs SET created_by = $1 WHERE id = $2`, owner, cj.ID))
+	require.NoError(t, db.InsertJobEvent(ctx, cj.ID, "Integration test event", ""))
+
+	require.NoError(t, db.ArchiveJob(ctx, cj.ID))
+	require.ErrorIs(t, db.ArchiveJob(ctx, cj.ID), database.ErrJobNotFound)
+
+	_, err = db.FindJob(ctx, database.FindJobParams{ID: cj.ID}) //nolint:exhaustruct
+	require.ErrorIs(t, err, database.ErrJobNotFound)
+
+	archived, err := db.FindArchivedJobs(ctx, database.FindJobsParams{CreatedBy: owner}) //nolint:exhaustruct
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, cj.ID, archived[0].ID)
+
+	// The audit logs are kept.
+	events, err := db.FindJobEvents(ctx, cj.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestIntegrationArchiveColumns(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	db := integrationPool(t)
+
+	columns := func(table string) []string {
+		sql := `
+		SELECT column_name || ' ' || data_type
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1
+		ORDER BY column_name
+		`
+
+		cols, err := database.SelectMapped(ctx, db, sql, pgx.RowTo[string], table)
+		require.NoError(t, err)
+
+		return cols
+	}
+
+	jobsCols := columns("jobs")
+	assert.NotEmpty(t, jobsCols)
+	assert.Equal(t, jobsCols, columns("jobs_archive"))
+}
+
+func TestIntegrationWithTransactionRollback(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	db := integrationPool(t)
+	mockErr := errors.New("mock error")
+
+	params := database.NewCopyJobParams{ //nolint:exhaustruct
+		Label: "Integration test",
+		Type:  models.JobTypeCopyFollowers,
+	}
+	params.Metadata.Frequency = models.JobFrequencyDaily
+	params.Metadata.UserID = time.Now().UnixNano()
+
+	cj, err := db.NewCopyJob(ctx, params)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = database.Execute(ctx, db, `DELETE FROM jobs WHERE id = $1`, cj.ID)
+	})
+
+	err = db.WithTransaction(ctx, func(tx *database.Database) error {
+		if err := tx.UpdateJob(ctx, database.UpdateJobParams{ //nolint:exhaustruct
+			ID:    cj.ID,
+			Label: "Rolled back",
+		}); err != nil {
+			return err
+		}
+
+		return mockErr
+	})
+	require.ErrorIs(t, err, mockErr)
+
+	job, err := db.FindJob(ctx, database.FindJobParams{ID: cj.ID}) //nolint:exhaustruct
+	require.NoError(t, err)
+
+	assert.Equal(t, "Integration test", job.Label)
+}
+
+func TestIntegrationPing(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, integrationPool(t).Ping(context.TODO()))
+}
@@ -21,8 +21,10 @@ package database
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -32,8 +34,9 @@ import (
 )
 
 const (
-	MaxCopyResults = 100 // The maximum number of users per page to retrieve with copy-followers and copy-following jobs.
-	MaxJobsResult  = 20  // The maximum number of jobs per page that are retrieved by FindJobs().
+	MaxCopyResults     = 100 // The maximum number of users per page to retrieve with copy-followers and copy-following jobs.
+	MaxJobEventsResult = 50  // The maximum number of events per page that are retrieved by FindJobEvents().
+	MaxJobsResult      = 20  // The maximum number of jobs per page that are retrieved by FindJobs().
 )
 
 var (
@@ -42,15 +45,30 @@ var (
 	ErrFindCopyJobParams = errors.New("invalid direction")       // Invalid direction passed to FindCopyJob().
 	ErrInvalidChecksum   = errors.New("invalid checksum")        // Invalid checksum.
 	ErrInvalidID         = errors.New("invalid ID")              // Invalid identifier.
+	ErrInvalidLabel      = errors.New("label is required")       // Missing label in NewCopyJob().
 	ErrInvalidState      = errors.New("invalid job state")       // Invalid state.
+	ErrInvalidTransition = errors.New("invalid transition")      // The job can't be moved to the requested state.
 	ErrInvalidType       = errors.New("invalid job type")        // Invalid job type.
+	ErrJobNotFound       = errors.New("job not found")           // The requested job does not exist.
 )
 
+// ArchiveJobParams defines the input data for ArchiveJob().
+type ArchiveJobParams struct {
+	ID int64 `in:"id,path,required"`
+}
+
+// DeleteJobParams defines the input data for DeleteJob().
+type DeleteJobParams struct {
+	ID int64 `in:"id,path,required"`
+}
+
 // FindCopyJobParams defines the search parameters for FindCopyJob().
 type FindCopyJobParams struct {
-	Direction string `in:"direction,required"`
-	UserID    int64  `in:"userID,required"`
-	WithPage  *int   `in:"page,omitempty"`
+	Direction    string `in:"direction,required"`
+	Limit        *int   `in:"limit,omitempty"` // Users per page, capped at MaxCopyResults. Default: MaxCopyResults.
+	ResultsOrder string `in:"order"`
+	UserID       int64  `in:"userID,required"`
+	WithPage     *int   `in:"page,omitempty"`
 }
 
 // FindJobParams defines the search parameters for FindJob().
@@ -61,12 +79,21 @@ type FindJobParams struct {
 	Type     string `in:"type"`
 }
 
+// FindJobEventsParams defines the search parameters for FindJobEvents().
+type FindJobEventsParams struct {
+	JobID int64 `in:"id,path,required"`
+	Page  int   `in:"page"`
+}
+
 // FindJobsParams defines the search parameters for FindJobs().
+// When States is populated, State is ignored.
 type FindJobsParams struct {
-	Order string `in:"order"`
-	Page  int32  `in:"page"`
-	State string `in:"state"`
-	Type  string `in:"type"`
+	CreatedBy string   `in:"X-User-ID,header"` // Injected by the auth middleware, jobs are not filtered when empty.
+	Order     string   `in:"order"`
+	Page      int32    `in:"page"`
+	State     string   `in:"state"`
+	States    []string `in:"states,omitempty"`
+	Type      string   `in:"type"`
 }
 
 // NewCopyJobParams defines the input data for NewCopyJob().
@@ -94,14 +121,14 @@ type NewJobParams struct {
 // UpdateJobParams defines the input data for UpdateJob().
 type UpdateJobParams struct {
 	Frequency string `json:"frequency"`
-	ID        int64  `json:"id"`
+	ID        int64  `in:"id,path,required" json:"id"`
 	Label     string `json:"label"`
 	State     string `json:"state"`
 }
 
 // FindCopyJob finds a job of type `copy-followers` or `copy-following`.
 // It calls FindJob and augments the result with the total number of connections already retrieved.
-// If WithPage is set, that slice of results is also included in the returned value.
+// If WithPage is set, that slice of results is also included in the returned value, sorted by ResultsOrder.
 func (d *Database) FindCopyJob(ctx context.Context, params FindCopyJobParams) (*models.CopyJob, error) {
 	var table string
 
@@ -121,12 +148,8 @@ func (d *Database) FindCopyJob(ctx context.Context, params FindCopyJobParams) (*
 	}
 
 	job, err := d.FindJob(ctx, p)
-
-	switch {
-	case err != nil:
+	if err != nil {
 		return nil, err
-	case job == nil:
-		return nil, nil //nolint:nilnil // It means not found
 	}
 
 	sql := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE account_id = $1`, table)
@@ -146,12 +169,15 @@ func (d *Database) FindCopyJob(ctx context.Context, params FindCopyJobParams) (*
 		return ret, nil
 	}
 
-	limit, offset := MaxCopyResults, *params.WithPage*MaxCopyResults
+	limit := CopyResultsLimit(params.Limit)
+	offset := *params.WithPage * limit
+	order, dir := copyResultsOrder(params.ResultsOrder)
 
 	sql = `
 	SELECT
 		user_id,
 		first_seen,
+		full_name,
 		handler,
 		last_seen,
 		pic_url
@@ -160,7 +186,7 @@ func (d *Database) FindCopyJob(ctx context.Context, params FindCopyJobParams) (*
 	WHERE
 		account_id = $1
 	ORDER BY
-		first_seen DESC
+		` + order + ` ` + dir + `
 	LIMIT $2 OFFSET $3
 	`
 
@@ -181,7 +207,7 @@ func (d *Database) FindCopyJob(ctx context.Context, params FindCopyJobParams) (*
 }
 
 // FindJob finds a job by its ID or checksum.
-// It returns a nil pointer if no job is found.
+// It returns ErrJobNotFound if no job is found, or if it was deleted.
 func (d *Database) FindJob(ctx context.Context, params FindJobParams) (*models.Job, error) {
 	if params.ID <= 0 && params.Checksum == "" {
 		return nil, ErrFindJobParams
@@ -210,6 +236,8 @@ func (d *Database) FindJob(ctx context.Context, params FindJobParams) (*models.J
 		whereV = append(whereV, params.Type)
 	}
 
+	whereP = append(whereP, "deleted_at IS NULL")
+
 	sql := `
 	SELECT
 		id,
@@ -229,34 +257,212 @@ func (d *Database) FindJob(ctx context.Context, params FindJobParams) (*models.J
 	switch {
 	case err == nil:
 		return job, nil
-	case errors.Is(err, pgx.ErrNoRows):
-		return nil, nil //nolint:nilnil // It means not found
+	case errors.Is(err, ErrNoRows):
+		return nil, ErrJobNotFound
 	default:
 		return nil, err //nolint:wrapcheck // Error from the same package
 	}
 }
 
+// FindJobEvents returns a page of the audit logs of a job, most recent first.
+// It returns ErrJobNotFound if the page is empty and the job does not exist.
+func (d *Database) FindJobEvents(ctx context.Context, jobID int64, page int) ([]models.JobEvent, error) {
+	if jobID <= 0 {
+		return nil, ErrInvalidID
+	}
+
+	if page < 0 {
+		page = 0
+	}
+
+	sql := `
+	SELECT
+		id,
+		event_msg,
+		job_id,
+		ts
+	FROM
+		jobs_events
+	WHERE
+		job_id = $1
+	ORDER BY
+		ts DESC, id DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	events, err := d.querier.SelectJobEvents(ctx, d, sql, jobID, MaxJobEventsResult, page*MaxJobEventsResult)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Error from the same package
+	}
+
+	// An empty page could either mean that the job has no more events, or that it does not exist.
+	if len(events) == 0 {
+		if _, err := d.FindJob(ctx, FindJobParams{ID: jobID}); err != nil { //nolint:exhaustruct
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+// ArchiveJob moves a job from the `jobs` table to the `jobs_archive` table, keeping its ID so that its audit logs in
+// `jobs_events` still refer to it.
+// Both queries are executed within a transaction: if either fails, the job is left untouched in the `jobs` table.
+// It returns ErrJobNotFound if the job doesn't exist or was deleted.
+func (d *Database) ArchiveJob(ctx context.Context, id int64) error {
+	if id < 1 {
+		return ErrInvalidID
+	}
+
+	sqlArchive := `
+	INSERT INTO jobs_archive
+		(id, checksum, created_by, deleted_at, job_type, label, last_run, metadata, next_run, state)
+	SELECT
+		id, checksum, created_by, deleted_at, job_type, label, last_run, metadata, next_run, state
+	FROM
+		jobs
+	WHERE
+		id = $1 AND deleted_at IS NULL
+	RETURNING
+		id
+	`
+
+	return d.WithTransaction(ctx, func(tx *Database) error {
+		_, err := tx.querier.SelectJob(ctx, tx, sqlArchive, id)
+
+		switch {
+		case errors.Is(err, ErrNoRows):
+			return ErrJobNotFound
+		case err != nil:
+			return err //nolint:wrapcheck // Error from the same package
+		}
+
+		return tx.querier.Execute(ctx, tx, `DELETE FROM jobs WHERE id = $1`, id) //nolint:wrapcheck // Same package
+	})
+}
+
+// DeleteJob soft-deletes a job, see SoftDeleteJob.
+func (d *Database) DeleteJob(ctx context.Context, id int64) error {
+	return d.SoftDeleteJob(ctx, id)
+}
+
+// SoftDeleteJob marks a job as deleted and unschedules it, so that it is no longer returned nor executed while its audit
+// logs are kept. Deleting a job twice is a no-op.
+func (d *Database) SoftDeleteJob(ctx context.Context, id int64) error {
+	if id < 1 {
+		return ErrInvalidID
+	}
+
+	sql := `UPDATE jobs SET deleted_at = NOW(), next_run = NULL WHERE id = $1 AND deleted_at IS NULL`
+
+	if err := d.querier.Execute(ctx, d, sql, id); err != nil {
+		return err //nolint:wrapcheck // Error from the same package
+	}
+
+	return nil
+}
+
+// FindArchivedJobs returns a list of archived jobs.
+func (d *Database) FindArchivedJobs(ctx context.Context, params FindJobsParams) ([]models.Job, error) {
+	return d.findJobs(ctx, "jobs_archive", params)
+}
+
 // FindJobs returns a list of jobs.
 func (d *Database) FindJobs(ctx context.Context, params FindJobsParams) ([]models.Job, error) {
+	return d.findJobs(ctx, "jobs", params)
+}
+
+// GetJobStats aggregates the jobs by type and state, along with the number of users copied so far.
+// All the figures are computed by a single query.
+func (d *Database) GetJobStats(ctx context.Context) (*models.JobStats, error) {
+	sql := `
+	WITH
+		live_jobs AS (SELECT job_type, next_run, state FROM jobs WHERE deleted_at IS NULL),
+		by_type AS (SELECT job_type, COUNT(*) AS n FROM live_jobs GROUP BY job_type),
+		by_state AS (SELECT state, COUNT(*) AS n FROM live_jobs GROUP BY state)
+	SELECT
+		COALESCE((SELECT jsonb_object_agg(job_type, n) FROM by_type), '{}') AS by_type,
+		COALESCE((SELECT jsonb_object_agg(state, n) FROM by_state), '{}') AS by_state,
+		(SELECT MIN(next_run) FROM live_jobs) AS oldest_next_run,
+		(SELECT COUNT(*) FROM user_followers) AS total_followers,
+		(SELECT COUNT(*) FROM user_following) AS total_following
+	`
+
+	stats, err := d.querier.SelectJobStats(ctx, d, sql)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Error from the same package
+	}
+
+	return stats, nil
+}
+
+// rowToJobStats is the row mapper of GetJobStats' query: columns are scanned by position, in the order they are
+// selected, so the aggregates don't need to be aliased after the struct tags.
+func rowToJobStats(row pgx.CollectableRow) (models.JobStats, error) {
+	var stats models.JobStats
+
+	err := row.Scan(&stats.ByType, &stats.ByState, &stats.OldestNextRun, &stats.TotalFollowers, &stats.TotalFollowing)
+
+	return stats, err //nolint:wrapcheck // Wrapped by SelectOneMapped
+}
+
+// CopyResultsLimit returns how many users a page of copy job results holds for the requested limit.
+// It is MaxCopyResults when limit is nil or not positive, and never more than that.
+func CopyResultsLimit(limit *int) int {
+	if limit == nil || *limit < 1 {
+		return MaxCopyResults
+	}
+
+	return min(*limit, MaxCopyResults)
+}
+
+// copyResultsOrder maps the value of FindCopyJobParams.ResultsOrder to a column and a sort direction.
+// Unknown values fall back to the most recently seen users first.
+func copyResultsOrder(order string) (string, string) {
+	switch order {
+	case "handler":
+		return "handler", OrderAsc
+	case "-handler":
+		return "handler", OrderDesc
+	case "first_seen":
+		return "first_seen", OrderAsc
+	case "last_seen":
+		return "last_seen", OrderAsc
+	case "-last_seen":
+		return "last_seen", OrderDesc
+	default:
+		return "first_seen", OrderDesc
+	}
+}
+
+// findJobs returns a list of jobs from the specified table.
+func (d *Database) findJobs(ctx context.Context, table string, params FindJobsParams) ([]models.Job, error) {
 	whereP := make([]string, 0)
 	args := make([]any, 0)
-	where := ""
 	order, dir := "last_run", OrderDesc
 
-	if params.State != "" {
-		whereP = append(whereP, nextPlaceholder("state", whereP))
+	switch {
+	case len(params.States) > 0:
+		whereP = append(whereP, anyPlaceholder("state", args))
+		args = append(args, params.States)
+	case params.State != "":
+		whereP = append(whereP, nextPlaceholder("state", args))
 		args = append(args, params.State)
 	}
 
 	if params.Type != "" {
-		whereP = append(whereP, nextPlaceholder("job_type", whereP))
+		whereP = append(whereP, nextPlaceholder("job_type", args))
 		args = append(args, params.Type)
 	}
 
-	if len(whereP) > 0 {
-		where = "WHERE " + strings.Join(whereP, " AND ")
+	if params.CreatedBy != "" {
+		whereP = append(whereP, nextPlaceholder("created_by", args))
+		args = append(args, params.CreatedBy)
 	}
 
+	whereP = append(whereP, "deleted_at IS NULL")
+	where := "WHERE " + strings.Join(whereP, " AND ")
+
 	switch params.Order {
 	case "-last_run":
 		order, dir = "last_run", OrderDesc
@@ -287,7 +493,7 @@ func (d *Database) FindJobs(ctx context.Context, params FindJobsParams) ([]model
 		next_run,
 		state
 	FROM
-		jobs
+		` + table + `
 	`
 
 	sql = fmt.Sprintf("%s %s ORDER BY %s %s LIMIT %d OFFSET %d",
@@ -308,6 +514,8 @@ func (d *Database) NewCopyJob(ctx context.Context, params NewCopyJobParams) (*mo
 		return nil, ErrFindCopyJobParams
 	case params.Metadata.UserID < 1:
 		return nil, ErrInvalidID
+	case params.Label == "":
+		return nil, ErrInvalidLabel
 	}
 
 	j, err := d.NewJob(ctx, NewJobParams{
@@ -347,7 +555,15 @@ func (d *Database) NewJob(ctx context.Context, params NewJobParams) (*models.Job
 		state
 	)
 	VALUES ($1, $2, $3, NULL, $4, $5, $6)
-	RETURNING *
+	RETURNING
+		id,
+		checksum,
+		job_type,
+		label,
+		last_run,
+		metadata,
+		next_run,
+		state
 	`
 
 	j, err := d.querier.SelectJob(ctx, d, sql, params.Checksum, params.Type, params.Label, params.Metadata, params.NextRun, params.State)
@@ -358,10 +574,104 @@ func (d *Database) NewJob(ctx context.Context, params NewJobParams) (*models.Job
 	return j, nil
 }
 
-// UpdateJob updates the specified columns in the `jobs` table.
+// StreamCopyJobResults writes a CopyJob as JSON into w, streaming the requested page of results one user at a time.
+// Results are sorted according to order, which accepts the same values as FindCopyJobParams.ResultsOrder.
+// Nothing is written if the query fails, so callers can still serve an error response.
+func (d *Database) StreamCopyJobResults(ctx context.Context, w io.Writer, job *models.CopyJob, page, limit int, order string) error {
+	limit = CopyResultsLimit(&limit)
+
+	table := "user_followers"
+	if job.Type == models.JobTypeCopyFollowing {
+		table = "user_following"
+	}
+
+	order, dir := copyResultsOrder(order)
+
+	header, err := json.Marshal(copyJobHeader{
+		Job:        job.Job,
+		Metadata:   job.Metadata,
+		Total:      job.Total,
+		TotalPages: job.TotalPages(limit),
+	})
+	if err != nil {
+		return errors.Join(ErrDriverFailure, err)
+	}
+
+	// Reopen the JSON object to append the results array.
+	header = append(header[:len(header)-1], []byte(`,"results":[`)...)
+
+	enc := json.NewEncoder(w)
+	written := 0
+
+	sql := `
+	SELECT
+		user_id,
+		first_seen,
+		full_name,
+		handler,
+		last_seen,
+		pic_url
+	FROM
+		` + table + `
+	WHERE
+		account_id = $1
+	ORDER BY
+		` + order + ` ` + dir + `
+	LIMIT $2 OFFSET $3
+	`
+
+	err = d.querier.StreamUsers(ctx, d, func(u models.User) error {
+		sep := []byte(",")
+		if written == 0 {
+			sep = header
+		}
+
+		if _, err := w.Write(sep); err != nil {
+			return errors.Join(ErrDriverFailure, err)
+		}
+
+		written++
+
+		return enc.Encode(u) //nolint:wrapcheck // Writer errors are not wrapped
+	}, sql, job.Metadata.UserID, limit, page*limit)
+	if err != nil {
+		return err //nolint:wrapcheck // Error from the same package
+	}
+
+	if written == 0 {
+		if _, err := w.Write(header); err != nil {
+			return errors.Join(ErrDriverFailure, err)
+		}
+	}
+
+	if _, err := w.Write([]byte("]}\n")); err != nil {
+		return errors.Join(ErrDriverFailure, err)
+	}
+
+	return nil
+}
+
+// UpdateJob updates the specified columns in the `jobs` table. Invalid frequencies and states are discarded, and
+// nothing is executed if no columns are left to update.
+// It returns ErrJobNotFound if the job doesn't exist or was deleted. When a state is provided, it returns ErrInvalidTransition if the
+// job can't be moved to it from its current state, including when that state changes before the job is updated.
 func (d *Database) UpdateJob(ctx context.Context, params UpdateJobParams) error {
 	colsP := make([]string, 0)
 	args := make([]any, 0)
+	current := ""
+
+	if models.IsValidJobState(params.State) {
+		job, err := d.FindJob(ctx, FindJobParams{ID: params.ID}) //nolint:exhaustruct
+		if err != nil {
+			return err
+		}
+
+		if !models.IsValidStateTransition(job.State, params.State) {
+			return ErrInvalidTransition
+		}
+
+		current = job.State
+	}
 
 	if models.IsValidJobFrequency(params.Frequency) {
 		colsP = append(colsP, "metadata = jsonb_set(metadata, '{frequency}', to_jsonb($1::text))")
@@ -378,17 +688,51 @@ func (d *Database) UpdateJob(ctx context.Context, params UpdateJobParams) error
 		args = append(args, params.Label)
 	}
 
+	if len(colsP) == 0 {
+		return nil
+	}
+
+	where := []string{nextPlaceholder("id", args)}
 	args = append(args, params.ID)
-	sql := `UPDATE jobs SET ` + strings.Join(colsP, ",") + ` WHERE ` + nextPlaceholder("id", colsP)
 
-	if err := d.querier.Execute(ctx, d, sql, args...); err != nil {
+	// The transition was validated against the current state, which must not have changed in the meantime.
+	if current != "" {
+		where = append(where, nextPlaceholder("state", args))
+		args = append(args, current)
+	}
+
+	where = append(where, "deleted_at IS NULL")
+	sql := `UPDATE jobs SET ` + strings.Join(colsP, ",") + ` WHERE ` + strings.Join(where, " AND ") + ` RETURNING id`
+
+	_, err := d.querier.SelectJob(ctx, d, sql, args...)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNoRows) && current != "":
+		return ErrInvalidTransition
+	case errors.Is(err, ErrNoRows):
+		return ErrJobNotFound
+	default:
 		return err //nolint:wrapcheck // Error from the same package
 	}
+}
 
-	return nil
+// copyJobHeader is a CopyJob without its results, used when streaming them.
+type copyJobHeader struct {
+	*models.Job
+
+	Metadata   models.CopyJobMetadata `json:"metadata"`
+	Total      int32                  `json:"resultsCount"`
+	TotalPages int                    `json:"totalPages"`
+}
+
+// anyPlaceholder builds a prepared statement's placeholder that matches any of the values of an array parameter.
+func anyPlaceholder[T any](col string, where []T) string {
+	return col + " = ANY($" + strconv.Itoa(len(where)+1) + ")"
 }
 
 // nextPlaceholder builds prepared statements' placeholders.
-func nextPlaceholder(col string, where []string) string {
+func nextPlaceholder[T any](col string, where []T) string {
 	return col + " = $" + strconv.Itoa(len(where)+1)
 }
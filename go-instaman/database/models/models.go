@@ -24,6 +24,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"log/slog"
+	"math"
 	"time"
 )
 
@@ -44,21 +46,49 @@ type CopyJob struct {
 
 // CopyJobMetadata.
 type CopyJobMetadata struct {
-	Cursor    *string `json:"cursor,omitempty"`
-	Frequency string  `json:"frequency"`
-	UserID    int64   `json:"userID"` //nolint:tagliatelle // Always capitalise ID suffix.
+	Cursor     *string    `json:"cursor,omitempty"`
+	Frequency  string     `json:"frequency"`
+	LastSyncAt *time.Time `json:"lastSyncAt,omitempty"` // When the last full sync was completed.
+	UserID     int64      `json:"userID"`               //nolint:tagliatelle // Always capitalise ID suffix.
 }
 
 // Job represents a record of the `jobs` table.
 type Job struct {
-	BinData  []byte     `description:"Job's metadata as binary stream" json:"metadata" db:"metadata"`
-	ID       int64      `description:"Record PK" json:"id" db:"id"`
-	Checksum string     `description:"Job checksum to avoid duplicates" json:"checksum" db:"checksum"`
-	Type     string     `description:"Job type (copy-followers, copy-following)" json:"type" db:"job_type"`
-	Label    string     `description:"Human readable label" json:"label" db:"label"`
-	LastRun  *time.Time `description:"Last execution time" json:"lastRun" db:"last_run"`
-	NextRun  *time.Time `description:"Next scheduled time" json:"nextRun" db:"next_run"`
-	State    string     `description:"Execution's state (active, error, new, pause)" json:"state" db:"state"`
+	BinData   []byte     `description:"Job's metadata as binary stream" json:"metadata" db:"metadata"`
+	ID        int64      `description:"Record PK" json:"id" db:"id"`
+	Checksum  string     `description:"Job checksum to avoid duplicates" json:"checksum" db:"checksum"`
+	DeletedAt *time.Time `description:"Soft-deletion time" json:"deletedAt,omitempty" db:"deleted_at"`
+	Type      string     `description:"Job type (copy-followers, copy-following)" json:"type" db:"job_type"`
+	Label     string     `description:"Human readable label" json:"label" db:"label"`
+	LastRun   *time.Time `description:"Last execution time" json:"lastRun" db:"last_run"`
+	NextRun   *time.Time `description:"Next scheduled time" json:"nextRun" db:"next_run"`
+	State     string     `description:"Execution's state (active, error, new, pause)" json:"state" db:"state"`
+}
+
+// JobEvent represents a record of the `jobs_events` table.
+type JobEvent struct {
+	ID        int64     `description:"Record PK" json:"id" db:"id"`
+	JobID     int64     `description:"Job's ID" json:"jobID" db:"job_id"` //nolint:tagliatelle // Always capitalise ID suffix.
+	Message   string    `description:"Event's message" json:"message" db:"event_msg"`
+	Timestamp time.Time `description:"Event's time" json:"ts" db:"ts"`
+}
+
+// JobStats aggregates the `jobs` table and the users copied into the `user_followers` and `user_following` tables.
+type JobStats struct {
+	ByType         map[string]int64 `description:"Number of jobs per type" json:"byType" db:"by_type"`
+	ByState        map[string]int64 `description:"Number of jobs per state" json:"byState" db:"by_state"`
+	OldestNextRun  *time.Time       `description:"Earliest scheduled time" json:"oldestNextRun" db:"oldest_next_run"`
+	TotalFollowers int64            `description:"Number of copied followers" json:"totalFollowers" db:"total_followers"`
+	TotalFollowing int64            `description:"Number of copied following" json:"totalFollowing" db:"total_following"`
+}
+
+// Webhook represents a record of the `webhooks` table.
+type Webhook struct {
+	ID        int64     `description:"Record PK" json:"id" db:"id"`
+	CreatedAt time.Time `description:"Registration time" json:"createdAt" db:"created_at"`
+	Events    []string  `description:"Events that trigger the webhook" json:"events" db:"events"`
+	Secret    string    `description:"HMAC-SHA256 signing secret" json:"-" db:"secret"`
+	URL       string    `description:"Notified URL" json:"url" db:"url"`
 }
 
 // User represents an Instagram user as stored in the `user_followers` and `user_following` tables.
@@ -66,6 +96,7 @@ type User struct {
 	AccountID  int64     `description:"Account ID (relationship owner)" json:"-" db:"account_id"`
 	ID         int64     `description:"User's Instagram ID" json:"id" db:"user_id"`
 	FirstSeen  time.Time `description:"First time the connection was indexed" json:"firstSeen" db:"first_seen"`
+	FullName   string    `description:"User's full name" json:"fullName" db:"full_name"`
 	Handler    string    `description:"User's Instagram handler" json:"handler" db:"handler"`
 	LastSeen   time.Time `description:"Last time the connection was indexed" json:"lastSeen" db:"last_seen"`
 	PictureURL *string   `description:"Profile picture URL" json:"pictureURL" db:"pic_url"` //nolint:tagliatelle // Make it consistent
@@ -107,3 +138,53 @@ func NewCopyJob(j *Job) (*CopyJob, error) {
 		Total:    0,
 	}, nil
 }
+
+// TotalPages returns how many pages of pageSize users are needed to list all the results of the job.
+// It returns 0 if pageSize is not positive.
+func (c *CopyJob) TotalPages(pageSize int) int {
+	if pageSize <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(float64(c.Total) / float64(pageSize)))
+}
+
+// HasNextPage reports whether there are more results after the zero-based currentPage.
+func (c *CopyJob) HasNextPage(currentPage, pageSize int) bool {
+	return currentPage+1 < c.TotalPages(pageSize)
+}
+
+// IsTerminal reports whether the job is no longer executed by the worker, until it is manually resumed.
+func (j *Job) IsTerminal() bool {
+	return j.State == JobStateError || j.State == JobStatePaused
+}
+
+// IsScheduled reports whether the job has a next execution time.
+func (j *Job) IsScheduled() bool {
+	return j.NextRun != nil
+}
+
+// IsOverdue reports whether the job was scheduled to run before now.
+func (j *Job) IsOverdue(now time.Time) bool {
+	return j.NextRun != nil && j.NextRun.Before(now)
+}
+
+// LogValue implements slog.LogValuer, so that jobs are logged as a group with the same keys across the codebase.
+func (j *Job) LogValue() slog.Value {
+	if j == nil {
+		return slog.AnyValue(nil)
+	}
+
+	nextRun := slog.Any("nextRun", nil)
+	if j.NextRun != nil {
+		nextRun = slog.Time("nextRun", *j.NextRun)
+	}
+
+	return slog.GroupValue(
+		slog.Int64("id", j.ID),
+		slog.String("type", j.Type),
+		slog.String("state", j.State),
+		slog.String("label", j.Label),
+		nextRun,
+	)
+}
@@ -20,7 +20,9 @@
 package models_test
 
 import (
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/luca-arch/instaman/database/models"
 	"github.com/stretchr/testify/assert"
@@ -106,6 +108,19 @@ func TestNewCopyJob(t *testing.T) {
 				},
 			},
 		},
+		"valid - with monthly frequency": {
+			args{
+				in:  `{"frequency":"monthly", "userID":1}`,
+				typ: "copy-followers",
+			},
+			wants{
+				out: &models.CopyJobMetadata{
+					Cursor:    nil,
+					Frequency: "monthly",
+					UserID:    1,
+				},
+			},
+		},
 		"valid - with normalised frequency": {
 			args{
 				in:  `{"frequency":"wrong", "userID":1}`,
@@ -145,6 +160,44 @@ func TestNewCopyJob(t *testing.T) {
 				},
 			},
 		},
+		"invalid - malformed lastSyncAt": {
+			args{
+				in:  `{"lastSyncAt":"yesterday", "userID":1}`,
+				typ: "copy-following",
+			},
+			wants{
+				err: models.ErrInvalidMetadata,
+				out: nil,
+			},
+		},
+		"valid - with lastSyncAt": {
+			args{
+				in:  `{"lastSyncAt":"2025-01-01T12:00:00.123456+00:00", "userID":1}`,
+				typ: "copy-following",
+			},
+			wants{
+				out: &models.CopyJobMetadata{
+					Cursor:     nil,
+					Frequency:  "daily",
+					LastSyncAt: timePtr(t, time.Date(2025, 1, 1, 12, 0, 0, 123456000, time.UTC)),
+					UserID:     1,
+				},
+			},
+		},
+		"valid - with lastSyncAt and cursor": {
+			args{
+				in:  `{"cursor":"abcdefg", "lastSyncAt":"2025-01-01T12:00:00Z", "userID":1}`,
+				typ: "copy-following",
+			},
+			wants{
+				out: &models.CopyJobMetadata{
+					Cursor:     strPtr(t, "abcdefg"),
+					Frequency:  "daily",
+					LastSyncAt: timePtr(t, time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+					UserID:     1,
+				},
+			},
+		},
 		"valid - with null cursor": {
 			args{
 				in:  `{"cursor":null, "userID":1}`,
@@ -179,6 +232,20 @@ func TestNewCopyJob(t *testing.T) {
 			}
 
 			assert.Equal(t, int64(123), jc.ID)
+
+			// Times are compared separately as their location depends on the environment.
+			if want := test.wants.out.LastSyncAt; want != nil {
+				if assert.NotNil(t, jc.Metadata.LastSyncAt) {
+					assert.True(t, want.Equal(*jc.Metadata.LastSyncAt))
+				}
+
+				wantOut := *test.wants.out
+				wantOut.LastSyncAt = jc.Metadata.LastSyncAt
+				assert.Equal(t, &wantOut, &jc.Metadata)
+
+				return
+			}
+
 			assert.Equal(t, test.wants.out, &jc.Metadata)
 		})
 	}
@@ -189,3 +256,180 @@ func strPtr(t *testing.T, str string) *string {
 
 	return &str
 }
+
+func timePtr(t *testing.T, tm time.Time) *time.Time {
+	t.Helper()
+
+	return &tm
+}
+
+func TestJobLogValue(t *testing.T) {
+	t.Parallel()
+
+	nextRun := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		in   *models.Job
+		want []slog.Attr
+	}{
+		"scheduled job": {
+			in: &models.Job{
+				ID:      123,
+				Label:   "Test label",
+				NextRun: &nextRun,
+				State:   models.JobStateActive,
+				Type:    models.JobTypeCopyFollowers,
+			},
+			want: []slog.Attr{
+				slog.Int64("id", 123),
+				slog.String("type", models.JobTypeCopyFollowers),
+				slog.String("state", models.JobStateActive),
+				slog.String("label", "Test label"),
+				slog.Time("nextRun", nextRun),
+			},
+		},
+		"unscheduled job": {
+			in: &models.Job{
+				ID:    456,
+				Label: "Other label",
+				State: models.JobStateError,
+				Type:  models.JobTypeCopyFollowing,
+			},
+			want: []slog.Attr{
+				slog.Int64("id", 456),
+				slog.String("type", models.JobTypeCopyFollowing),
+				slog.String("state", models.JobStateError),
+				slog.String("label", "Other label"),
+				slog.Any("nextRun", nil),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			val := test.in.LogValue()
+
+			assert.Equal(t, slog.KindGroup, val.Kind())
+			assert.Equal(t, test.want, val.Group())
+
+			// A CopyJob is logged the same way, through its embedded Job.
+			cj := &models.CopyJob{Job: test.in} //nolint:exhaustruct
+			assert.Equal(t, val, slog.AnyValue(cj).Resolve())
+		})
+	}
+}
+
+func TestJobStateHelpers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	type wants struct {
+		overdue   bool
+		scheduled bool
+		terminal  bool
+	}
+
+	tests := map[string]struct {
+		in *models.Job
+		wants
+	}{
+		"new job, not scheduled": {
+			in:    &models.Job{State: models.JobStateNew, NextRun: nil}, //nolint:exhaustruct
+			wants: wants{overdue: false, scheduled: false, terminal: false},
+		},
+		"active job, due in the future": {
+			in:    &models.Job{State: models.JobStateActive, NextRun: &future}, //nolint:exhaustruct
+			wants: wants{overdue: false, scheduled: true, terminal: false},
+		},
+		"active job, due in the past": {
+			in:    &models.Job{State: models.JobStateActive, NextRun: &past}, //nolint:exhaustruct
+			wants: wants{overdue: true, scheduled: true, terminal: false},
+		},
+		"active job, due now": {
+			in:    &models.Job{State: models.JobStateActive, NextRun: &now}, //nolint:exhaustruct
+			wants: wants{overdue: false, scheduled: true, terminal: false},
+		},
+		"errored job, not scheduled": {
+			in:    &models.Job{State: models.JobStateError, NextRun: nil}, //nolint:exhaustruct
+			wants: wants{overdue: false, scheduled: false, terminal: true},
+		},
+		"paused job, due in the past": {
+			in:    &models.Job{State: models.JobStatePaused, NextRun: &past}, //nolint:exhaustruct
+			wants: wants{overdue: true, scheduled: true, terminal: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.wants.overdue, test.in.IsOverdue(now))
+			assert.Equal(t, test.wants.scheduled, test.in.IsScheduled())
+			assert.Equal(t, test.wants.terminal, test.in.IsTerminal())
+		})
+	}
+}
+
+func TestCopyJobPages(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		currentPage int
+		pageSize    int
+		total       int32
+	}
+
+	type wants struct {
+		hasNext    bool
+		totalPages int
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"no results": {
+			args{currentPage: 0, pageSize: 100, total: 0},
+			wants{hasNext: false, totalPages: 0},
+		},
+		"single partial page": {
+			args{currentPage: 0, pageSize: 100, total: 2},
+			wants{hasNext: false, totalPages: 1},
+		},
+		"exactly one full page": {
+			args{currentPage: 0, pageSize: 100, total: 100},
+			wants{hasNext: false, totalPages: 1},
+		},
+		"first of several pages": {
+			args{currentPage: 0, pageSize: 100, total: 250},
+			wants{hasNext: true, totalPages: 3},
+		},
+		"last of several pages": {
+			args{currentPage: 2, pageSize: 100, total: 250},
+			wants{hasNext: false, totalPages: 3},
+		},
+		"past the last page": {
+			args{currentPage: 5, pageSize: 100, total: 250},
+			wants{hasNext: false, totalPages: 3},
+		},
+		"invalid page size": {
+			args{currentPage: 0, pageSize: 0, total: 250},
+			wants{hasNext: false, totalPages: 0},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cj := &models.CopyJob{Total: test.total} //nolint:exhaustruct
+
+			assert.Equal(t, test.wants.totalPages, cj.TotalPages(test.pageSize))
+			assert.Equal(t, test.wants.hasNext, cj.HasNextPage(test.currentPage, test.pageSize))
+		})
+	}
+}
@@ -19,8 +19,11 @@
 
 package models
 
+import "slices"
+
 const (
 	JobFrequencyDaily    = "daily"
+	JobFrequencyMonthly  = "monthly"
 	JobFrequencyWeekly   = "weekly"
 	JobStateActive       = "active"
 	JobStateError        = "error"
@@ -30,10 +33,21 @@ const (
 	JobTypeCopyFollowing = "copy-following"
 )
 
+// StateTransitions lists, for each job state, the states a job can be moved to.
+// New jobs can also go straight to error, as the worker executes them without activating them first.
+//
+//nolint:gochecknoglobals // Read-only
+var StateTransitions = map[string][]string{
+	JobStateNew:    {JobStateActive, JobStateError, JobStatePaused},
+	JobStateActive: {JobStateError, JobStatePaused},
+	JobStatePaused: {JobStateActive},
+	JobStateError:  {JobStateActive},
+}
+
 // IsValidJobFrequency return whether job frequency is a valid value for the jobs.metadata ->> frequency column.
 func IsValidJobFrequency(jobFreq string) bool {
 	switch jobFreq {
-	case JobFrequencyDaily, JobFrequencyWeekly:
+	case JobFrequencyDaily, JobFrequencyMonthly, JobFrequencyWeekly:
 		return true
 	default:
 		return false
@@ -59,3 +73,9 @@ func IsValidJobType(jobType string) bool {
 		return false
 	}
 }
+
+// IsValidStateTransition returns whether a job can be moved from one state to another, according to StateTransitions.
+// Keeping the same state is always allowed.
+func IsValidStateTransition(from, to string) bool {
+	return from == to || slices.Contains(StateTransitions[from], to)
+}
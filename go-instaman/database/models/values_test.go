@@ -57,6 +57,14 @@ func TestIsValidJobFrequency(t *testing.T) {
 				out: true,
 			},
 		},
+		"valid - monthly": {
+			args{
+				in: "monthly",
+			},
+			wants{
+				out: true,
+			},
+		},
 		"invalid - blank": {
 			args{
 				in: "",
@@ -215,3 +223,82 @@ func TestIsValidJobType(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidStateTransition(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		from string
+		to   string
+	}
+
+	type wants struct {
+		out bool
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"valid - new to active": {
+			args{from: "new", to: "active"},
+			wants{out: true},
+		},
+		"valid - new to pause": {
+			args{from: "new", to: "pause"},
+			wants{out: true},
+		},
+		"valid - new to error": {
+			args{from: "new", to: "error"},
+			wants{out: true},
+		},
+		"valid - active to pause": {
+			args{from: "active", to: "pause"},
+			wants{out: true},
+		},
+		"valid - active to error": {
+			args{from: "active", to: "error"},
+			wants{out: true},
+		},
+		"valid - pause to active": {
+			args{from: "pause", to: "active"},
+			wants{out: true},
+		},
+		"valid - error to active": {
+			args{from: "error", to: "active"},
+			wants{out: true},
+		},
+		"valid - same state": {
+			args{from: "pause", to: "pause"},
+			wants{out: true},
+		},
+		"invalid - error to new": {
+			args{from: "error", to: "new"},
+			wants{out: false},
+		},
+		"invalid - error to pause": {
+			args{from: "error", to: "pause"},
+			wants{out: false},
+		},
+		"invalid - active to new": {
+			args{from: "active", to: "new"},
+			wants{out: false},
+		},
+		"invalid - pause to error": {
+			args{from: "pause", to: "error"},
+			wants{out: false},
+		},
+		"invalid - unknown state": {
+			args{from: "fatal", to: "active"},
+			wants{out: false},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.wants.out, models.IsValidStateTransition(test.args.from, test.args.to))
+		})
+	}
+}
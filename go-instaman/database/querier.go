@@ -21,23 +21,45 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/luca-arch/instaman/database/models"
 )
 
 // querier defines an interface to abstract all database queries.
 // It is only ever useful for testing purposes.
 type querier interface {
+	Batch(context.Context, *Database, *pgx.Batch) error
+	CopyFrom(context.Context, *Database, *CopyUpsert) (int64, error)
 	Count(context.Context, *Database, string, ...any) (int32, error)
 	Execute(context.Context, *Database, string, ...any) error
 	SelectJob(context.Context, *Database, string, ...any) (*models.Job, error)
+	SelectJobEvents(context.Context, *Database, string, ...any) ([]models.JobEvent, error)
+	SelectJobStats(context.Context, *Database, string, ...any) (*models.JobStats, error)
 	SelectJobs(context.Context, *Database, string, ...any) ([]models.Job, error)
 	SelectUsers(context.Context, *Database, string, ...any) ([]models.User, error)
+	SelectWebhooks(context.Context, *Database, string, ...any) ([]models.Webhook, error)
+	StreamUsers(context.Context, *Database, func(models.User) error, string, ...any) error
+	Tx(context.Context, *Database, func(*Database) error) error
 }
 
-// Querier is the default querier that simply calls Count, Select, SelectOne and Execute.
+// Querier is the default querier that simply calls Batch, CopyFrom, Count, Select, SelectOne, Stream, Execute and
+// Transaction.
 type Querier struct{}
 
+// Batch calls the Batch function to send all the queued queries at once.
+func (q *Querier) Batch(ctx context.Context, db *Database, batch *pgx.Batch) error {
+	return Batch(ctx, db, batch)
+}
+
+// CopyFrom calls the CopyFrom function to bulk upsert rows, and returns the number of affected rows.
+func (q *Querier) CopyFrom(ctx context.Context, db *Database, c *CopyUpsert) (int64, error) {
+	return CopyFrom(ctx, db, c)
+}
+
 // Count calls the Count function to return the number of counted records.
 func (q *Querier) Count(ctx context.Context, db *Database, sql string, args ...any) (int32, error) {
 	return Count(ctx, db, sql, args...)
@@ -53,6 +75,16 @@ func (q *Querier) SelectJob(ctx context.Context, db *Database, sql string, args
 	return SelectOne[models.Job](ctx, db, sql, args...)
 }
 
+// SelectJobEvents calls the Select function to return a list of `JobEvent` objects.
+func (q *Querier) SelectJobEvents(ctx context.Context, db *Database, sql string, args ...any) ([]models.JobEvent, error) {
+	return Select[models.JobEvent](ctx, db, sql, args...)
+}
+
+// SelectJobStats calls the SelectOneMapped function to return a `JobStats` object.
+func (q *Querier) SelectJobStats(ctx context.Context, db *Database, sql string, args ...any) (*models.JobStats, error) {
+	return SelectOneMapped(ctx, db, sql, rowToJobStats, args...)
+}
+
 // SelectJobs calls the Select function to return a list of `Job` objects.
 func (q *Querier) SelectJobs(ctx context.Context, db *Database, sql string, args ...any) ([]models.Job, error) {
 	return Select[models.Job](ctx, db, sql, args...)
@@ -62,3 +94,125 @@ func (q *Querier) SelectJobs(ctx context.Context, db *Database, sql string, args
 func (q *Querier) SelectUsers(ctx context.Context, db *Database, sql string, args ...any) ([]models.User, error) {
 	return Select[models.User](ctx, db, sql, args...)
 }
+
+// SelectWebhooks calls the Select function to return a list of `Webhook` objects.
+func (q *Querier) SelectWebhooks(ctx context.Context, db *Database, sql string, args ...any) ([]models.Webhook, error) {
+	return Select[models.Webhook](ctx, db, sql, args...)
+}
+
+// StreamUsers calls the Stream function to iterate over a list of `User` objects.
+func (q *Querier) StreamUsers(ctx context.Context, db *Database, fn func(models.User) error, sql string, args ...any) error {
+	return Stream[models.User](ctx, db, fn, sql, args...)
+}
+
+// Tx calls the Transaction function to execute fn's queries within a single transaction.
+func (q *Querier) Tx(ctx context.Context, db *Database, fn func(*Database) error) error {
+	return Transaction(ctx, db, fn)
+}
+
+// PreparedQuerier is a querier that executes the most frequent queries as named prepared statements,
+// so PostgreSQL does not need to plan them again on every call.
+// Statements must be registered on each new connection via AfterConnect; any other query is executed as is.
+type PreparedQuerier struct {
+	Querier
+
+	names map[string]string // Statement names keyed by their SQL.
+}
+
+// NewPreparedQuerier returns a PreparedQuerier for the provided SQL statements.
+// If none is provided, the default high-frequency statements are used.
+func NewPreparedQuerier(statements ...string) *PreparedQuerier {
+	if len(statements) == 0 {
+		statements = []string{
+			sqlNextJob,
+			fmt.Sprintf(sqlUpsertUser, "user_followers"),
+			fmt.Sprintf(sqlUpsertUser, "user_following"),
+		}
+	}
+
+	names := make(map[string]string, len(statements))
+
+	for i, sql := range statements {
+		names[sql] = "instaman_stmt_" + strconv.Itoa(i)
+	}
+
+	return &PreparedQuerier{
+		Querier: Querier{},
+		names:   names,
+	}
+}
+
+// AfterConnect prepares all the registered statements on a new connection.
+// It satisfies the pgxpool.Config.AfterConnect signature.
+func (q *PreparedQuerier) AfterConnect(ctx context.Context, conn *pgx.Conn) error {
+	for sql, name := range q.names {
+		if _, err := conn.Prepare(ctx, name, sql); err != nil {
+			return errors.Join(ErrDatabaseFailure, err)
+		}
+	}
+
+	return nil
+}
+
+// Statement returns the name of the prepared statement for sql, or sql itself if it was not registered.
+func (q *PreparedQuerier) Statement(sql string) string {
+	if name, ok := q.names[sql]; ok {
+		return name
+	}
+
+	return sql
+}
+
+// Batch calls the Batch function replacing the queued queries with their prepared statements.
+func (q *PreparedQuerier) Batch(ctx context.Context, db *Database, batch *pgx.Batch) error {
+	for _, qq := range batch.QueuedQueries {
+		qq.SQL = q.Statement(qq.SQL)
+	}
+
+	return Batch(ctx, db, batch)
+}
+
+// Count calls the Count function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) Count(ctx context.Context, db *Database, sql string, args ...any) (int32, error) {
+	return Count(ctx, db, q.Statement(sql), args...)
+}
+
+// Execute calls the Execute function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) Execute(ctx context.Context, db *Database, sql string, args ...any) error {
+	return Execute(ctx, db, q.Statement(sql), args...)
+}
+
+// SelectJob calls the SelectOne function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) SelectJob(ctx context.Context, db *Database, sql string, args ...any) (*models.Job, error) {
+	return SelectOne[models.Job](ctx, db, q.Statement(sql), args...)
+}
+
+// SelectJobEvents calls the Select function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) SelectJobEvents(ctx context.Context, db *Database, sql string, args ...any) ([]models.JobEvent, error) {
+	return Select[models.JobEvent](ctx, db, q.Statement(sql), args...)
+}
+
+// SelectJobStats calls the SelectOneMapped function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) SelectJobStats(ctx context.Context, db *Database, sql string, args ...any) (*models.JobStats, error) {
+	return SelectOneMapped(ctx, db, q.Statement(sql), rowToJobStats, args...)
+}
+
+// SelectJobs calls the Select function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) SelectJobs(ctx context.Context, db *Database, sql string, args ...any) ([]models.Job, error) {
+	return Select[models.Job](ctx, db, q.Statement(sql), args...)
+}
+
+// SelectUsers calls the Select function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) SelectUsers(ctx context.Context, db *Database, sql string, args ...any) ([]models.User, error) {
+	return Select[models.User](ctx, db, q.Statement(sql), args...)
+}
+
+// SelectWebhooks calls the Select function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) SelectWebhooks(ctx context.Context, db *Database, sql string, args ...any) ([]models.Webhook, error) {
+	return Select[models.Webhook](ctx, db, q.Statement(sql), args...)
+}
+
+// StreamUsers calls the Stream function with the prepared statement for sql, if any.
+func (q *PreparedQuerier) StreamUsers(ctx context.Context, db *Database, fn func(models.User) error, sql string, args ...any) error {
+	return Stream[models.User](ctx, db, fn, q.Statement(sql), args...)
+}
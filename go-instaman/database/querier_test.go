@@ -0,0 +1,48 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package database_test
+
+import (
+	"testing"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreparedQuerierStatement(t *testing.T) {
+	t.Parallel()
+
+	q := database.NewPreparedQuerier("SELECT 1", "SELECT 2")
+
+	assert.Equal(t, "instaman_stmt_0", q.Statement("SELECT 1"))
+	assert.Equal(t, "instaman_stmt_1", q.Statement("SELECT 2"))
+	assert.Equal(t, "SELECT 3", q.Statement("SELECT 3"))
+
+	// Default statements.
+	q = database.NewPreparedQuerier()
+
+	assert.Equal(t, "instaman_stmt_1", q.Statement(`
+		INSERT INTO user_followers (account_id, first_seen, handler, last_seen, pic_url, user_id, full_name)
+			VALUES ($1, NOW(), $2, NOW(), $3, $4, $5)
+		ON CONFLICT (account_id, user_id) DO UPDATE
+			SET last_seen = NOW(), handler = $2, pic_url = $3, full_name = $5
+	`))
+	assert.Equal(t, "SELECT 1", q.Statement("SELECT 1"))
+}
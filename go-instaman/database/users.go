@@ -0,0 +1,64 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package database
+
+import (
+	"context"
+)
+
+// UpdateUserPictureParams defines the input data for UpdateUserPicture().
+type UpdateUserPictureParams struct {
+	AccountID  int64  `in:"accountID,required" json:"accountID"` //nolint:tagliatelle // Always capitalise ID suffix.
+	Direction  string `in:"direction,required" json:"direction"`
+	PictureURL string `in:"-" json:"pictureURL"`                //nolint:tagliatelle // Make it consistent
+	UserID     int64  `in:"userID,path,required" json:"userID"` //nolint:tagliatelle // Always capitalise ID suffix.
+}
+
+// UpdateUserPicture updates the picture URL of a single user in the `user_followers` or `user_following` table,
+// depending on direction, and marks the user as seen. An empty picURL resets the picture.
+func (d *Database) UpdateUserPicture(ctx context.Context, accountID, userID int64, direction, picURL string) error {
+	var table string
+
+	switch direction {
+	case "followers":
+		table = "user_followers"
+	case "following":
+		table = "user_following"
+	default:
+		return ErrFindCopyJobParams
+	}
+
+	if accountID < 1 || userID < 1 {
+		return ErrInvalidID
+	}
+
+	var pic *string
+	if picURL != "" {
+		pic = &picURL
+	}
+
+	sql := `UPDATE ` + table + ` SET pic_url = $1, last_seen = NOW() WHERE account_id = $2 AND user_id = $3`
+
+	if err := d.querier.Execute(ctx, d, sql, pic, accountID, userID); err != nil {
+		return err //nolint:wrapcheck // Error from the same package
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpdateUserPicture(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	mockErr := errors.New("mock error")
+	picURL := "https://example.com/pic.jpeg"
+
+	var nilString *string
+
+	type args struct {
+		accountID int64
+		direction string
+		picURL    string
+		userID    int64
+	}
+
+	type fields struct {
+		querier func() *mockQuerier
+	}
+
+	type wants struct {
+		err error
+	}
+
+	tests := map[string]struct {
+		args
+		fields
+		wants
+	}{
+		"followers - ok": {
+			args{accountID: 1, direction: "followers", picURL: picURL, userID: 2},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					expectedSQL := oneLineSQL(`
+					UPDATE user_followers SET pic_url = $1, last_seen = NOW()
+					WHERE account_id = $2 AND user_id = $3`)
+
+					q := &mockQuerier{}
+
+					q.On("Execute", ctx, mock.AnythingOfType("*database.Database"), expectedSQL, &picURL, int64(1), int64(2)).
+						Return(nil)
+
+					return q
+				},
+			},
+			wants{},
+		},
+		"following, reset picture - ok": {
+			args{accountID: 1, direction: "following", picURL: "", userID: 2},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					expectedSQL := oneLineSQL(`
+					UPDATE user_following SET pic_url = $1, last_seen = NOW()
+					WHERE account_id = $2 AND user_id = $3`)
+
+					q := &mockQuerier{}
+
+					q.On("Execute", ctx, mock.AnythingOfType("*database.Database"), expectedSQL, nilString, int64(1), int64(2)).
+						Return(nil)
+
+					return q
+				},
+			},
+			wants{},
+		},
+		"invalid direction - err": {
+			args{accountID: 1, direction: "fololo", picURL: picURL, userID: 2},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					return &mockQuerier{}
+				},
+			},
+			wants{err: database.ErrFindCopyJobParams},
+		},
+		"invalid user ID - err": {
+			args{accountID: 1, direction: "followers", picURL: picURL, userID: 0},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					return &mockQuerier{}
+				},
+			},
+			wants{err: database.ErrInvalidID},
+		},
+		"query error": {
+			args{accountID: 1, direction: "following", picURL: picURL, userID: 2},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					q := &mockQuerier{}
+
+					q.On("Execute", ctx, mock.AnythingOfType("*database.Database"), mock.Anything, &picURL, int64(1), int64(2)).
+						Return(mockErr)
+
+					return q
+				},
+			},
+			wants{err: mockErr},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := test.fields.querier()
+			db := mockPool(t).
+				WithQuerier(q)
+
+			err := db.UpdateUserPicture(ctx, test.args.accountID, test.args.userID, test.args.direction, test.args.picURL)
+
+			q.AssertExpectations(t)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
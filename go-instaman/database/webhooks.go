@@ -0,0 +1,85 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luca-arch/instaman/database/models"
+)
+
+var ErrInvalidWebhook = errors.New("invalid webhook") // Missing URL, secret or events.
+
+// NewWebhookParams defines the input data for NewWebhook().
+type NewWebhookParams struct {
+	Events []string
+	Secret string
+	URL    string
+}
+
+// FindWebhooks returns all the webhooks that subscribed to event.
+func (d *Database) FindWebhooks(ctx context.Context, event string) ([]models.Webhook, error) {
+	sql := `
+	SELECT
+		id,
+		created_at,
+		events,
+		secret,
+		url
+	FROM
+		webhooks
+	WHERE
+		$1 = ANY(events)
+	ORDER BY
+		id ASC
+	`
+
+	webhooks, err := d.querier.SelectWebhooks(ctx, d, sql, event)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Error from the same package
+	}
+
+	return webhooks, nil
+}
+
+// NewWebhook creates a new Webhook in the `webhooks` table.
+func (d *Database) NewWebhook(ctx context.Context, params NewWebhookParams) (*models.Webhook, error) {
+	if params.URL == "" || params.Secret == "" || len(params.Events) == 0 {
+		return nil, ErrInvalidWebhook
+	}
+
+	sql := `
+	INSERT INTO webhooks (created_at, events, secret, url)
+	VALUES (NOW(), $1, $2, $3)
+	RETURNING *
+	`
+
+	webhooks, err := d.querier.SelectWebhooks(ctx, d, sql, params.Events, params.Secret, params.URL)
+
+	switch {
+	case err != nil:
+		return nil, err //nolint:wrapcheck // Error from the same package
+	case len(webhooks) != 1:
+		return nil, ErrDriverFailure
+	}
+
+	return &webhooks[0], nil
+}
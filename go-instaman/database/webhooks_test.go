@@ -0,0 +1,255 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package database_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFindWebhooks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	mockErr := errors.New("mock error")
+
+	mockWebhooks := []models.Webhook{
+		{
+			ID:     1,
+			Events: []string{"job.completed"},
+			Secret: "s3cr3t",
+			URL:    "https://example.com/hook",
+		},
+	}
+
+	expectedSQL := oneLineSQL(`
+	SELECT id, created_at, events, secret, url
+	FROM webhooks
+	WHERE $1 = ANY(events)
+	ORDER BY id ASC`)
+
+	type fields struct {
+		querier func() *mockQuerier
+	}
+
+	type wants struct {
+		err error
+		out []models.Webhook
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"select - ok": {
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					q := &mockQuerier{}
+
+					q.On("SelectWebhooks", ctx, mock.AnythingOfType("*database.Database"), expectedSQL, "job.completed").
+						Return(mockWebhooks, nil)
+
+					return q
+				},
+			},
+			wants{
+				out: mockWebhooks,
+			},
+		},
+		"select - error": {
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					var w []models.Webhook
+
+					q := &mockQuerier{}
+
+					q.On("SelectWebhooks", ctx, mock.AnythingOfType("*database.Database"), expectedSQL, "job.completed").
+						Return(w, mockErr)
+
+					return q
+				},
+			},
+			wants{
+				err: mockErr,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := test.fields.querier()
+			db := mockPool(t).
+				WithQuerier(q)
+
+			out, err := db.FindWebhooks(ctx, "job.completed")
+
+			q.AssertExpectations(t)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	mockErr := errors.New("mock error")
+
+	mockWebhook := models.Webhook{
+		ID:     1,
+		Events: []string{"job.completed"},
+		Secret: "s3cr3t",
+		URL:    "https://example.com/hook",
+	}
+
+	expectedSQL := oneLineSQL(`
+	INSERT INTO webhooks (created_at, events, secret, url)
+	VALUES (NOW(), $1, $2, $3)
+	RETURNING *`)
+
+	validParams := database.NewWebhookParams{
+		Events: []string{"job.completed"},
+		Secret: "s3cr3t",
+		URL:    "https://example.com/hook",
+	}
+
+	type args struct {
+		in database.NewWebhookParams
+	}
+
+	type fields struct {
+		querier func() *mockQuerier
+	}
+
+	type wants struct {
+		err error
+		out *models.Webhook
+	}
+
+	tests := map[string]struct {
+		args
+		fields
+		wants
+	}{
+		"insert - ok": {
+			args{
+				in: validParams,
+			},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					q := &mockQuerier{}
+
+					q.On("SelectWebhooks", ctx, mock.AnythingOfType("*database.Database"), expectedSQL, []string{"job.completed"}, "s3cr3t", "https://example.com/hook").
+						Return([]models.Webhook{mockWebhook}, nil)
+
+					return q
+				},
+			},
+			wants{
+				out: &mockWebhook,
+			},
+		},
+		"insert - error": {
+			args{
+				in: validParams,
+			},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					var w []models.Webhook
+
+					q := &mockQuerier{}
+
+					q.On("SelectWebhooks", ctx, mock.AnythingOfType("*database.Database"), expectedSQL, []string{"job.completed"}, "s3cr3t", "https://example.com/hook").
+						Return(w, mockErr)
+
+					return q
+				},
+			},
+			wants{
+				err: mockErr,
+			},
+		},
+		"missing events": {
+			args{
+				in: database.NewWebhookParams{
+					Secret: "s3cr3t",
+					URL:    "https://example.com/hook",
+				},
+			},
+			fields{
+				querier: func() *mockQuerier {
+					t.Helper()
+
+					return &mockQuerier{}
+				},
+			},
+			wants{
+				err: database.ErrInvalidWebhook,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := test.fields.querier()
+			db := mockPool(t).
+				WithQuerier(q)
+
+			out, err := db.NewWebhook(ctx, test.args.in)
+
+			q.AssertExpectations(t)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
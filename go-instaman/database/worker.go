@@ -23,6 +23,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -30,21 +31,60 @@ import (
 	"github.com/luca-arch/instaman/instaproxy"
 )
 
-// InsertJobEvent registers a new event in the jobs' audit logs table.
-func (d *Database) InsertJobEvent(ctx context.Context, jobID int64, event string) error {
-	sqlEvent := `INSERT INTO jobs_events (event_msg, job_id, ts) VALUES ($1, $2, NOW())`
-
-	if err := d.querier.Execute(ctx, d, sqlEvent, event, jobID); err != nil {
-		return err //nolint:wrapcheck // Error from the same package
-	}
-
-	return nil
-}
+const (
+	// sqlNextJob claims the next job that is ready for execution, by pushing its `next_run` one hour ahead.
+	// Rows locked by a concurrent claim are skipped, so that each job is returned to one caller only.
+	sqlNextJob = `
+	UPDATE jobs
+		SET next_run = NOW() + INTERVAL '1 HOUR'
+	WHERE id = (
+		SELECT
+			id
+		FROM
+			jobs
+		WHERE
+			job_type = $1
+			AND next_run IS NOT NULL
+			AND next_run < NOW()
+			AND state IN ($2, $3)
+			AND deleted_at IS NULL
+		ORDER BY
+			next_run ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING
+		id,
+		checksum,
+		job_type,
+		label,
+		last_run,
+		metadata,
+		next_run,
+		state
+	`
 
-// NextJob returns the first job that is ready for execution.
-func (d *Database) NextJob(ctx context.Context, jobType string) (*models.Job, error) {
-	sql := `
-	SELECT
+	// sqlNextImmediateJob claims the active job with the earliest `next_run`, even if it is not due yet, the same way
+	// sqlNextJob does.
+	sqlNextImmediateJob = `
+	UPDATE jobs
+		SET next_run = NOW() + INTERVAL '1 HOUR'
+	WHERE id = (
+		SELECT
+			id
+		FROM
+			jobs
+		WHERE
+			job_type = $1
+			AND next_run IS NOT NULL
+			AND state = $2
+			AND deleted_at IS NULL
+		ORDER BY
+			next_run ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING
 		id,
 		checksum,
 		job_type,
@@ -53,24 +93,125 @@ func (d *Database) NextJob(ctx context.Context, jobType string) (*models.Job, er
 		metadata,
 		next_run,
 		state
-	FROM
-		jobs
-	WHERE
-		job_type = $1
-		AND next_run IS NOT NULL
-		AND next_run < NOW()
-		AND state IN ($2, $3)
-	ORDER BY
-		next_run ASC
-	LIMIT 1
 	`
 
-	job, err := d.querier.SelectJob(ctx, d, sql, jobType, models.JobStateActive, models.JobStateNew)
+	// sqlCreateBulkUsers creates the temporary table BulkInsertFollowers and BulkInsertFollowing copy users into.
+	sqlCreateBulkUsers = `
+		CREATE TEMPORARY TABLE bulk_users (
+			user_id   BIGINT NOT NULL,
+			handler   TEXT   NOT NULL,
+			pic_url   TEXT,
+			full_name TEXT   NOT NULL
+		) ON COMMIT DROP
+	`
+
+	// sqlMergeBulkUsers upserts the content of the temporary table into the table specified by the placeholder.
+	// Users that are listed more than once are only upserted once.
+	sqlMergeBulkUsers = `
+		INSERT INTO %s (account_id, first_seen, handler, last_seen, pic_url, user_id, full_name)
+			SELECT DISTINCT ON (user_id) $1::BIGINT, NOW(), handler, NOW(), pic_url, user_id, full_name FROM bulk_users
+		ON CONFLICT (account_id, user_id) DO UPDATE
+			SET last_seen = NOW(), handler = EXCLUDED.handler, pic_url = EXCLUDED.pic_url, full_name = EXCLUDED.full_name
+	`
+
+	// sqlUpsertUser inserts or updates a connection in the table specified by the placeholder.
+	sqlUpsertUser = `
+		INSERT INTO %s (account_id, first_seen, handler, last_seen, pic_url, user_id, full_name)
+			VALUES ($1, NOW(), $2, NOW(), $3, $4, $5)
+		ON CONFLICT (account_id, user_id) DO UPDATE
+			SET last_seen = NOW(), handler = $2, pic_url = $3, full_name = $5
+	`
+)
+
+// BulkInsertThreshold is the number of users from which StoreCopyJobResults switches from pipelined upserts to the
+// COPY protocol.
+const BulkInsertThreshold = 1000
+
+// BulkInsertFollowers upserts many users at once into the `user_followers` table, using the COPY protocol.
+// It returns how many users were stored, and how many were skipped because invalid or listed more than once.
+func (d *Database) BulkInsertFollowers(ctx context.Context, accountID int64, users []instaproxy.User) (int, int, error) {
+	return d.bulkInsertUsers(ctx, "user_followers", accountID, users)
+}
+
+// BulkInsertFollowing upserts many users at once into the `user_following` table, using the COPY protocol.
+// It returns how many users were stored, and how many were skipped because invalid or listed more than once.
+func (d *Database) BulkInsertFollowing(ctx context.Context, accountID int64, users []instaproxy.User) (int, int, error) {
+	return d.bulkInsertUsers(ctx, "user_following", accountID, users)
+}
+
+// bulkInsertUsers copies users into a temporary table, and then merges it into table with a single statement.
+func (d *Database) bulkInsertUsers(ctx context.Context, table string, accountID int64, users []instaproxy.User) (int, int, error) {
+	if accountID < 1 {
+		return 0, 0, ErrInvalidID
+	}
+
+	rows := make([][]any, 0, len(users))
+
+	for _, u := range users {
+		if u.ID < 1 || u.Handler == "" {
+			continue
+		}
+
+		rows = append(rows, []any{u.ID, u.Handler, urlStringPtr(u.PictureURL), u.FullName})
+	}
+
+	if len(rows) == 0 {
+		return 0, len(users), nil
+	}
+
+	affected, err := d.querier.CopyFrom(ctx, d, &CopyUpsert{
+		CreateSQL: sqlCreateBulkUsers,
+		TempTable: "bulk_users",
+		Columns:   []string{"user_id", "handler", "pic_url", "full_name"},
+		Rows:      rows,
+		MergeSQL:  fmt.Sprintf(sqlMergeBulkUsers, table),
+		MergeArgs: []any{accountID},
+	})
+	if err != nil {
+		return 0, 0, err //nolint:wrapcheck // Error from the same package
+	}
+
+	return int(affected), len(users) - int(affected), nil
+}
+
+// InsertJobEvent registers a new event in the jobs' audit logs table, along with the hostname of the worker that
+// generated it. An empty hostname is stored as NULL.
+func (d *Database) InsertJobEvent(ctx context.Context, jobID int64, event, hostname string) error {
+	sqlEvent := `INSERT INTO jobs_events (event_msg, job_id, ts, worker_host) VALUES ($1, $2, NOW(), NULLIF($3, ''))`
+
+	if err := d.querier.Execute(ctx, d, sqlEvent, event, jobID, hostname); err != nil {
+		return err //nolint:wrapcheck // Error from the same package
+	}
+
+	return nil
+}
+
+// NextJob claims and returns the first job that is ready for execution.
+// The claimed job is not returned again for one hour, or until it is rescheduled with ScheduleJob: this way, a job
+// that is still running is not picked up twice, and one whose worker died is eventually retried.
+func (d *Database) NextJob(ctx context.Context, jobType string) (*models.Job, error) {
+	job, err := d.querier.SelectJob(ctx, d, sqlNextJob, jobType, models.JobStateActive, models.JobStateNew)
+
+	switch {
+	case err == nil:
+		return job, nil
+	case errors.Is(err, ErrNoRows):
+		return nil, nil //nolint:nilnil // It means not found.
+	default:
+		return nil, err //nolint:wrapcheck // Error from the same package
+	}
+}
+
+// NextImmediateJob claims and returns the active job with the earliest `next_run`, regardless of whether it is due yet.
+// This lets a job that an operator has just resumed run without waiting for its schedule. As with NextJob, the claimed
+// job is not returned again for one hour, or until it is rescheduled with ScheduleJob.
+func (d *Database) NextImmediateJob(ctx context.Context, jobType string) (*models.Job, error) {
+	job, err := d.querier.SelectJob(ctx, d, sqlNextImmediateJob, jobType, models.JobStateActive)
 
 	switch {
 	case err == nil:
 		return job, nil
-	case errors.Is(err, pgx.ErrNoRows):
+	case errors.Is(err, ErrNoRows):
 		return nil, nil //nolint:nilnil // It means not found.
 	default:
 		return nil, err //nolint:wrapcheck // Error from the same package
@@ -78,68 +219,120 @@ func (d *Database) NextJob(ctx context.Context, jobType string) (*models.Job, er
 }
 
 // ScheduleJob updates a job's `next_run` column.
+// It returns ErrJobNotFound if the job doesn't exist or was deleted.
 func (d *Database) ScheduleJob(ctx context.Context, jobID int64, nextRun time.Duration) error {
 	interval := fmt.Sprintf("%d SECOND", int(nextRun.Seconds()))
 	sqlUpdate := `
 		UPDATE jobs
 			SET next_run = NOW() + INTERVAL '` + interval + `',
 			state = $1
-		WHERE id = $2
+		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING id
 	`
 
-	if err := d.querier.Execute(ctx, d, sqlUpdate, models.JobStateActive, jobID); err != nil {
+	_, err := d.querier.SelectJob(ctx, d, sqlUpdate, models.JobStateActive, jobID)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNoRows):
+		return ErrJobNotFound
+	default:
 		return err //nolint:wrapcheck // Error from the same package
 	}
-
-	return nil
 }
 
-// StoreCopyJobResults updates the `user_followers` or `user_following` tables and the `jobs.metadata.cursor` value.
+// StoreCopyJobResults updates the `user_followers` or `user_following` tables and the `jobs.metadata.cursor` value,
+// all within a single transaction. The queries are pipelined in a single batch, unless there are at least
+// BulkInsertThreshold users: these are then upserted beforehand with the COPY protocol.
 func (d *Database) StoreCopyJobResults(ctx context.Context, job *models.CopyJob, results *instaproxy.Connections) error {
+	return d.WithTransaction(ctx, func(tx *Database) error {
+		return tx.storeCopyJobResults(ctx, job, results)
+	})
+}
+
+// storeCopyJobResults executes StoreCopyJobResults' queries, which are expected to run within a transaction.
+func (d *Database) storeCopyJobResults(ctx context.Context, job *models.CopyJob, results *instaproxy.Connections) error {
 	table := "user_followers"
 	if job.Type == models.JobTypeCopyFollowing {
 		table = "user_following"
 	}
 
-	sql := fmt.Sprintf(`
-		INSERT INTO %s (account_id, first_seen, handler, last_seen, pic_url, user_id)
-			VALUES ($1, NOW(), $2, NOW(), $3, $4)
-		ON CONFLICT (account_id, user_id) DO UPDATE
-			SET last_seen = NOW(), handler = $2, pic_url = $3
-	`, table)
+	batch := &pgx.Batch{}
 
-	for _, u := range results.Users {
-		d.logger.Debug("upsert "+table, "job.id", job.ID, "user", u)
+	if len(results.Users) >= BulkInsertThreshold {
+		inserted, skipped, err := d.bulkInsertUsers(ctx, table, job.Metadata.UserID, results.Users)
+		if err != nil {
+			return err
+		}
+
+		d.logger.Debug("bulk upsert "+table, slog.Any("job", job), "inserted", inserted, "skipped", skipped)
+	} else {
+		sql := fmt.Sprintf(sqlUpsertUser, table)
+
+		for _, u := range results.Users {
+			d.logger.Debug("upsert "+table, slog.Any("job", job), "user", u)
 
-		if err := d.querier.Execute(ctx, d, sql, job.Metadata.UserID, u.Handler, urlStringPtr(u.PictureURL), u.ID); err != nil {
-			return err //nolint:wrapcheck // Error from the same package
+			batch.Queue(sql, job.Metadata.UserID, u.Handler, urlStringPtr(u.PictureURL), u.ID, u.FullName)
 		}
 	}
 
+	var sql string
+
+	// The last sync time is only set once the whole list has been copied, and left untouched by partial syncs.
 	if results.Next == nil {
 		sql = `
 			UPDATE jobs SET
-				metadata = jsonb_set(metadata, '{cursor}', 'null'::jsonb),
+				metadata = jsonb_set(jsonb_set(metadata, '{cursor}', 'null'::jsonb), '{lastSyncAt}', to_jsonb(NOW())),
 				state = $1
 			WHERE id = $2
 		`
 
-		return d.querier.Execute(ctx, d, sql, models.JobStateActive, job.ID) //nolint:wrapcheck // Error from the same package
+		batch.Queue(sql, models.JobStateActive, job.ID)
+	} else {
+		sql = `
+			UPDATE jobs SET
+				metadata = jsonb_set(metadata, '{cursor}', to_jsonb($1::text)),
+				state = $2
+			WHERE id = $3
+		`
+
+		batch.Queue(sql, results.Next, models.JobStateActive, job.ID)
 	}
 
-	sql = `
-		UPDATE jobs SET
-			metadata = jsonb_set(metadata, '{cursor}', to_jsonb($1::text)),
-			state = $2
-		WHERE id = $3
-	`
+	if err := d.querier.Batch(ctx, d, batch); err != nil {
+		return err //nolint:wrapcheck // Error from the same package
+	}
 
-	return d.querier.Execute(ctx, d, sql, results.Next, models.JobStateActive, job.ID) //nolint:wrapcheck // Error from the same package
+	return nil
 }
 
 // TouchJob updates the job's last_run value.
+// It returns ErrJobNotFound if the job doesn't exist or was deleted.
 func (d *Database) TouchJob(ctx context.Context, jobID int64) error {
-	if err := d.querier.Execute(ctx, d, "UPDATE jobs SET last_run = NOW() WHERE id = $1", jobID); err != nil {
+	_, err := d.querier.SelectJob(ctx, d, "UPDATE jobs SET last_run = NOW() WHERE id = $1 AND deleted_at IS NULL RETURNING id", jobID)
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNoRows):
+		return ErrJobNotFound
+	default:
+		return err //nolint:wrapcheck // Error from the same package
+	}
+}
+
+// UpdateWorkerJobID records the job that the worker running on hostname is currently executing.
+// A nil jobID means the worker is idle.
+func (d *Database) UpdateWorkerJobID(ctx context.Context, hostname string, jobID *int64) error {
+	sqlUpsert := `
+	INSERT INTO workers (hostname, current_job_id, last_seen)
+		VALUES ($1, $2, NOW())
+	ON CONFLICT (hostname) DO UPDATE
+		SET current_job_id = $2, last_seen = NOW()
+	`
+
+	if err := d.querier.Execute(ctx, d, sqlUpsert, hostname, jobID); err != nil {
 		return err //nolint:wrapcheck // Error from the same package
 	}
 
@@ -0,0 +1,250 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package grpcserver provides a gRPC server that exposes the same service layer as the HTTP API.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/proto/instamanpb"
+	"github.com/luca-arch/instaman/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultAddr is the address the gRPC server listens on, unless INSTAMAN_GRPC_ADDR is set.
+const DefaultAddr = ":10001"
+
+// igservice describes a service that can access Instagram.
+type igservice interface {
+	GetAccount(context.Context) (*instaproxy.Account, error)
+}
+
+// jobservice describes a service that can access and manipulate jobs.
+type jobservice interface {
+	FindCopyJob(context.Context, database.FindCopyJobParams) (*models.CopyJob, error)
+	FindJobs(context.Context, database.FindJobsParams) ([]models.Job, error)
+	NewCopyJob(context.Context, database.NewCopyJobParams) (*models.CopyJob, error)
+}
+
+// Server implements instamanpb.InstamanServiceServer.
+type Server struct {
+	instamanpb.UnimplementedInstamanServiceServer
+
+	igService  igservice
+	jobService jobservice
+	logger     *slog.Logger
+}
+
+// Create sets up a gRPC server with the InstamanService registered.
+func Create(jobService jobservice, igService igservice, logger *slog.Logger, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+
+	instamanpb.RegisterInstamanServiceServer(server, &Server{ //nolint:exhaustruct // Unimplemented methods are ok
+		igService:  igService,
+		jobService: jobService,
+		logger:     logger,
+	})
+
+	return server
+}
+
+// GetAccount returns the Instagram account instaproxy is logged in with.
+func (s *Server) GetAccount(ctx context.Context, _ *instamanpb.GetAccountRequest) (*instamanpb.Account, error) {
+	s.logger.Info("gRPC request", "grpc.method", "GetAccount")
+
+	account, err := s.igService.GetAccount(ctx)
+	if err != nil {
+		return nil, s.statusError(err)
+	}
+
+	out := &instamanpb.Account{
+		Biography: account.Biography,
+		FullName:  account.FullName,
+		Handler:   account.Handler,
+		Id:        account.ID,
+	}
+
+	if account.PictureURL != nil {
+		out.PictureUrl = account.PictureURL.String()
+	}
+
+	return out, nil
+}
+
+// FindCopyJob finds a job of type `copy-followers` or `copy-following`.
+func (s *Server) FindCopyJob(ctx context.Context, in *instamanpb.FindCopyJobRequest) (*instamanpb.CopyJob, error) {
+	s.logger.Info("gRPC request", "grpc.method", "FindCopyJob")
+
+	params := database.FindCopyJobParams{
+		Direction:    in.GetDirection(),
+		ResultsOrder: in.GetOrder(),
+		UserID:       in.GetUserId(),
+		WithPage:     nil,
+	}
+
+	if in.Page != nil {
+		page := int(in.GetPage())
+		params.WithPage = &page
+	}
+
+	job, err := s.jobService.FindCopyJob(ctx, params)
+	if err != nil {
+		return nil, s.statusError(err)
+	}
+
+	return copyJobToProto(job), nil
+}
+
+// FindJobs returns a list of jobs.
+func (s *Server) FindJobs(ctx context.Context, in *instamanpb.FindJobsRequest) (*instamanpb.FindJobsResponse, error) {
+	s.logger.Info("gRPC request", "grpc.method", "FindJobs")
+
+	jobs, err := s.jobService.FindJobs(ctx, database.FindJobsParams{
+		Order:  in.GetOrder(),
+		Page:   in.GetPage(),
+		State:  in.GetState(),
+		States: in.GetStates(),
+		Type:   in.GetType(),
+	})
+	if err != nil {
+		return nil, s.statusError(err)
+	}
+
+	out := &instamanpb.FindJobsResponse{Jobs: make([]*instamanpb.Job, len(jobs))}
+
+	for i := range jobs {
+		out.Jobs[i] = jobToProto(&jobs[i])
+	}
+
+	return out, nil
+}
+
+// NewCopyJob creates a new job of type `copy-followers` or `copy-following`.
+func (s *Server) NewCopyJob(ctx context.Context, in *instamanpb.NewCopyJobRequest) (*instamanpb.CopyJob, error) {
+	s.logger.Info("gRPC request", "grpc.method", "NewCopyJob")
+
+	params := database.NewCopyJobParams{ //nolint:exhaustruct // Metadata is set below
+		Label: in.GetLabel(),
+		Type:  in.GetType(),
+	}
+
+	if in.GetNextRun() != nil {
+		nextRun := in.GetNextRun().AsTime()
+		params.NextRun = &nextRun
+	}
+
+	params.Metadata.Frequency = in.GetFrequency()
+	params.Metadata.UserID = in.GetUserId()
+
+	job, err := s.jobService.NewCopyJob(ctx, params)
+	if err != nil {
+		return nil, s.statusError(err)
+	}
+
+	return copyJobToProto(job), nil
+}
+
+// statusError maps err to a gRPC status, the same way the HTTP API maps errors to status codes.
+func (s *Server) statusError(err error) error {
+	switch {
+	case errors.Is(err, database.ErrFindCopyJobParams),
+		errors.Is(err, database.ErrInvalidChecksum),
+		errors.Is(err, database.ErrInvalidID),
+		errors.Is(err, database.ErrInvalidLabel),
+		errors.Is(err, database.ErrInvalidState),
+		errors.Is(err, database.ErrInvalidType),
+		errors.Is(err, service.ErrInvalidNextRun):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, database.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, instaproxy.ErrInvalidStatus):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, instaproxy.ErrNotFound), errors.Is(err, service.ErrNotFound),
+		errors.Is(err, database.ErrJobNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		s.logger.Warn("gRPC request failed", "error", err)
+
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func copyJobToProto(cj *models.CopyJob) *instamanpb.CopyJob {
+	out := &instamanpb.CopyJob{
+		Job: jobToProto(cj.Job),
+		Metadata: &instamanpb.CopyJobMetadata{
+			Frequency:  cj.Metadata.Frequency,
+			LastSyncAt: timeToProto(cj.Metadata.LastSyncAt),
+			UserId:     cj.Metadata.UserID,
+		},
+		Results: make([]*instamanpb.User, len(cj.Results)),
+		Total:   cj.Total,
+	}
+
+	for i, u := range cj.Results {
+		out.Results[i] = &instamanpb.User{
+			Id:        u.ID,
+			FirstSeen: timestamppb.New(u.FirstSeen),
+			FullName:  u.FullName,
+			Handler:   u.Handler,
+			LastSeen:  timestamppb.New(u.LastSeen),
+		}
+
+		if u.PictureURL != nil {
+			out.Results[i].PictureUrl = *u.PictureURL
+		}
+	}
+
+	return out
+}
+
+func jobToProto(j *models.Job) *instamanpb.Job {
+	if j == nil {
+		return nil
+	}
+
+	return &instamanpb.Job{
+		Id:       j.ID,
+		Checksum: j.Checksum,
+		Type:     j.Type,
+		Label:    j.Label,
+		LastRun:  timeToProto(j.LastRun),
+		NextRun:  timeToProto(j.NextRun),
+		State:    j.State,
+		Metadata: j.BinData,
+	}
+}
+
+func timeToProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,336 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package grpcserver_test
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/grpcserver"
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/proto/instamanpb"
+	"github.com/luca-arch/instaman/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var testTime = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// igservice implements grpcserver.igservice.
+type igservice struct{}
+
+func (i *igservice) GetAccount(context.Context) (*instaproxy.Account, error) {
+	picURL, _ := url.Parse("https://example.com/avatar.png")
+
+	return &instaproxy.Account{
+		Biography:  "account bio",
+		FullName:   "John Doe",
+		Handler:    "john_doe",
+		ID:         123,
+		PictureURL: &instaproxy.URLField{URL: *picURL},
+	}, nil
+}
+
+// jobsvc implements grpcserver.jobservice.
+type jobsvc struct{}
+
+func (j *jobsvc) FindCopyJob(_ context.Context, params database.FindCopyJobParams) (*models.CopyJob, error) {
+	switch {
+	case params.Direction != "followers" && params.Direction != "following":
+		return nil, database.ErrFindCopyJobParams
+	case params.UserID == 404:
+		return nil, service.ErrNotFound
+	}
+
+	cj := &models.CopyJob{
+		Job: &models.Job{
+			BinData:  []byte(`{"frequency":"daily","userID":123}`),
+			ID:       1,
+			Checksum: "copy-followers:123",
+			Type:     "copy-followers",
+			Label:    "Test label",
+			LastRun:  &testTime,
+			NextRun:  nil,
+			State:    "active",
+		},
+		Metadata: models.CopyJobMetadata{
+			Frequency: "daily",
+			UserID:    params.UserID,
+		},
+		Total: 2,
+	}
+
+	if params.WithPage != nil {
+		cj.Results = []models.User{
+			{ID: int64(*params.WithPage), FirstSeen: testTime, FullName: params.ResultsOrder, Handler: "johndoe", LastSeen: testTime},
+		}
+	}
+
+	return cj, nil
+}
+
+func (j *jobsvc) FindJobs(_ context.Context, params database.FindJobsParams) ([]models.Job, error) {
+	return []models.Job{
+		{ID: 1, Checksum: "test:1", Type: params.Type, Label: params.Order, State: params.State},
+		{ID: 2, Checksum: "test:2", Type: params.Type, Label: params.Order, State: params.State, NextRun: &testTime},
+	}, nil
+}
+
+func (j *jobsvc) NewCopyJob(_ context.Context, params database.NewCopyJobParams) (*models.CopyJob, error) {
+	if params.Type != "copy-followers" && params.Type != "copy-following" {
+		return nil, database.ErrInvalidType
+	}
+
+	return &models.CopyJob{
+		Job: &models.Job{
+			ID:       3,
+			Checksum: params.Type + ":123",
+			Type:     params.Type,
+			Label:    params.Label,
+			NextRun:  params.NextRun,
+			State:    "new",
+		},
+		Metadata: models.CopyJobMetadata{
+			Frequency: params.Metadata.Frequency,
+			UserID:    params.Metadata.UserID,
+		},
+	}, nil
+}
+
+// newClient starts a gRPC server on an in-memory listener, and returns a client connected to it.
+func newClient(t *testing.T) instamanpb.InstamanServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := grpcserver.Create(&jobsvc{}, &igservice{}, logger)
+
+	go server.Serve(lis) //nolint:errcheck // Stopped by t.Cleanup
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	return instamanpb.NewInstamanServiceClient(conn)
+}
+
+func TestGetAccount(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t)
+
+	out, err := client.GetAccount(context.TODO(), &instamanpb.GetAccountRequest{})
+	require.NoError(t, err)
+
+	assert.True(t, proto.Equal(&instamanpb.Account{
+		Biography:  "account bio",
+		FullName:   "John Doe",
+		Handler:    "john_doe",
+		Id:         123,
+		PictureUrl: "https://example.com/avatar.png",
+	}, out), out.String())
+}
+
+func TestFindCopyJob(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t)
+
+	type wants struct {
+		code codes.Code
+		out  *instamanpb.CopyJob
+	}
+
+	tests := map[string]struct {
+		in *instamanpb.FindCopyJobRequest
+		wants
+	}{
+		"without results - ok": {
+			&instamanpb.FindCopyJobRequest{Direction: "followers", UserId: 123},
+			wants{
+				code: codes.OK,
+				out: &instamanpb.CopyJob{
+					Job: &instamanpb.Job{
+						Id:       1,
+						Checksum: "copy-followers:123",
+						Type:     "copy-followers",
+						Label:    "Test label",
+						LastRun:  timestamppb.New(testTime),
+						State:    "active",
+						Metadata: []byte(`{"frequency":"daily","userID":123}`),
+					},
+					Metadata: &instamanpb.CopyJobMetadata{Frequency: "daily", UserId: 123},
+					Total:    2,
+				},
+			},
+		},
+		"with results - ok": {
+			&instamanpb.FindCopyJobRequest{Direction: "followers", UserId: 123, Page: proto.Int32(4), Order: "handler"},
+			wants{
+				code: codes.OK,
+				out: &instamanpb.CopyJob{
+					Job: &instamanpb.Job{
+						Id:       1,
+						Checksum: "copy-followers:123",
+						Type:     "copy-followers",
+						Label:    "Test label",
+						LastRun:  timestamppb.New(testTime),
+						State:    "active",
+						Metadata: []byte(`{"frequency":"daily","userID":123}`),
+					},
+					Metadata: &instamanpb.CopyJobMetadata{Frequency: "daily", UserId: 123},
+					Results: []*instamanpb.User{
+						{
+							Id:        4,
+							FirstSeen: timestamppb.New(testTime),
+							FullName:  "handler",
+							Handler:   "johndoe",
+							LastSeen:  timestamppb.New(testTime),
+						},
+					},
+					Total: 2,
+				},
+			},
+		},
+		"invalid direction - err": {
+			&instamanpb.FindCopyJobRequest{Direction: "fololo", UserId: 123},
+			wants{code: codes.InvalidArgument},
+		},
+		"not found - err": {
+			&instamanpb.FindCopyJobRequest{Direction: "following", UserId: 404},
+			wants{code: codes.NotFound},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := client.FindCopyJob(context.TODO(), test.in)
+
+			assert.Equal(t, test.wants.code, status.Code(err))
+
+			if test.wants.out != nil {
+				assert.True(t, proto.Equal(test.wants.out, out), out.String())
+			}
+		})
+	}
+}
+
+func TestFindJobs(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t)
+
+	out, err := client.FindJobs(context.TODO(), &instamanpb.FindJobsRequest{
+		Order: "-label",
+		State: "active",
+		Type:  "copy-following",
+	})
+	require.NoError(t, err)
+
+	assert.True(t, proto.Equal(&instamanpb.FindJobsResponse{
+		Jobs: []*instamanpb.Job{
+			{Id: 1, Checksum: "test:1", Type: "copy-following", Label: "-label", State: "active"},
+			{Id: 2, Checksum: "test:2", Type: "copy-following", Label: "-label", State: "active", NextRun: timestamppb.New(testTime)},
+		},
+	}, out), out.String())
+}
+
+func TestNewCopyJob(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t)
+
+	type wants struct {
+		code codes.Code
+		out  *instamanpb.CopyJob
+	}
+
+	tests := map[string]struct {
+		in *instamanpb.NewCopyJobRequest
+		wants
+	}{
+		"ok": {
+			&instamanpb.NewCopyJobRequest{
+				Label:     "People who follow @johndoe",
+				NextRun:   timestamppb.New(testTime),
+				Type:      "copy-followers",
+				Frequency: "daily",
+				UserId:    123,
+			},
+			wants{
+				code: codes.OK,
+				out: &instamanpb.CopyJob{
+					Job: &instamanpb.Job{
+						Id:       3,
+						Checksum: "copy-followers:123",
+						Type:     "copy-followers",
+						Label:    "People who follow @johndoe",
+						NextRun:  timestamppb.New(testTime),
+						State:    "new",
+					},
+					Metadata: &instamanpb.CopyJobMetadata{Frequency: "daily", UserId: 123},
+				},
+			},
+		},
+		"invalid type - err": {
+			&instamanpb.NewCopyJobRequest{Type: "copy-something"},
+			wants{code: codes.InvalidArgument},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := client.NewCopyJob(context.TODO(), test.in)
+
+			assert.Equal(t, test.wants.code, status.Code(err))
+
+			if test.wants.out != nil {
+				assert.True(t, proto.Equal(test.wants.out, out), out.String())
+			}
+		})
+	}
+}
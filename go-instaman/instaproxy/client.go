@@ -24,17 +24,30 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/luca-arch/instaman/internal/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/proxy"
 )
 
 const (
 	DefaultBaseURL   = "http://instaproxy:15000"
 	DefaultUserAgent = "go-instaman"
+	MaxErrorBodySize = 4096                                       // The maximum number of bytes read from a non-200 response body.
+	MaxRetryDelay    = 60 * time.Second                           // The longest pause between two attempts of a rate limited request.
+	TracerName       = "github.com/luca-arch/instaman/instaproxy" // Instrumentation name used when a TracerProvider is set.
 )
 
 var (
@@ -46,6 +59,9 @@ var (
 	ErrNoProtocol    = errors.New("missing HTTP/HTTPS protocol")
 	ErrNotFound      = errors.New("resource not found")
 	ErrTransport     = errors.New("transport error")
+
+	// ErrRateLimited is returned when instaproxy responds with HTTP 429. It also matches ErrInvalidStatus.
+	ErrRateLimited = fmt.Errorf("%w: rate limited", ErrInvalidStatus)
 )
 
 // httpDoer defines an interface to make HTTP requests.
@@ -55,22 +71,111 @@ type httpDoer interface {
 
 // Client is an instaproxy API client.
 type Client struct {
-	base   string
-	client httpDoer
-	logger *slog.Logger
+	base       string
+	client     httpDoer
+	logger     *slog.Logger
+	retries    int           // Optional, rate limited requests are not retried when lower than 2.
+	retryDelay time.Duration // Pause before the first retry, doubled at every subsequent one.
+	timeout    time.Duration // Optional, requests only honour their context and the httpDoer's settings when 0.
+	tracer     trace.Tracer  // Optional, requests are not traced when nil.
+}
+
+// ClientOption configures optional Client settings.
+type ClientOption func(*Client)
+
+// WithTracerProvider enables tracing of outgoing requests.
+// Each request is wrapped in a child span and the trace context is propagated via the `traceparent` header.
+func WithTracerProvider(tp trace.TracerProvider) ClientOption {
+	return func(c *Client) {
+		if tp == nil {
+			c.tracer = nil
+
+			return
+		}
+
+		c.tracer = tp.Tracer(TracerName)
+	}
+}
+
+// WithTimeout sets a timeout on every request, which overrides the one of the httpDoer if shorter.
+// Values lower than 1 disable it.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = max(d, 0)
+	}
+}
+
+// WithRetry makes the client retry the requests that are rate limited (HTTP 429), for a total of maxAttempts.
+// The pause before each retry starts at baseDelay and doubles every time, up to MaxRetryDelay.
+// Values of maxAttempts lower than 2 disable it.
+func WithRetry(maxAttempts int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retries = maxAttempts
+		c.retryDelay = max(baseDelay, 0)
+	}
+}
+
+// WithSOCKS5Proxy routes all the outgoing requests through the SOCKS5 proxy listening at addr.
+// The option is a no-op when addr is empty. When the client's httpDoer is an *http.Client, it is replaced with a copy
+// that keeps its settings but owns the proxied transport, so that the caller's client is left untouched; otherwise the
+// httpDoer is replaced with a new *http.Client.
+func WithSOCKS5Proxy(addr string) ClientOption {
+	return func(c *Client) {
+		if addr == "" {
+			return
+		}
+
+		dialer, err := proxy.SOCKS5("tcp", addr, nil, &net.Dialer{}) //nolint:exhaustruct // Defaults are ok
+		if err != nil {
+			c.logger.Error("could not set up SOCKS5 proxy", "error", err, "proxy.address", addr)
+
+			return
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // Always true
+		transport.Proxy = nil
+
+		if cd, ok := dialer.(proxy.ContextDialer); ok {
+			transport.DialContext = cd.DialContext
+		} else {
+			transport.DialContext = func(_ context.Context, network, address string) (net.Conn, error) {
+				return dialer.Dial(network, address) //nolint:wrapcheck // Pass-through
+			}
+		}
+
+		if hc, ok := c.client.(*http.Client); ok {
+			clone := *hc
+			clone.Transport = transport
+			c.client = &clone
+
+			return
+		}
+
+		c.client = &http.Client{Transport: transport} //nolint:exhaustruct // Defaults are ok
+	}
 }
 
 // NewClient instantiates a new instaproxy API client.
-func NewClient(client httpDoer, logger *slog.Logger) *Client {
+func NewClient(client httpDoer, logger *slog.Logger, opts ...ClientOption) *Client {
 	if logger == nil {
 		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
 	}
 
-	return &Client{
-		base:   DefaultBaseURL,
-		client: client,
-		logger: logger,
+	c := &Client{
+		base:       DefaultBaseURL,
+		client:     client,
+		logger:     logger,
+		retries:    0,
+		retryDelay: 0,
+		timeout:    0,
+		tracer:     nil,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 // BaseURL sets the client's base URL.
@@ -91,7 +196,7 @@ func (c *Client) BaseURL(base string) error {
 
 // GetAccount sends a GET request to instaproxy's `/me` endpoint and returns the primary account's information.
 func (c *Client) GetAccount(ctx context.Context) (*Account, error) {
-	return get[Account](ctx, c, "/me")
+	return get[Account](ctx, c, route{template: "/me"}, "/me") //nolint:exhaustruct
 }
 
 // GetFollowers sends a GET request to instaproxy's `/followers/{id}` endpoint and returns that user's connections.
@@ -102,7 +207,7 @@ func (c *Client) GetFollowers(ctx context.Context, userID int64, cursor *string)
 		endpoint = endpoint + "?next_cursor=" + url.QueryEscape(*cursor)
 	}
 
-	return get[Connections](ctx, c, endpoint)
+	return get[Connections](ctx, c, userIDRoute("/followers/{id}", userID), endpoint, cursorAttr(cursor))
 }
 
 // GetFollowing sends a GET request to instaproxy's `/following/{id}` endpoint and returns that user's connections.
@@ -113,25 +218,85 @@ func (c *Client) GetFollowing(ctx context.Context, userID int64, cursor *string)
 		endpoint = endpoint + "?next_cursor=" + url.QueryEscape(*cursor)
 	}
 
-	return get[Connections](ctx, c, endpoint)
+	return get[Connections](ctx, c, userIDRoute("/following/{id}", userID), endpoint, cursorAttr(cursor))
+}
+
+// GetMediaCount sends a GET request to instaproxy's `/media-count/{id}` endpoint and returns that user's post count.
+func (c *Client) GetMediaCount(ctx context.Context, userID int64) (int64, error) {
+	res, err := get[MediaCountResponse](ctx, c, userIDRoute("/media-count/{id}", userID), "/media-count/"+strconv.FormatInt(userID, 10))
+	if err != nil {
+		return -1, err
+	}
+
+	return res.Count, nil
+}
+
+// Ping checks that instaproxy can be reached, by sending a GET request to its `/me` endpoint.
+// The response is discarded.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.GetAccount(ctx)
+
+	return err
 }
 
 // GetUser sends a GET request to instaproxy's `/account/{username}` endpoint and returns that user's information.
 func (c *Client) GetUser(ctx context.Context, username string) (*User, error) {
-	return get[User](ctx, c, "/account/"+username)
+	r := route{template: "/account/{username}", param: attribute.String("instagram.user.name", username)}
+
+	return get[User](ctx, c, r, "/account/"+username)
 }
 
 // GetUserByID sends a GET request to instaproxy's `/account-id/{id}` endpoint and returns that user's information.
 func (c *Client) GetUserByID(ctx context.Context, userID int64) (*User, error) {
-	return get[User](ctx, c, "/account-id/"+strconv.FormatInt(userID, 10))
+	return get[User](ctx, c, userIDRoute("/account-id/{id}", userID), "/account-id/"+strconv.FormatInt(userID, 10))
 }
 
-// Get sends a GET request to the instaproxy service.
-func get[T Account | Connections | User](ctx context.Context, c *Client, endpoint string) (*T, error) {
-	var out T
+// route identifies an instaproxy endpoint in traces, so that the spans of requests to the same endpoint share a name.
+type route struct {
+	template string             // The endpoint's path, with its parameter as a placeholder, e.g. `/followers/{id}`.
+	param    attribute.KeyValue // The value of the path parameter, unset for endpoints without one.
+}
+
+// userIDRoute returns the route of an endpoint whose path parameter is a user ID.
+func userIDRoute(template string, userID int64) route {
+	return route{template: template, param: attribute.Int64("instagram.user.id", userID)}
+}
 
+// Get sends a GET request to the instaproxy service, retrying it if rate limited and the client is configured to.
+// The response's status and timing are logged at debug level, together with the optional attrs.
+func get[T Account | Connections | MediaCountResponse | User](
+	ctx context.Context, c *Client, r route, endpoint string, attrs ...any,
+) (*T, error) {
 	c.logger.Info("instaproxy request", "http.request.method", http.MethodGet, "http.route", endpoint)
 
+	//nolint:wrapcheck // getOnce's error is returned as is
+	return retry.Do(ctx, retry.Policy{
+		Attempts: c.retries,
+		Delay:    retry.Exponential(c.retryDelay, MaxRetryDelay),
+		Retryable: func(err error) bool {
+			return errors.Is(err, ErrRateLimited)
+		},
+		OnRetry: func(attempt int, delay time.Duration, _ error) {
+			c.logger.Warn("instaproxy rate limited, retrying", "http.route", endpoint, "attempt", attempt+1, "delay", delay)
+		},
+	}, func() (*T, error) {
+		return getOnce[T](ctx, c, r, endpoint, attrs...)
+	})
+}
+
+// getOnce sends a single GET request to the instaproxy service and decodes its response.
+func getOnce[T Account | Connections | MediaCountResponse | User](
+	ctx context.Context, c *Client, r route, endpoint string, attrs ...any,
+) (*T, error) {
+	var out T
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+endpoint, nil)
 	if err != nil {
 		return nil, errors.Join(ErrHTTPFailure, err)
@@ -141,18 +306,40 @@ func get[T Account | Connections | User](ctx context.Context, c *Client, endpoin
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", DefaultUserAgent)
 
+	// Request-scoped headers may override the default ones.
+	setContextHeaders(ctx, req)
+
+	if c.tracer != nil {
+		var span trace.Span
+
+		ctx, span = startSpan(ctx, c.tracer, req, r)
+		defer span.End()
+
+		req = req.WithContext(ctx)
+	}
+
+	start := time.Now()
+
 	resp, err := c.client.Do(req)
 	if resp != nil && resp.Body != nil {
 		defer resp.Body.Close()
 	}
 
+	c.logResponse(ctx, endpoint, resp, time.Since(start), attrs...)
+
+	if c.tracer != nil {
+		endSpan(trace.SpanFromContext(ctx), resp, err)
+	}
+
 	switch {
 	case err != nil:
 		return nil, errors.Join(ErrHTTPFailure, err)
 	case resp.StatusCode == http.StatusNotFound:
-		return nil, ErrNotFound
+		return nil, statusError(ErrNotFound, resp)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, statusError(ErrRateLimited, resp)
 	case resp.StatusCode != http.StatusOK:
-		return nil, ErrInvalidStatus
+		return nil, statusError(ErrInvalidStatus, resp)
 	default:
 		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
 			return nil, errors.Join(ErrInvalidJSON, err)
@@ -161,3 +348,83 @@ func get[T Account | Connections | User](ctx context.Context, c *Client, endpoin
 
 	return &out, nil
 }
+
+// logResponse logs the status code and duration of a request to endpoint at debug level.
+// The status code is 0 when no response was received.
+func (c *Client) logResponse(ctx context.Context, endpoint string, resp *http.Response, elapsed time.Duration, attrs ...any) {
+	if !c.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+
+	args := append([]any{"status_code", status, "duration_ms", elapsed.Milliseconds(), "endpoint", endpoint}, attrs...)
+
+	c.logger.DebugContext(ctx, "instaproxy response", args...)
+}
+
+// cursorAttr returns the log attribute of a pagination cursor, which is empty for the first page.
+func cursorAttr(cursor *string) slog.Attr {
+	if cursor == nil {
+		return slog.String("cursor", "")
+	}
+
+	return slog.String("cursor", *cursor)
+}
+
+// statusError attempts to decode the response's body into an ErrorResponse and wraps its details into err.
+// If the body is not a valid ErrorResponse, err is returned as is.
+func statusError(err error, resp *http.Response) error {
+	var body ErrorResponse
+
+	if resp.Body == nil {
+		return err
+	}
+
+	if json.NewDecoder(io.LimitReader(resp.Body, MaxErrorBodySize)).Decode(&body) != nil || body.Error == "" {
+		return err
+	}
+
+	return fmt.Errorf("%w: %s (code %d)", err, body.Error, body.Code)
+}
+
+// startSpan starts a child span for the outgoing request and injects the trace context into its headers.
+// The span is named after the route's template, while the value of its path parameter is recorded as an attribute.
+func startSpan(ctx context.Context, tracer trace.Tracer, req *http.Request, r route) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", req.URL.String()),
+	}
+
+	if r.param.Valid() {
+		attrs = append(attrs, r.param)
+	}
+
+	ctx, span := tracer.Start(ctx, "instaproxy."+r.template,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+
+	propagation.TraceContext{}.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return ctx, span
+}
+
+// endSpan records the response's outcome in the span.
+func endSpan(span trace.Span, resp *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+
+	if resp.StatusCode != http.StatusOK {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
@@ -22,14 +22,27 @@ package instaproxy_test
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/luca-arch/instaman/instaproxy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type httpDoer struct {
@@ -139,6 +152,24 @@ func TestBaseURL(t *testing.T) {
 	}
 }
 
+func mockErrorBodyDoer(t *testing.T, status int, respStubPath string) *httpDoer {
+	t.Helper()
+
+	body := fixture(t, respStubPath)
+
+	h := new(httpDoer)
+
+	h.httpGet = func(_ *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Body:       io.NopCloser(bytes.NewBuffer(body)),
+			Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode: status,
+		}, nil
+	}
+
+	return h
+}
+
 func TestGetErrors(t *testing.T) {
 	t.Parallel()
 
@@ -148,6 +179,7 @@ func TestGetErrors(t *testing.T) {
 
 	type wants struct {
 		err error
+		msg string
 	}
 
 	tests := map[string]struct {
@@ -166,10 +198,45 @@ func TestGetErrors(t *testing.T) {
 			fields{
 				httpDoer: mockErrorDoer(t, http.StatusTooManyRequests, nil),
 			},
+			wants{
+				err: instaproxy.ErrRateLimited,
+			},
+		},
+		"client receives 500": {
+			fields{
+				httpDoer: mockErrorDoer(t, http.StatusInternalServerError, nil),
+			},
 			wants{
 				err: instaproxy.ErrInvalidStatus,
 			},
 		},
+		"client receives 404 with error body": {
+			fields{
+				httpDoer: mockErrorBodyDoer(t, http.StatusNotFound, "testdata/error-404.json"),
+			},
+			wants{
+				err: instaproxy.ErrNotFound,
+				msg: "resource not found: user not found (code 404)",
+			},
+		},
+		"client receives 429 with error body": {
+			fields{
+				httpDoer: mockErrorBodyDoer(t, http.StatusTooManyRequests, "testdata/error-429.json"),
+			},
+			wants{
+				err: instaproxy.ErrRateLimited,
+				msg: "unexpected status code: rate limited: please wait a few minutes before you try again (code 429)",
+			},
+		},
+		"client receives 429 with invalid body": {
+			fields{
+				httpDoer: mockErrorBodyDoer(t, http.StatusTooManyRequests, "testdata/me.json"),
+			},
+			wants{
+				err: instaproxy.ErrRateLimited,
+				msg: "unexpected status code: rate limited",
+			},
+		},
 		"network failure": {
 			fields{
 				httpDoer: mockErrorDoer(t, 0, errors.New("broken")),
@@ -199,10 +266,143 @@ func TestGetErrors(t *testing.T) {
 
 			assert.ErrorIs(t, err, test.wants.err)
 			assert.Nil(t, out)
+
+			if test.wants.msg != "" {
+				assert.EqualError(t, err, test.wants.msg)
+			}
 		})
 	}
 }
 
+func TestGetRetry(t *testing.T) {
+	t.Parallel()
+
+	// rateLimitedDoer responds with HTTP 429 the first `limited` times, and with the account's fixture afterwards.
+	rateLimitedDoer := func(t *testing.T, limited int, calls *int) *httpDoer {
+		t.Helper()
+
+		ok := mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/me", "testdata/me.json")
+		tooMany := mockErrorDoer(t, http.StatusTooManyRequests, nil)
+
+		return &httpDoer{
+			httpGet: func(req *http.Request) (*http.Response, error) {
+				*calls++
+
+				if *calls <= limited {
+					return tooMany.Do(req)
+				}
+
+				return ok.Do(req)
+			},
+		}
+	}
+
+	type fields struct {
+		limited     int
+		maxAttempts int
+	}
+
+	type wants struct {
+		calls int
+		err   error
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"succeeds after two retries": {
+			fields{
+				limited:     2,
+				maxAttempts: 3,
+			},
+			wants{
+				calls: 3,
+				err:   nil,
+			},
+		},
+		"attempts exhausted": {
+			fields{
+				limited:     2,
+				maxAttempts: 2,
+			},
+			wants{
+				calls: 2,
+				err:   instaproxy.ErrRateLimited,
+			},
+		},
+		"retry disabled": {
+			fields{
+				limited:     1,
+				maxAttempts: 0,
+			},
+			wants{
+				calls: 1,
+				err:   instaproxy.ErrRateLimited,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			client := instaproxy.NewClient(
+				rateLimitedDoer(t, test.fields.limited, &calls),
+				nil,
+				instaproxy.WithRetry(test.fields.maxAttempts, time.Millisecond),
+			)
+
+			out, err := client.GetAccount(context.TODO())
+
+			assert.Equal(t, test.wants.calls, calls)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+				assert.ErrorIs(t, err, instaproxy.ErrInvalidStatus)
+				assert.Nil(t, out)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, out)
+		})
+	}
+}
+
+func TestGetRetryCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+
+	client := instaproxy.NewClient(
+		mockErrorDoer(t, http.StatusTooManyRequests, nil),
+		nil,
+		instaproxy.WithRetry(5, time.Minute),
+	)
+
+	start := time.Now()
+	out, err := client.GetAccount(ctx)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, instaproxy.ErrRateLimited)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, out)
+}
+
+func TestPing(t *testing.T) {
+	t.Parallel()
+
+	client := instaproxy.NewClient(mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/me", "testdata/me.json"), nil)
+	assert.NoError(t, client.Ping(context.TODO()))
+
+	client = instaproxy.NewClient(mockErrorDoer(t, http.StatusInternalServerError, nil), nil)
+	assert.ErrorIs(t, client.Ping(context.TODO()), instaproxy.ErrInvalidStatus)
+}
+
 func TestMethods(t *testing.T) {
 	t.Parallel()
 
@@ -270,8 +470,9 @@ func TestMethods(t *testing.T) {
 			},
 			wants{
 				out: &instaproxy.Connections{
-					Next:  strPtr(t, "wxyz123"),
-					Users: stubUsers,
+					Next:       strPtr(t, "wxyz123"),
+					TotalCount: int64Ptr(t, 1500),
+					Users:      stubUsers,
 				},
 			},
 		},
@@ -284,8 +485,9 @@ func TestMethods(t *testing.T) {
 			},
 			wants{
 				out: &instaproxy.Connections{
-					Next:  strPtr(t, "wxyz123"),
-					Users: stubUsers,
+					Next:       strPtr(t, "wxyz123"),
+					TotalCount: int64Ptr(t, 1500),
+					Users:      stubUsers,
 				},
 			},
 		},
@@ -317,6 +519,17 @@ func TestMethods(t *testing.T) {
 				},
 			},
 		},
+		"GetMediaCount": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetMediaCount(context.TODO(), int64(12345))
+				},
+				httpDoer: mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/media-count/12345", "testdata/media-count.json"),
+			},
+			wants{
+				out: int64(87),
+			},
+		},
 		"GetUser": {
 			fields{
 				callMethod: func(c *instaproxy.Client) (any, error) {
@@ -353,3 +566,456 @@ func TestMethods(t *testing.T) {
 		})
 	}
 }
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	type fields struct {
+		httpDoer func(*testing.T) *httpDoer
+	}
+
+	type wants struct {
+		attrs  []attribute.KeyValue
+		status codes.Code
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"request - ok": {
+			fields{
+				httpDoer: func(t *testing.T) *httpDoer {
+					t.Helper()
+
+					h := mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/followers/1234?next_cursor=abcdef", "testdata/followers.json")
+					doer := h.httpGet
+
+					h.httpGet = func(req *http.Request) (*http.Response, error) {
+						assert.Regexp(t, `^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`, req.Header.Get("traceparent"))
+
+						return doer(req)
+					}
+
+					return h
+				},
+			},
+			wants{
+				attrs: []attribute.KeyValue{
+					attribute.String("http.request.method", http.MethodGet),
+					attribute.String("url.full", instaproxy.DefaultBaseURL+"/followers/1234?next_cursor=abcdef"),
+					attribute.Int64("instagram.user.id", 1234),
+					attribute.Int("http.response.status_code", http.StatusOK),
+				},
+				status: codes.Unset,
+			},
+		},
+		"request - invalid status": {
+			fields{
+				httpDoer: func(t *testing.T) *httpDoer {
+					t.Helper()
+
+					return mockErrorDoer(t, http.StatusBadGateway, nil)
+				},
+			},
+			wants{
+				attrs: []attribute.KeyValue{
+					attribute.String("http.request.method", http.MethodGet),
+					attribute.String("url.full", instaproxy.DefaultBaseURL+"/followers/1234?next_cursor=abcdef"),
+					attribute.Int64("instagram.user.id", 1234),
+					attribute.Int("http.response.status_code", http.StatusBadGateway),
+				},
+				status: codes.Error,
+			},
+		},
+		"request - network failure": {
+			fields{
+				httpDoer: func(t *testing.T) *httpDoer {
+					t.Helper()
+
+					return mockErrorDoer(t, 0, errors.New("broken"))
+				},
+			},
+			wants{
+				attrs: []attribute.KeyValue{
+					attribute.String("http.request.method", http.MethodGet),
+					attribute.String("url.full", instaproxy.DefaultBaseURL+"/followers/1234?next_cursor=abcdef"),
+					attribute.Int64("instagram.user.id", 1234),
+				},
+				status: codes.Error,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			client := instaproxy.NewClient(test.fields.httpDoer(t), nil, instaproxy.WithTracerProvider(tp))
+
+			_, _ = client.GetFollowers(context.TODO(), int64(1234), strPtr(t, "abcdef"))
+
+			spans := exporter.GetSpans()
+
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "instaproxy./followers/{id}", spans[0].Name)
+			assert.Equal(t, test.wants.attrs, spans[0].Attributes)
+			assert.Equal(t, test.wants.status, spans[0].Status.Code)
+		})
+	}
+}
+
+func TestNoTracing(t *testing.T) {
+	t.Parallel()
+
+	h := mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/me", "testdata/me.json")
+	doer := h.httpGet
+
+	h.httpGet = func(req *http.Request) (*http.Response, error) {
+		assert.Empty(t, req.Header.Get("traceparent"))
+
+		return doer(req)
+	}
+
+	client := instaproxy.NewClient(h, nil, instaproxy.WithTracerProvider(nil))
+
+	_, err := client.GetAccount(context.TODO())
+	assert.NoError(t, err)
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	body := fixture(t, "testdata/me.json")
+
+	// slowDoer responds after delay, unless the request's context is done first.
+	slowDoer := func(delay time.Duration) *httpDoer {
+		return &httpDoer{
+			httpGet: func(req *http.Request) (*http.Response, error) {
+				select {
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				case <-time.After(delay):
+				}
+
+				return &http.Response{
+					Body:       io.NopCloser(bytes.NewBuffer(body)),
+					Status:     fmt.Sprintf("%d %s", http.StatusOK, http.StatusText(http.StatusOK)),
+					StatusCode: http.StatusOK,
+				}, nil
+			},
+		}
+	}
+
+	type fields struct {
+		httpDoer *httpDoer
+		timeout  time.Duration
+	}
+
+	type wants struct {
+		err error
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"no timeout": {
+			fields{
+				httpDoer: slowDoer(20 * time.Millisecond),
+				timeout:  0,
+			},
+			wants{
+				err: nil,
+			},
+		},
+		"timeout not exceeded": {
+			fields{
+				httpDoer: slowDoer(time.Millisecond),
+				timeout:  time.Second,
+			},
+			wants{
+				err: nil,
+			},
+		},
+		"timeout exceeded": {
+			fields{
+				httpDoer: slowDoer(time.Second),
+				timeout:  10 * time.Millisecond,
+			},
+			wants{
+				err: instaproxy.ErrHTTPFailure,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			client := instaproxy.NewClient(test.fields.httpDoer, nil, instaproxy.WithTimeout(test.fields.timeout))
+
+			out, err := client.GetAccount(context.TODO())
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Nil(t, out)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotNil(t, out)
+		})
+	}
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	t.Parallel()
+
+	body := fixture(t, "testdata/me.json")
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(body) //nolint:errcheck
+	}))
+	t.Cleanup(target.Close)
+
+	dialed := make(chan string, 1)
+	addr := socks5Listener(t, dialed)
+
+	httpClient := &http.Client{}
+
+	client := instaproxy.NewClient(httpClient, nil, instaproxy.WithSOCKS5Proxy(addr))
+	assert.NoError(t, client.BaseURL(target.URL))
+
+	_, err := client.GetAccount(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimPrefix(target.URL, "http://"), <-dialed)
+
+	// The caller's client is not changed, so its requests still go direct.
+	assert.Nil(t, httpClient.Transport)
+
+	res, err := httpClient.Get(target.URL) //nolint:noctx
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Empty(t, dialed)
+}
+
+func TestSOCKS5ProxyEmpty(t *testing.T) {
+	t.Parallel()
+
+	httpClient := &http.Client{}
+
+	instaproxy.NewClient(httpClient, nil, instaproxy.WithSOCKS5Proxy(""))
+	assert.Nil(t, httpClient.Transport)
+}
+
+// socks5Listener starts a minimal SOCKS5 server (no auth, CONNECT with IPv4 only) and returns its address.
+// Each requested destination is sent into dialed.
+func socks5Listener(t *testing.T, dialed chan<- string) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveSOCKS5(conn, dialed)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func serveSOCKS5(conn net.Conn, dialed chan<- string) {
+	defer conn.Close()
+
+	// Greeting: VER, NMETHODS, METHODS.
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return
+	}
+
+	if _, err := io.ReadFull(conn, make([]byte, head[1])); err != nil {
+		return
+	}
+
+	if _, err := conn.Write([]byte{0x05, 0x00}); err != nil {
+		return
+	}
+
+	// Request: VER, CMD, RSV, ATYP=IPv4, DST.ADDR, DST.PORT.
+	req := make([]byte, 10)
+	if _, err := io.ReadFull(conn, req); err != nil || req[3] != 0x01 {
+		return
+	}
+
+	dst := net.JoinHostPort(net.IP(req[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(req[8:10]))))
+
+	upstream, err := net.Dial("tcp", dst)
+	if err != nil {
+		return
+	}
+
+	defer upstream.Close()
+
+	if _, err := conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	select {
+	case dialed <- dst:
+	default:
+	}
+
+	go io.Copy(upstream, conn) //nolint:errcheck
+
+	io.Copy(conn, upstream) //nolint:errcheck
+}
+
+func TestDebugLogging(t *testing.T) {
+	t.Parallel()
+
+	type args struct {
+		call func(*instaproxy.Client) error
+	}
+
+	type wants struct {
+		cursor   *string
+		endpoint string
+		status   float64
+	}
+
+	tests := map[string]struct {
+		args
+		doer *httpDoer
+		wants
+	}{
+		"followers, with cursor": {
+			args{
+				call: func(c *instaproxy.Client) error {
+					_, err := c.GetFollowers(context.TODO(), 123, strPtr(t, "abc"))
+
+					return err
+				},
+			},
+			mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/followers/123?next_cursor=abc", "testdata/followers.json"),
+			wants{
+				cursor:   strPtr(t, "abc"),
+				endpoint: "/followers/123?next_cursor=abc",
+				status:   http.StatusOK,
+			},
+		},
+		"following, first page": {
+			args{
+				call: func(c *instaproxy.Client) error {
+					_, err := c.GetFollowing(context.TODO(), 123, nil)
+
+					return err
+				},
+			},
+			mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/following/123", "testdata/following.json"),
+			wants{
+				cursor:   strPtr(t, ""),
+				endpoint: "/following/123",
+				status:   http.StatusOK,
+			},
+		},
+		"account, not found": {
+			args{
+				call: func(c *instaproxy.Client) error {
+					_, err := c.GetAccount(context.TODO())
+
+					return err
+				},
+			},
+			mockErrorDoer(t, http.StatusNotFound, nil),
+			wants{
+				cursor:   nil,
+				endpoint: "/me",
+				status:   http.StatusNotFound,
+			},
+		},
+		"account, transport error": {
+			args{
+				call: func(c *instaproxy.Client) error {
+					_, err := c.GetAccount(context.TODO())
+
+					return err
+				},
+			},
+			mockErrorDoer(t, 0, errors.New("connection refused")),
+			wants{
+				cursor:   nil,
+				endpoint: "/me",
+				status:   0,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			_ = test.args.call(instaproxy.NewClient(test.doer, logger))
+
+			var entry map[string]any
+
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var e map[string]any
+
+				if err := json.Unmarshal([]byte(line), &e); err != nil {
+					t.Fatal(err)
+				}
+
+				if e["msg"] == "instaproxy response" {
+					entry = e
+				}
+			}
+
+			if !assert.NotNil(t, entry) {
+				return
+			}
+
+			assert.Equal(t, "DEBUG", entry["level"])
+			assert.Equal(t, test.wants.endpoint, entry["endpoint"])
+			assert.InDelta(t, test.wants.status, entry["status_code"], 0)
+			assert.Contains(t, entry, "duration_ms")
+
+			if test.wants.cursor == nil {
+				assert.NotContains(t, entry, "cursor")
+			} else {
+				assert.Equal(t, *test.wants.cursor, entry["cursor"])
+			}
+		})
+	}
+}
+
+func TestDebugLoggingDisabled(t *testing.T) {
+	t.Parallel()
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	client := instaproxy.NewClient(mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/me", "testdata/me.json"), logger)
+
+	_, err := client.GetAccount(context.TODO())
+	assert.NoError(t, err)
+	assert.NotContains(t, buf.String(), "instaproxy response")
+}
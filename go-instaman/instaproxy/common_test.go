@@ -38,6 +38,12 @@ func fixture(t *testing.T, path string) []byte {
 	return data
 }
 
+func int64Ptr(t *testing.T, n int64) *int64 {
+	t.Helper()
+
+	return &n
+}
+
 func strPtr(t *testing.T, str string) *string {
 	t.Helper()
 
@@ -0,0 +1,58 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package instaproxy
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// headerKey is the context key that holds the value of a request-scoped header.
+type headerKey struct{ name string }
+
+// headerNamesKey is the context key that holds the names of all the request-scoped headers.
+type headerNamesKey struct{}
+
+// WithHeader returns a copy of ctx that carries a header to be sent with every instaproxy request made with it.
+// Setting the same header twice overrides its value.
+func WithHeader(ctx context.Context, name, value string) context.Context {
+	name = http.CanonicalHeaderKey(name)
+
+	names, _ := ctx.Value(headerNamesKey{}).([]string)
+	if !slices.Contains(names, name) {
+		// Copy the slice so that sibling contexts don't share the backing array.
+		names = append(slices.Clone(names), name)
+		ctx = context.WithValue(ctx, headerNamesKey{}, names)
+	}
+
+	return context.WithValue(ctx, headerKey{name: name}, value)
+}
+
+// setContextHeaders adds the request-scoped headers carried by ctx to req.
+func setContextHeaders(ctx context.Context, req *http.Request) {
+	names, _ := ctx.Value(headerNamesKey{}).([]string)
+
+	for _, name := range names {
+		if value, ok := ctx.Value(headerKey{name: name}).(string); ok {
+			req.Header.Set(name, value)
+		}
+	}
+}
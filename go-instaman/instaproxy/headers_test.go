@@ -0,0 +1,68 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package instaproxy_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHeader(t *testing.T) {
+	t.Parallel()
+
+	h := mockHTTPDoer(t, instaproxy.DefaultBaseURL+"/me", "testdata/me.json")
+	doer := h.httpGet
+
+	var got http.Header
+
+	h.httpGet = func(req *http.Request) (*http.Response, error) {
+		got = req.Header.Clone()
+
+		return doer(req)
+	}
+
+	client := instaproxy.NewClient(h, nil)
+
+	ctx := instaproxy.WithHeader(context.TODO(), "x-api-key", "secret")
+	ctx = instaproxy.WithHeader(ctx, "X-Trace-ID", "trace-1")
+	ctx = instaproxy.WithHeader(ctx, "X-Trace-Id", "trace-2")
+
+	// Sibling contexts must not leak headers into each other.
+	_ = instaproxy.WithHeader(ctx, "X-Sibling", "value")
+
+	_, err := client.GetAccount(ctx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "secret", got.Get("X-Api-Key"))
+	assert.Equal(t, []string{"trace-2"}, got.Values("X-Trace-Id"))
+	assert.Empty(t, got.Get("X-Sibling"))
+	assert.Equal(t, "application/json", got.Get("Accept"))
+
+	// Requests made without the context don't carry the headers.
+	_, err = client.GetAccount(context.TODO())
+	assert.NoError(t, err)
+
+	assert.Empty(t, got.Get("X-Api-Key"))
+	assert.Empty(t, got.Get("X-Trace-Id"))
+}
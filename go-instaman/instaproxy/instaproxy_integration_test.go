@@ -0,0 +1,215 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package instaproxy_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeFixture unmarshals a fixture file into a new T.
+func decodeFixture[T any](t *testing.T, path string) *T {
+	t.Helper()
+
+	var out T
+
+	require.NoError(t, json.Unmarshal(fixture(t, path), &out))
+
+	return &out
+}
+
+// instaproxyServer starts an HTTP server that mimics instaproxy, serving a fixture file for each known request URI.
+// Unknown URIs are answered with 404, and requests without the expected headers with 400.
+func instaproxyServer(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet ||
+			r.Header.Get("Accept") != "application/json" ||
+			r.Header.Get("User-Agent") != instaproxy.DefaultUserAgent {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		path, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture(t, path)) //nolint:errcheck
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// TestIntegrationClient calls every Client method against a real HTTP server, so that URL construction, request
+// headers and JSON decoding are all exercised. Run it alone with `go test -run Integration`.
+func TestIntegrationClient(t *testing.T) {
+	t.Parallel()
+
+	server := instaproxyServer(t, map[string]string{
+		"/me":                              "testdata/me.json",
+		"/followers/456":                   "testdata/followers.json",
+		"/followers/456?next_cursor=a%2Fb": "testdata/followers.json",
+		"/following/456":                   "testdata/following.json",
+		"/following/456?next_cursor=wxyz":  "testdata/following.json",
+		"/account/johndoe":                 "testdata/user.json",
+		"/account-id/12345":                "testdata/user.json",
+		"/media-count/12345":               "testdata/media-count.json",
+	})
+
+	client := instaproxy.NewClient(server.Client(), nil)
+	require.NoError(t, client.BaseURL(server.URL))
+
+	type fields struct {
+		callMethod func(*instaproxy.Client) (any, error)
+	}
+
+	type wants struct {
+		err error
+		out any
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"GetAccount": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetAccount(context.TODO())
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.Account](t, "testdata/me.json"),
+			},
+		},
+		"GetFollowers": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetFollowers(context.TODO(), 456, nil)
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.Connections](t, "testdata/followers.json"),
+			},
+		},
+		"GetFollowers (paginated, escaped cursor)": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetFollowers(context.TODO(), 456, strPtr(t, "a/b"))
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.Connections](t, "testdata/followers.json"),
+			},
+		},
+		"GetFollowing": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetFollowing(context.TODO(), 456, nil)
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.Connections](t, "testdata/following.json"),
+			},
+		},
+		"GetFollowing (paginated)": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetFollowing(context.TODO(), 456, strPtr(t, "wxyz"))
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.Connections](t, "testdata/following.json"),
+			},
+		},
+		"GetMediaCount": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetMediaCount(context.TODO(), 12345)
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.MediaCountResponse](t, "testdata/media-count.json").Count,
+			},
+		},
+		"GetUser": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetUser(context.TODO(), "johndoe")
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.User](t, "testdata/user.json"),
+			},
+		},
+		"GetUserByID": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetUserByID(context.TODO(), 12345)
+				},
+			},
+			wants{
+				out: decodeFixture[instaproxy.User](t, "testdata/user.json"),
+			},
+		},
+		"GetUser (not found)": {
+			fields{
+				callMethod: func(c *instaproxy.Client) (any, error) {
+					return c.GetUser(context.TODO(), "nobody")
+				},
+			},
+			wants{
+				err: instaproxy.ErrNotFound,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := test.fields.callMethod(client)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package mock provides a fake instaproxy server, so that the whole stack can be tested without Instagram credentials.
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// MockServerOptions holds the JSON bodies served by each instaproxy endpoint.
+// Requests for anything that is not listed are answered with a 404 error.
+//
+//nolint:revive // Named after the server it configures.
+type MockServerOptions struct {
+	AccountFixture     string            // Served by /me.
+	FollowersFixtures  map[int64]string  // Served by /followers/{id}, keyed by user ID.
+	FollowingFixtures  map[int64]string  // Served by /following/{id}, keyed by user ID.
+	MediaCountFixtures map[int64]string  // Served by /media-count/{id}, keyed by user ID.
+	UserByIDFixtures   map[int64]string  // Served by /account-id/{id}, keyed by user ID.
+	UserFixtures       map[string]string // Served by /account/{name}, keyed by username.
+}
+
+// NewMockServer starts and returns an HTTP server that serves the instaproxy endpoints from the provided fixtures.
+// Point a real instaproxy.Client at it with BaseURL(server.URL), and close it when done.
+// The `next_cursor` query parameter is ignored, so connections fixtures are expected to have a null `next` cursor.
+//
+//nolint:revive // Reads better than mock.NewServer alongside httptest.NewServer.
+func NewMockServer(opts MockServerOptions) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /me", func(w http.ResponseWriter, _ *http.Request) {
+		if opts.AccountFixture == "" {
+			notFound(w)
+
+			return
+		}
+
+		serve(w, opts.AccountFixture)
+	})
+	mux.HandleFunc("GET /account/{name}", func(w http.ResponseWriter, r *http.Request) {
+		serveFixture(w, opts.UserFixtures, r.PathValue("name"))
+	})
+	mux.HandleFunc("GET /account-id/{id}", byID(opts.UserByIDFixtures))
+	mux.HandleFunc("GET /followers/{id}", byID(opts.FollowersFixtures))
+	mux.HandleFunc("GET /following/{id}", byID(opts.FollowingFixtures))
+	mux.HandleFunc("GET /media-count/{id}", byID(opts.MediaCountFixtures))
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		notFound(w)
+	})
+
+	return httptest.NewServer(mux)
+}
+
+// byID serves the fixture keyed by the `{id}` path value.
+func byID(fixtures map[int64]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid user ID", "code": 400}`)) //nolint:errcheck
+
+			return
+		}
+
+		serveFixture(w, fixtures, id)
+	}
+}
+
+// serveFixture serves the fixture found at key, or a 404 error if there is none.
+func serveFixture[K comparable](w http.ResponseWriter, fixtures map[K]string, key K) {
+	fixture, ok := fixtures[key]
+	if !ok {
+		notFound(w)
+
+		return
+	}
+
+	serve(w, fixture)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error": "user not found", "code": 404}`)) //nolint:errcheck
+}
+
+func serve(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body)) //nolint:errcheck
+}
@@ -0,0 +1,161 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package mock_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/instaproxy/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMockServer(t *testing.T) {
+	t.Parallel()
+
+	server := mock.NewMockServer(mock.MockServerOptions{
+		AccountFixture:     `{"fullName": "Test Account", "handler": "test_account", "id": 123}`,
+		FollowersFixtures:  map[int64]string{111: `{"next": null, "users": [{"handler": "johndoe", "id": 45}]}`},
+		FollowingFixtures:  map[int64]string{111: `{"next": null, "users": [{"handler": "janedoe", "id": 56}]}`},
+		MediaCountFixtures: map[int64]string{111: `{"count": 87}`},
+		UserByIDFixtures:   map[int64]string{111: `{"handler": "johndoe", "id": 111}`},
+		UserFixtures:       map[string]string{"johndoe": `{"handler": "johndoe", "id": 111}`},
+	})
+	t.Cleanup(server.Close)
+
+	client := instaproxy.NewClient(server.Client(), nil)
+	require.NoError(t, client.BaseURL(server.URL))
+
+	type wants struct {
+		err error
+		out any
+	}
+
+	tests := map[string]struct {
+		call func(*instaproxy.Client) (any, error)
+		wants
+	}{
+		"GetAccount": {
+			call: func(c *instaproxy.Client) (any, error) {
+				a, err := c.GetAccount(context.TODO())
+				if err != nil {
+					return nil, err
+				}
+
+				return a.Handler, nil
+			},
+			wants: wants{out: "test_account"},
+		},
+		"GetFollowers": {
+			call: func(c *instaproxy.Client) (any, error) {
+				conns, err := c.GetFollowers(context.TODO(), 111, nil)
+				if err != nil {
+					return nil, err
+				}
+
+				return conns.Users[0].Handler, nil
+			},
+			wants: wants{out: "johndoe"},
+		},
+		"GetFollowing": {
+			call: func(c *instaproxy.Client) (any, error) {
+				conns, err := c.GetFollowing(context.TODO(), 111, nil)
+				if err != nil {
+					return nil, err
+				}
+
+				return conns.Users[0].Handler, nil
+			},
+			wants: wants{out: "janedoe"},
+		},
+		"GetMediaCount": {
+			call: func(c *instaproxy.Client) (any, error) {
+				return c.GetMediaCount(context.TODO(), 111)
+			},
+			wants: wants{out: int64(87)},
+		},
+		"GetUser": {
+			call: func(c *instaproxy.Client) (any, error) {
+				u, err := c.GetUser(context.TODO(), "johndoe")
+				if err != nil {
+					return nil, err
+				}
+
+				return u.ID, nil
+			},
+			wants: wants{out: int64(111)},
+		},
+		"GetUserByID": {
+			call: func(c *instaproxy.Client) (any, error) {
+				u, err := c.GetUserByID(context.TODO(), 111)
+				if err != nil {
+					return nil, err
+				}
+
+				return u.Handler, nil
+			},
+			wants: wants{out: "johndoe"},
+		},
+		"GetFollowers (not found)": {
+			call: func(c *instaproxy.Client) (any, error) {
+				return c.GetFollowers(context.TODO(), 222, nil)
+			},
+			wants: wants{err: instaproxy.ErrNotFound},
+		},
+		"GetUser (not found)": {
+			call: func(c *instaproxy.Client) (any, error) {
+				return c.GetUser(context.TODO(), "nobody")
+			},
+			wants: wants{err: instaproxy.ErrNotFound},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := test.call(client)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
+
+func TestNewMockServerEmpty(t *testing.T) {
+	t.Parallel()
+
+	server := mock.NewMockServer(mock.MockServerOptions{}) //nolint:exhaustruct
+	t.Cleanup(server.Close)
+
+	client := instaproxy.NewClient(server.Client(), nil)
+	require.NoError(t, client.BaseURL(server.URL))
+
+	_, err := client.GetAccount(context.TODO())
+	assert.ErrorIs(t, err, instaproxy.ErrNotFound)
+}
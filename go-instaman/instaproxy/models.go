@@ -39,8 +39,20 @@ type Account struct {
 
 // Connections is a struct that mirrors instaproxy's `/followers/<id>` and `/following/<id>` response.
 type Connections struct {
-	Next  *string `description:"Next cursor for pagination" json:"next,omitempty"`
-	Users []User  `description:"List of users" json:"users"`
+	Next       *string `description:"Next cursor for pagination" json:"next,omitempty"`
+	TotalCount *int64  `description:"Total number of connections, if reported by Instagram" json:"totalCount,omitempty"`
+	Users      []User  `description:"List of users" json:"users"`
+}
+
+// ErrorResponse is a struct that mirrors instaproxy's error response body.
+type ErrorResponse struct {
+	Error string `description:"Error message" json:"error"`
+	Code  int    `description:"Error code" json:"code"`
+}
+
+// MediaCountResponse is a struct that mirrors instaproxy's `/media-count/<id>` response.
+type MediaCountResponse struct {
+	Count int64 `description:"Number of posts" json:"count"`
 }
 
 // User is a struct that mirrors instaproxy's `InstagramUserDict` objects.
@@ -30,14 +30,37 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// It is not parallel because it sets environment variables.
+func TestAPIKey(t *testing.T) {
+	t.Setenv("INSTAMAN_API_KEY", "")
+	assert.Empty(t, internal.APIKey())
+
+	t.Setenv("INSTAMAN_API_KEY", "secret")
+	assert.Equal(t, "secret", internal.APIKey())
+}
+
+// It is not parallel because it sets environment variables.
+func TestMetricsKey(t *testing.T) {
+	t.Setenv("INSTAMAN_METRICS_KEY", "")
+	assert.Empty(t, internal.MetricsKey())
+
+	t.Setenv("INSTAMAN_METRICS_KEY", "secret")
+	assert.Equal(t, "secret", internal.MetricsKey())
+}
+
 // This test does almost nothing but increase code coverage.
+// It is not parallel because it sets environment variables.
 func TestDatabase(t *testing.T) {
-	t.Parallel()
+	t.Setenv("POSTGRES_USER", "user")
+	t.Setenv("POSTGRES_PASSWORD", "pass")
+	t.Setenv("POSTGRES_DB", "db1")
 
-	out := internal.Database(context.TODO(), nopLogger(t), true)
+	out, err := internal.Database(context.TODO(), nopLogger(t), true)
+	assert.NoError(t, err)
 	assert.NotNil(t, out)
 
-	out = internal.Database(context.TODO(), nopLogger(t), false)
+	out, err = internal.Database(context.TODO(), nopLogger(t), false)
+	assert.NoError(t, err)
 	assert.NotNil(t, out)
 }
 
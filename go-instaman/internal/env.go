@@ -0,0 +1,46 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal
+
+import (
+	"os"
+)
+
+// MustEnv returns the value of the environment variable named by key.
+// It panics if the variable is unset or empty.
+func MustEnv(key string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		panic("required environment variable " + key + " is not set")
+	}
+
+	return value
+}
+
+// OptEnv returns the value of the environment variable named by key, or defaultValue if the variable is unset.
+// A variable that is set to an empty string is returned as is.
+func OptEnv(key, defaultValue string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	return value
+}
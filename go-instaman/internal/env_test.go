@@ -0,0 +1,77 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/luca-arch/instaman/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+// These tests are not parallel because they set environment variables.
+
+func TestMustEnv(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		t.Setenv("INSTAMAN_TEST_ENV", "value")
+
+		assert.Equal(t, "value", internal.MustEnv("INSTAMAN_TEST_ENV"))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Setenv("INSTAMAN_TEST_ENV", "")
+
+		assert.PanicsWithValue(t, "required environment variable INSTAMAN_TEST_ENV is not set", func() {
+			internal.MustEnv("INSTAMAN_TEST_ENV")
+		})
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		t.Setenv("INSTAMAN_TEST_ENV", "")
+		os.Unsetenv("INSTAMAN_TEST_ENV")
+
+		assert.PanicsWithValue(t, "required environment variable INSTAMAN_TEST_ENV is not set", func() {
+			internal.MustEnv("INSTAMAN_TEST_ENV")
+		})
+	})
+}
+
+func TestOptEnv(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		t.Setenv("INSTAMAN_TEST_ENV", "value")
+
+		assert.Equal(t, "value", internal.OptEnv("INSTAMAN_TEST_ENV", "default"))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Setenv("INSTAMAN_TEST_ENV", "")
+
+		assert.Equal(t, "", internal.OptEnv("INSTAMAN_TEST_ENV", "default"))
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		// Setenv restores the original state once the test completes.
+		t.Setenv("INSTAMAN_TEST_ENV", "")
+		os.Unsetenv("INSTAMAN_TEST_ENV")
+
+		assert.Equal(t, "default", internal.OptEnv("INSTAMAN_TEST_ENV", "default"))
+	})
+}
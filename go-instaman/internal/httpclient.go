@@ -0,0 +1,45 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultMaxIdleConns = 10 // How many idle connections per host are kept by NewHTTPClient's clients, by default.
+
+	httpIdleConnTimeout = 90 * time.Second // How long idle connections are kept before being closed.
+)
+
+// NewHTTPClient returns an HTTP client with the given timeout, that keeps up to maxIdleConns idle connections alive
+// for each host. Other transport settings are the same as http.DefaultTransport's.
+func NewHTTPClient(timeout time.Duration, maxIdleConns int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // Always true
+	transport.DisableKeepAlives = false
+	transport.IdleConnTimeout = httpIdleConnTimeout
+	transport.MaxIdleConnsPerHost = maxIdleConns
+
+	return &http.Client{ //nolint:exhaustruct // Defaults are ok
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	client := internal.NewHTTPClient(5*time.Second, 3)
+
+	assert.Equal(t, 5*time.Second, client.Timeout)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+
+	assert.False(t, transport.DisableKeepAlives)
+	assert.Equal(t, 3, transport.MaxIdleConnsPerHost)
+	assert.Positive(t, transport.IdleConnTimeout)
+
+	// The default transport is not altered.
+	assert.NotSame(t, http.DefaultTransport, transport)
+}
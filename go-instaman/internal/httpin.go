@@ -29,14 +29,42 @@ import (
 	"time"
 )
 
-// InputFromRequest hydrates a struct reading from the request args and path.
+// ValidationError describes an invalid request field.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the error message.
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors aggregates all the invalid fields of a request.
+type ValidationErrors []ValidationError
+
+// Error returns all the error messages, separated by a semicolon.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+
+	for _, v := range e {
+		msgs = append(msgs, v.Message)
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// InputFromRequest hydrates a struct reading from the request args, path and headers.
 // Behaviour is defined via struct tags, eg:
 //   - `in:"pk,path,required"` will search for the pathvalue named pk, and return an error if not found.
+//   - `in:"X-User-ID,header"` will search for the request header named X-User-ID.
 //   - `in:"job_id,omitempty"` will search for the query arg named job_id, allowing it to be empty.
+//
+// All the fields are processed, and the returned error is a ValidationErrors listing every invalid one.
 func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 	var (
-		err error
-		in  T
+		errs ValidationErrors
+		in   T
 	)
 
 	// Get the reflect.Value of the struct
@@ -60,10 +88,13 @@ func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 		tagName := tagParts[0]
 		isRequired := false
 		omitEmpty := false
+		inHeader := false
 		inPath := false
 
 		for _, option := range tagParts[1:] {
 			switch option {
+			case "header":
+				inHeader = true
 			case "path":
 				inPath = true
 			case "required":
@@ -73,10 +104,14 @@ func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 			}
 		}
 
-		if inPath {
+		switch {
+		case inHeader:
+			// Get the value from the request headers.
+			queryValue = r.Header.Get(tagName)
+		case inPath:
 			// Get the value from the path.
 			queryValue = r.PathValue(tagName)
-		} else {
+		default:
 			// Get the value from the URL query parameters.
 			queryValue = r.URL.Query().Get(tagName)
 		}
@@ -84,7 +119,9 @@ func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 		// Handle required fields.
 		if queryValue == "" {
 			if isRequired {
-				return in, errors.New("missing required field: " + tagName) //nolint:err113
+				errs = append(errs, ValidationError{Field: tagName, Message: "missing required field: " + tagName})
+
+				continue
 			}
 
 			if omitEmpty {
@@ -93,6 +130,8 @@ func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 		}
 
 		// Set the field value.
+		var err error
+
 		fieldValue := inValue.Field(i)
 		switch fieldValue.Kind() { //nolint:exhaustive // The default should cover enough.
 		case reflect.Ptr:
@@ -102,10 +141,14 @@ func InputFromRequest[T any](r *http.Request) (T, error) { //nolint:ireturn
 		}
 
 		if err != nil {
-			return in, err
+			errs = append(errs, ValidationError{Field: tagName, Message: err.Error()})
 		}
 	}
 
+	if len(errs) > 0 {
+		return in, errs
+	}
+
 	return in, nil
 }
 
@@ -123,6 +166,13 @@ func hydratePointer(fieldValue *reflect.Value, field *reflect.StructField, tagNa
 	elemValue := reflect.New(elemType).Elem()
 
 	switch elemType.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		boolVal, err := strconv.ParseBool(queryValue)
+		if err != nil {
+			return errors.New("invalid boolean value for field: " + tagName) //nolint:err113
+		}
+
+		elemValue.SetBool(boolVal)
 	case reflect.String:
 		elemValue.SetString(queryValue)
 	case reflect.Int, reflect.Int32, reflect.Int64:
@@ -158,8 +208,30 @@ func hydratePointer(fieldValue *reflect.Value, field *reflect.StructField, tagNa
 // hydrateValue sets the value based on its type and the queryValue.
 func hydrateValue(fieldValue *reflect.Value, tagName, queryValue string) error {
 	switch fieldValue.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		if queryValue == "" {
+			fieldValue.SetBool(false)
+		} else {
+			boolVal, err := strconv.ParseBool(queryValue)
+			if err != nil {
+				return errors.New("invalid boolean value for field: " + tagName) //nolint:err113
+			}
+
+			fieldValue.SetBool(boolVal)
+		}
 	case reflect.String:
 		fieldValue.SetString(queryValue)
+	case reflect.Slice:
+		if fieldValue.Type().Elem().Kind() != reflect.String {
+			return errors.New("cannot parse " + tagName + ": " + fieldValue.Type().String()) //nolint:err113
+		}
+
+		// Comma separated values.
+		if queryValue == "" {
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		} else {
+			fieldValue.Set(reflect.ValueOf(strings.Split(queryValue, ",")).Convert(fieldValue.Type()))
+		}
 	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
 		if queryValue == "" {
 			fieldValue.SetInt(0)
@@ -28,6 +28,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+type StructBool struct {
+	Active      bool  `in:"active"`
+	WithResults *bool `in:"withResults"`
+}
+
 type StructInt struct {
 	IntNum   int   `in:"val"`
 	Int16Num int16 `in:"val16"`
@@ -43,14 +48,32 @@ type StructPtr struct {
 	String   *string `in:"valStr"`
 }
 
+type StructSlice struct {
+	Values []string `in:"values,omitempty"`
+}
+
 type StructRequired struct {
 	Param string `in:"sentence,required"`
 }
 
+type StructHeader struct {
+	UserID string `in:"X-User-ID,header"`
+	Query  string `in:"query"`
+}
+
+type StructMultiple struct {
+	ID    int64  `in:"id,required"`
+	Name  string `in:"name,required"`
+	Page  int    `in:"page"`
+	Title string `in:"title"`
+}
+
 func TestInputFromRequest(t *testing.T) {
 	t.Parallel()
 
 	var (
+		falseVal       = false
+		trueVal        = true
 		intNum         = 10
 		int16Num int16 = 20
 		int32Num int32 = 30
@@ -59,7 +82,8 @@ func TestInputFromRequest(t *testing.T) {
 	)
 
 	type args struct {
-		url string
+		header http.Header
+		url    string
 	}
 
 	type fields struct {
@@ -94,6 +118,129 @@ func TestInputFromRequest(t *testing.T) {
 				},
 			},
 		},
+		"ok - struct with booleans (true)": {
+			args{
+				url: "https://example.com/?active=true&withResults=true",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructBool](r)
+				},
+			},
+			wants{
+				out: StructBool{
+					Active:      true,
+					WithResults: &trueVal,
+				},
+			},
+		},
+		"ok - struct with booleans (false)": {
+			args{
+				url: "https://example.com/?active=false&withResults=false",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructBool](r)
+				},
+			},
+			wants{
+				out: StructBool{
+					Active:      false,
+					WithResults: &falseVal,
+				},
+			},
+		},
+		"ok - struct with booleans (1)": {
+			args{
+				url: "https://example.com/?active=1&withResults=1",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructBool](r)
+				},
+			},
+			wants{
+				out: StructBool{
+					Active:      true,
+					WithResults: &trueVal,
+				},
+			},
+		},
+		"ok - struct with booleans (0)": {
+			args{
+				url: "https://example.com/?active=0&withResults=0",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructBool](r)
+				},
+			},
+			wants{
+				out: StructBool{
+					Active:      false,
+					WithResults: &falseVal,
+				},
+			},
+		},
+		"ok - struct with missing booleans": {
+			args{
+				url: "https://example.com/",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructBool](r)
+				},
+			},
+			wants{
+				out: StructBool{
+					Active:      false,
+					WithResults: nil,
+				},
+			},
+		},
+		"error - struct with invalid booleans": {
+			args{
+				url: "https://example.com/?active=yes&withResults=nope",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructBool](r)
+				},
+			},
+			wants{
+				err: "invalid boolean value for field: active; invalid boolean value for field: withResults",
+			},
+		},
+		"ok - struct with slice": {
+			args{
+				url: "https://example.com/?values=active,new",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructSlice](r)
+				},
+			},
+			wants{
+				out: StructSlice{
+					Values: []string{"active", "new"},
+				},
+			},
+		},
+		"ok - struct with empty slice": {
+			args{
+				url: "https://example.com/",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructSlice](r)
+				},
+			},
+			wants{
+				out: StructSlice{
+					Values: nil,
+				},
+			},
+		},
 		"ok - struct with required value": {
 			args{
 				url: "https://example.com/?sentence=my+string",
@@ -128,6 +275,23 @@ func TestInputFromRequest(t *testing.T) {
 				},
 			},
 		},
+		"ok - struct with header": {
+			args{
+				header: http.Header{"X-User-Id": []string{"user-1"}},
+				url:    "https://example.com/?query=my+string&X-User-ID=ignored",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructHeader](r)
+				},
+			},
+			wants{
+				out: StructHeader{
+					UserID: "user-1",
+					Query:  strVal,
+				},
+			},
+		},
 		"error - struct with required value": {
 			args{
 				url: "https://example.com/",
@@ -141,6 +305,19 @@ func TestInputFromRequest(t *testing.T) {
 				err: "missing required field: sentence",
 			},
 		},
+		"error - struct with multiple invalid values": {
+			args{
+				url: "https://example.com/?id=abc&page=def&title=ok",
+			},
+			fields{
+				call: func(r *http.Request) (any, error) {
+					return internal.InputFromRequest[StructMultiple](r)
+				},
+			},
+			wants{
+				err: "invalid number for field: id; missing required field: name; invalid number for field: page",
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -148,6 +325,9 @@ func TestInputFromRequest(t *testing.T) {
 			t.Parallel()
 
 			r := httptest.NewRequest(http.MethodGet, test.args.url, nil)
+			for k, v := range test.args.header {
+				r.Header[k] = v
+			}
 
 			out, err := test.fields.call(r)
 
@@ -161,3 +341,23 @@ func TestInputFromRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestInputFromRequestValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/?id=abc&page=def&title=ok", nil)
+
+	out, err := internal.InputFromRequest[StructMultiple](r)
+
+	var verrs internal.ValidationErrors
+
+	assert.ErrorAs(t, err, &verrs)
+	assert.Equal(t, internal.ValidationErrors{
+		{Field: "id", Message: "invalid number for field: id"},
+		{Field: "name", Message: "missing required field: name"},
+		{Field: "page", Message: "invalid number for field: page"},
+	}, verrs)
+
+	// Valid fields are hydrated anyway.
+	assert.Equal(t, "ok", out.Title)
+}
@@ -0,0 +1,72 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDLogKey is the log attribute that holds the request ID.
+const RequestIDLogKey = "request.id"
+
+// requestIDKey is the context key that holds the request ID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx that carries the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or an empty string.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// requestIDHandler is a slog.Handler that adds the context's request ID to each record.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+// WithRequestID wraps h so that the request ID found in the logging context, if any, is added to each record.
+func WithRequestID(h slog.Handler) slog.Handler {
+	return &requestIDHandler{Handler: h}
+}
+
+// Handle adds the request ID attribute before passing the record to the wrapped handler.
+func (h *requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String(RequestIDLogKey, id))
+	}
+
+	return h.Handler.Handle(ctx, r) //nolint:wrapcheck // Transparent wrapper
+}
+
+// WithAttrs returns a new requestIDHandler whose wrapped handler has the given attributes.
+func (h *requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &requestIDHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new requestIDHandler whose wrapped handler has the given group.
+func (h *requestIDHandler) WithGroup(name string) slog.Handler {
+	return &requestIDHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,98 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/luca-arch/instaman/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDFromContext(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		ctx    context.Context //nolint:containedctx
+		expect string
+	}{
+		"missing ID": {
+			ctx:    context.TODO(),
+			expect: "",
+		},
+		"round trip": {
+			ctx:    internal.ContextWithRequestID(context.TODO(), "req-123"),
+			expect: "req-123",
+		},
+		"overridden ID": {
+			ctx:    internal.ContextWithRequestID(internal.ContextWithRequestID(context.TODO(), "req-1"), "req-2"),
+			expect: "req-2",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expect, internal.RequestIDFromContext(test.ctx))
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		ctx      context.Context //nolint:containedctx
+		expectID any
+	}{
+		"missing ID": {
+			ctx:      context.TODO(),
+			expectID: nil,
+		},
+		"round trip": {
+			ctx:      internal.ContextWithRequestID(context.TODO(), "req-123"),
+			expectID: "req-123",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			logger := slog.New(internal.WithRequestID(slog.NewJSONHandler(&buf, nil))).With("key", "value")
+			logger.InfoContext(test.ctx, "hello")
+
+			var record map[string]any
+
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+			assert.Equal(t, "value", record["key"])
+			assert.Equal(t, test.expectID, record[internal.RequestIDLogKey])
+		})
+	}
+}
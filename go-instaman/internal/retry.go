@@ -0,0 +1,47 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/luca-arch/instaman/internal/retry"
+)
+
+// maxRetryDelay caps the pause between two attempts of Retry.
+const maxRetryDelay = time.Minute
+
+// NoRetry wraps err so that Retry returns it immediately instead of attempting fn again.
+func NoRetry(err error) error {
+	return retry.NoRetry(err)
+}
+
+// Retry calls fn up to attempts times, until it returns a nil error.
+// Between each attempt it pauses for an exponentially growing delay, starting from baseDelay and capped at one minute,
+// with a random jitter. It returns early when ctx is cancelled or when fn returns an error wrapped by NoRetry.
+// The last value and error returned by fn are returned.
+func Retry[T any](ctx context.Context, attempts int, baseDelay time.Duration, fn func() (T, error)) (T, error) {
+	//nolint:wrapcheck // fn's error is returned as is
+	return retry.Do(ctx, retry.Policy{
+		Attempts: attempts,
+		Delay:    retry.Exponential(baseDelay, maxRetryDelay),
+	}, fn)
+}
@@ -0,0 +1,121 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package retry calls functions again when they fail, pausing between attempts.
+// It imports no other package of this module, so that any of them can use it.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// errNoRetry marks errors that must not be retried by Do.
+var errNoRetry = errors.New("no retry")
+
+// noRetryError wraps an error that must not be retried, without altering its message.
+type noRetryError struct {
+	err error
+}
+
+func (e *noRetryError) Error() string {
+	return e.err.Error()
+}
+
+func (e *noRetryError) Is(target error) bool {
+	return target == errNoRetry //nolint:errorlint // Sentinel comparison
+}
+
+func (e *noRetryError) Unwrap() error {
+	return e.err
+}
+
+// NoRetry wraps err so that Do returns it immediately instead of attempting fn again.
+func NoRetry(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &noRetryError{err: err}
+}
+
+// Policy describes how Do retries a function.
+type Policy struct {
+	Attempts  int                                             // Total number of attempts, values lower than 1 mean 1.
+	Delay     func(retry int) time.Duration                   // Pause before the given retry, starting from 0.
+	Retryable func(err error) bool                            // Optional, all the errors are retried when nil.
+	OnRetry   func(retry int, delay time.Duration, err error) // Optional, called before each pause.
+}
+
+// Do calls fn up to p.Attempts times, while it returns a retryable error.
+// It returns early when ctx is cancelled, joining ctx's error to fn's, or when fn returns an error wrapped by NoRetry.
+// The last value and error returned by fn are returned.
+func Do[T any](ctx context.Context, p Policy, fn func() (T, error)) (T, error) {
+	for r := 0; ; r++ {
+		out, err := fn()
+
+		switch {
+		case err == nil, errors.Is(err, errNoRetry), r+1 >= p.Attempts:
+			return out, err
+		case p.Retryable != nil && !p.Retryable(err):
+			return out, err
+		}
+
+		delay := p.Delay(r)
+
+		if p.OnRetry != nil {
+			p.OnRetry(r, delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return out, errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Exponential returns a Delay that starts at base and doubles on every retry, up to maxDelay.
+// Each delay is then shortened by a random jitter of up to half, so that callers that failed together don't retry in
+// lockstep.
+func Exponential(base, maxDelay time.Duration) func(int) time.Duration {
+	return func(retry int) time.Duration {
+		delay := base
+
+		for range retry {
+			if delay >= maxDelay/2 { //nolint:mnd // Doubling would reach maxDelay, or overflow
+				delay = maxDelay
+
+				break
+			}
+
+			delay *= 2
+		}
+
+		delay = min(delay, maxDelay)
+
+		if half := delay / 2; half > 0 { //nolint:mnd
+			delay -= rand.N(half) //nolint:gosec // Jitter
+		}
+
+		return delay
+	}
+}
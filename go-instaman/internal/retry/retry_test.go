@@ -0,0 +1,217 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/internal/retry"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errOther = errors.New("other error")
+	errTest  = errors.New("test error")
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	// failingFn returns a function that fails n times before succeeding.
+	failingFn := func(n int, err error) (func() (string, error), *int) {
+		calls := 0
+
+		return func() (string, error) {
+			calls++
+			if calls <= n {
+				return "", err
+			}
+
+			return "ok", nil
+		}, &calls
+	}
+
+	tests := map[string]struct {
+		attempts    int
+		failures    int
+		fnErr       error
+		expectCalls int
+		expectErr   bool
+		expectOut   string
+	}{
+		"success at first attempt": {
+			attempts:    3,
+			failures:    0,
+			fnErr:       errTest,
+			expectCalls: 1,
+			expectErr:   false,
+			expectOut:   "ok",
+		},
+		"success after failures": {
+			attempts:    3,
+			failures:    2,
+			fnErr:       errTest,
+			expectCalls: 3,
+			expectErr:   false,
+			expectOut:   "ok",
+		},
+		"attempts exhausted": {
+			attempts:    3,
+			failures:    5,
+			fnErr:       errTest,
+			expectCalls: 3,
+			expectErr:   true,
+			expectOut:   "",
+		},
+		"no retry": {
+			attempts:    3,
+			failures:    5,
+			fnErr:       retry.NoRetry(errTest),
+			expectCalls: 1,
+			expectErr:   true,
+			expectOut:   "",
+		},
+		"not retryable": {
+			attempts:    3,
+			failures:    5,
+			fnErr:       errOther,
+			expectCalls: 1,
+			expectErr:   true,
+			expectOut:   "",
+		},
+		"zero attempts": {
+			attempts:    0,
+			failures:    0,
+			fnErr:       errTest,
+			expectCalls: 1,
+			expectErr:   false,
+			expectOut:   "ok",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			fn, calls := failingFn(test.failures, test.fnErr)
+
+			out, err := retry.Do(context.TODO(), retry.Policy{
+				Attempts: test.attempts,
+				Retryable: func(err error) bool {
+					return !errors.Is(err, errOther)
+				},
+				Delay: retry.Exponential(time.Millisecond, time.Millisecond),
+			}, fn)
+
+			assert.Equal(t, test.expectCalls, *calls)
+			assert.Equal(t, test.expectOut, out)
+
+			if test.expectErr {
+				assert.ErrorIs(t, err, test.fnErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	calls := 0
+
+	_, err := retry.Do(ctx, retry.Policy{
+		Attempts: 5,
+		Delay:    retry.Exponential(time.Hour, time.Hour),
+	}, func() (int, error) {
+		calls++
+
+		cancel()
+
+		return 0, errTest
+	})
+
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, errTest)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestExponential(t *testing.T) {
+	t.Parallel()
+
+	delay := retry.Exponential(time.Second, 5*time.Second)
+
+	// Each delay is shortened by up to half, at random.
+	between := func(d, lower, upper time.Duration) {
+		t.Helper()
+
+		assert.Greater(t, d, lower)
+		assert.LessOrEqual(t, d, upper)
+	}
+
+	between(delay(0), 500*time.Millisecond, time.Second)
+	between(delay(1), time.Second, 2*time.Second)
+	between(delay(2), 2*time.Second, 4*time.Second)
+	between(delay(3), 2500*time.Millisecond, 5*time.Second)
+	between(delay(100), 2500*time.Millisecond, 5*time.Second)
+
+	// The maximum delay is not overflowed.
+	between(retry.Exponential(time.Second, time.Duration(math.MaxInt64))(100), 0, time.Duration(math.MaxInt64))
+}
+
+func TestExponentialJitter(t *testing.T) {
+	t.Parallel()
+
+	delay := retry.Exponential(time.Minute, time.Hour)
+	seen := make(map[time.Duration]bool)
+
+	for range 10 {
+		seen[delay(3)] = true
+	}
+
+	assert.Greater(t, len(seen), 1, "delays should be randomised")
+}
+
+func TestOnRetry(t *testing.T) {
+	t.Parallel()
+
+	var retries []int
+
+	_, err := retry.Do(context.TODO(), retry.Policy{
+		Attempts: 3,
+		Delay:    retry.Exponential(time.Millisecond, time.Millisecond),
+		OnRetry: func(retry int, delay time.Duration, err error) {
+			assert.LessOrEqual(t, delay, time.Millisecond)
+			assert.ErrorIs(t, err, errTest)
+
+			retries = append(retries, retry)
+		},
+	}, func() (int, error) {
+		return 0, errTest
+	})
+
+	assert.ErrorIs(t, err, errTest)
+	assert.Equal(t, []int{0, 1}, retries)
+}
@@ -0,0 +1,138 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package internal_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTest = errors.New("test error")
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	// failingFn returns a function that fails n times before succeeding.
+	failingFn := func(n int, err error) (func() (string, error), *int) {
+		calls := 0
+
+		return func() (string, error) {
+			calls++
+			if calls <= n {
+				return "", err
+			}
+
+			return "ok", nil
+		}, &calls
+	}
+
+	tests := map[string]struct {
+		attempts    int
+		failures    int
+		fnErr       error
+		expectCalls int
+		expectErr   bool
+		expectOut   string
+	}{
+		"success at first attempt": {
+			attempts:    3,
+			failures:    0,
+			fnErr:       errTest,
+			expectCalls: 1,
+			expectErr:   false,
+			expectOut:   "ok",
+		},
+		"success after failures": {
+			attempts:    3,
+			failures:    2,
+			fnErr:       errTest,
+			expectCalls: 3,
+			expectErr:   false,
+			expectOut:   "ok",
+		},
+		"attempts exhausted": {
+			attempts:    3,
+			failures:    5,
+			fnErr:       errTest,
+			expectCalls: 3,
+			expectErr:   true,
+			expectOut:   "",
+		},
+		"no retry": {
+			attempts:    3,
+			failures:    5,
+			fnErr:       internal.NoRetry(errTest),
+			expectCalls: 1,
+			expectErr:   true,
+			expectOut:   "",
+		},
+		"zero attempts": {
+			attempts:    0,
+			failures:    0,
+			fnErr:       errTest,
+			expectCalls: 1,
+			expectErr:   false,
+			expectOut:   "ok",
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			fn, calls := failingFn(test.failures, test.fnErr)
+
+			out, err := internal.Retry(context.TODO(), test.attempts, time.Millisecond, fn)
+
+			assert.Equal(t, test.expectCalls, *calls)
+			assert.Equal(t, test.expectOut, out)
+
+			if test.expectErr {
+				assert.ErrorIs(t, err, errTest)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	calls := 0
+
+	_, err := internal.Retry(ctx, 5, time.Hour, func() (int, error) {
+		calls++
+
+		cancel()
+
+		return 0, errTest
+	})
+
+	assert.Equal(t, 1, calls)
+	assert.ErrorIs(t, err, errTest)
+	assert.ErrorIs(t, err, context.Canceled)
+}
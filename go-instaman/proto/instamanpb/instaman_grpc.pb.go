@@ -0,0 +1,265 @@
+//
+// Instaman - Simple Instagram account manager.
+//
+// Copyright (C) 2024 Luca Contini
+//
+// This program is free software: you can redistribute it and/or modify it
+// under the terms of the GNU General Public License as published by the Free
+// Software Foundation, either version 3 of the License, or (at your option)
+// any later version.
+//
+// This program is distributed in the hope that it will be useful, but WITHOUT
+// ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+// FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+// more details.
+//
+// You should have received a copy of the GNU General Public License along with
+// this program. If not, see <http://www.gnu.org/licenses/>.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: instaman.proto
+
+package instamanpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	InstamanService_GetAccount_FullMethodName  = "/instaman.v1.InstamanService/GetAccount"
+	InstamanService_FindJobs_FullMethodName    = "/instaman.v1.InstamanService/FindJobs"
+	InstamanService_FindCopyJob_FullMethodName = "/instaman.v1.InstamanService/FindCopyJob"
+	InstamanService_NewCopyJob_FullMethodName  = "/instaman.v1.InstamanService/NewCopyJob"
+)
+
+// InstamanServiceClient is the client API for InstamanService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// InstamanService mirrors a subset of the HTTP API for machine-to-machine consumers.
+type InstamanServiceClient interface {
+	// GetAccount returns the Instagram account instaproxy is logged in with.
+	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	// FindJobs returns a page of jobs. Same as GET /instaman/jobs.
+	FindJobs(ctx context.Context, in *FindJobsRequest, opts ...grpc.CallOption) (*FindJobsResponse, error)
+	// FindCopyJob finds a copy job. Same as GET /instaman/jobs/copy.
+	FindCopyJob(ctx context.Context, in *FindCopyJobRequest, opts ...grpc.CallOption) (*CopyJob, error)
+	// NewCopyJob creates a new copy job. Same as POST /instaman/jobs/copy.
+	NewCopyJob(ctx context.Context, in *NewCopyJobRequest, opts ...grpc.CallOption) (*CopyJob, error)
+}
+
+type instamanServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInstamanServiceClient(cc grpc.ClientConnInterface) InstamanServiceClient {
+	return &instamanServiceClient{cc}
+}
+
+func (c *instamanServiceClient) GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, InstamanService_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instamanServiceClient) FindJobs(ctx context.Context, in *FindJobsRequest, opts ...grpc.CallOption) (*FindJobsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindJobsResponse)
+	err := c.cc.Invoke(ctx, InstamanService_FindJobs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instamanServiceClient) FindCopyJob(ctx context.Context, in *FindCopyJobRequest, opts ...grpc.CallOption) (*CopyJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CopyJob)
+	err := c.cc.Invoke(ctx, InstamanService_FindCopyJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *instamanServiceClient) NewCopyJob(ctx context.Context, in *NewCopyJobRequest, opts ...grpc.CallOption) (*CopyJob, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CopyJob)
+	err := c.cc.Invoke(ctx, InstamanService_NewCopyJob_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InstamanServiceServer is the server API for InstamanService service.
+// All implementations must embed UnimplementedInstamanServiceServer
+// for forward compatibility.
+//
+// InstamanService mirrors a subset of the HTTP API for machine-to-machine consumers.
+type InstamanServiceServer interface {
+	// GetAccount returns the Instagram account instaproxy is logged in with.
+	GetAccount(context.Context, *GetAccountRequest) (*Account, error)
+	// FindJobs returns a page of jobs. Same as GET /instaman/jobs.
+	FindJobs(context.Context, *FindJobsRequest) (*FindJobsResponse, error)
+	// FindCopyJob finds a copy job. Same as GET /instaman/jobs/copy.
+	FindCopyJob(context.Context, *FindCopyJobRequest) (*CopyJob, error)
+	// NewCopyJob creates a new copy job. Same as POST /instaman/jobs/copy.
+	NewCopyJob(context.Context, *NewCopyJobRequest) (*CopyJob, error)
+	mustEmbedUnimplementedInstamanServiceServer()
+}
+
+// UnimplementedInstamanServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInstamanServiceServer struct{}
+
+func (UnimplementedInstamanServiceServer) GetAccount(context.Context, *GetAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedInstamanServiceServer) FindJobs(context.Context, *FindJobsRequest) (*FindJobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindJobs not implemented")
+}
+func (UnimplementedInstamanServiceServer) FindCopyJob(context.Context, *FindCopyJobRequest) (*CopyJob, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindCopyJob not implemented")
+}
+func (UnimplementedInstamanServiceServer) NewCopyJob(context.Context, *NewCopyJobRequest) (*CopyJob, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NewCopyJob not implemented")
+}
+func (UnimplementedInstamanServiceServer) mustEmbedUnimplementedInstamanServiceServer() {}
+func (UnimplementedInstamanServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeInstamanServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InstamanServiceServer will
+// result in compilation errors.
+type UnsafeInstamanServiceServer interface {
+	mustEmbedUnimplementedInstamanServiceServer()
+}
+
+func RegisterInstamanServiceServer(s grpc.ServiceRegistrar, srv InstamanServiceServer) {
+	// If the following call pancis, it indicates UnimplementedInstamanServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&InstamanService_ServiceDesc, srv)
+}
+
+func _InstamanService_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstamanServiceServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstamanService_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstamanServiceServer).GetAccount(ctx, req.(*GetAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstamanService_FindJobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindJobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstamanServiceServer).FindJobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstamanService_FindJobs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstamanServiceServer).FindJobs(ctx, req.(*FindJobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstamanService_FindCopyJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindCopyJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstamanServiceServer).FindCopyJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstamanService_FindCopyJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstamanServiceServer).FindCopyJob(ctx, req.(*FindCopyJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InstamanService_NewCopyJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NewCopyJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InstamanServiceServer).NewCopyJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InstamanService_NewCopyJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InstamanServiceServer).NewCopyJob(ctx, req.(*NewCopyJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// InstamanService_ServiceDesc is the grpc.ServiceDesc for InstamanService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InstamanService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "instaman.v1.InstamanService",
+	HandlerType: (*InstamanServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAccount",
+			Handler:    _InstamanService_GetAccount_Handler,
+		},
+		{
+			MethodName: "FindJobs",
+			Handler:    _InstamanService_FindJobs_Handler,
+		},
+		{
+			MethodName: "FindCopyJob",
+			Handler:    _InstamanService_FindCopyJob_Handler,
+		},
+		{
+			MethodName: "NewCopyJob",
+			Handler:    _InstamanService_NewCopyJob_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "instaman.proto",
+}
@@ -0,0 +1,84 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Job lifecycle events, emitted via EventEmitter.
+const (
+	EventJobCreated = "job.created" // A new job was created.
+	EventJobUpdated = "job.updated" // A job was updated.
+	EventJobStarted = "job.started" // A worker picked up a job for execution.
+	EventJobPaused  = "job.paused"  // A sync was paused, and will be resumed at the next run.
+	EventJobFailed  = "job.failed"  // A job could not be executed.
+)
+
+// JobEvent describes a change in a job's lifecycle.
+type JobEvent struct {
+	Type      string
+	JobID     int64
+	Details   string
+	Timestamp time.Time
+}
+
+// EventEmitter describes a receiver of job lifecycle events.
+type EventEmitter interface {
+	Emit(ctx context.Context, event JobEvent)
+}
+
+// LogEventEmitter is an EventEmitter that logs each event.
+type LogEventEmitter struct {
+	logger *slog.Logger
+}
+
+// NewLogEventEmitter sets up and returns a new LogEventEmitter.
+func NewLogEventEmitter(logger *slog.Logger) *LogEventEmitter {
+	return &LogEventEmitter{
+		logger: logger,
+	}
+}
+
+// Emit logs the event at info level.
+func (l *LogEventEmitter) Emit(ctx context.Context, event JobEvent) {
+	l.logger.InfoContext(ctx, "job event",
+		"event.type", event.Type,
+		"event.details", event.Details,
+		"event.timestamp", event.Timestamp,
+		"job.id", event.JobID,
+	)
+}
+
+// emit sends a new event to e, unless it's nil.
+func emit(ctx context.Context, e EventEmitter, eventType string, jobID int64, details string) {
+	if e == nil {
+		return
+	}
+
+	e.Emit(ctx, JobEvent{
+		Type:      eventType,
+		JobID:     jobID,
+		Details:   details,
+		Timestamp: time.Now().UTC(),
+	})
+}
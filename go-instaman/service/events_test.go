@@ -0,0 +1,114 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEmitter is a service.EventEmitter that keeps all the emitted events.
+type recordingEmitter struct {
+	events []service.JobEvent
+	lock   sync.Mutex
+}
+
+func (r *recordingEmitter) Emit(_ context.Context, event service.JobEvent) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func TestLogEventEmitter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	emitter := service.NewLogEventEmitter(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	emitter.Emit(context.TODO(), service.JobEvent{
+		Type:      service.EventJobCreated,
+		JobID:     123,
+		Details:   "test label",
+		Timestamp: ts,
+	})
+
+	var record map[string]any
+
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+
+	assert.Equal(t, "job event", record["msg"])
+	assert.Equal(t, "job.created", record["event.type"])
+	assert.Equal(t, "test label", record["event.details"])
+	assert.Equal(t, "2025-01-01T12:00:00Z", record["event.timestamp"])
+	assert.InDelta(t, 123, record["job.id"], 0)
+}
+
+func TestJobsEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	newParams := database.NewCopyJobParams{Label: "test label", Type: models.JobTypeCopyFollowers} //nolint:exhaustruct
+	updateParams := database.UpdateJobParams{ID: 123, State: models.JobStateActive}                //nolint:exhaustruct
+	findParams := database.FindJobParams{ID: 123}                                                  //nolint:exhaustruct
+
+	db := &mockDBJobs{}
+	db.On("NewCopyJob", ctx, newParams).
+		Return(&models.CopyJob{Job: &models.Job{ID: 123, Label: "test label"}}, nil) //nolint:exhaustruct
+	db.On("UpdateJob", ctx, updateParams).
+		Return(nil)
+	db.On("FindJob", ctx, findParams).
+		Return(&models.Job{ID: 123, State: models.JobStateActive}, nil) //nolint:exhaustruct
+
+	emitter := &recordingEmitter{}
+	svc := service.NewJobsService(db).WithEventEmitter(emitter)
+
+	_, err := svc.NewCopyJob(ctx, newParams)
+	require.NoError(t, err)
+
+	_, err = svc.UpdateJob(ctx, updateParams)
+	require.NoError(t, err)
+
+	db.AssertExpectations(t)
+
+	require.Len(t, emitter.events, 2)
+
+	assert.Equal(t, service.EventJobCreated, emitter.events[0].Type)
+	assert.Equal(t, int64(123), emitter.events[0].JobID)
+	assert.Equal(t, "test label", emitter.events[0].Details)
+	assert.False(t, emitter.events[0].Timestamp.IsZero())
+
+	assert.Equal(t, service.EventJobUpdated, emitter.events[1].Type)
+	assert.Equal(t, int64(123), emitter.events[1].JobID)
+	assert.Equal(t, "state: active", emitter.events[1].Details)
+}
@@ -0,0 +1,132 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package service provides several services for communicating between different layers of the application.
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/instaproxy"
+)
+
+var ErrImportCSV = errors.New("invalid CSV file") // The uploaded CSV file could not be read.
+
+// ImportSummary reports the outcome of ImportCopyJobResults.
+type ImportSummary struct {
+	Errors   int `json:"errors"`   // Number of rows that were skipped because invalid.
+	Imported int `json:"imported"` // Number of users stored.
+}
+
+// ImportCopyJobResults seeds the results of a copy job with the users listed in a CSV file.
+// The file's columns are `user_id,handler,full_name`, and its first row is skipped if it is a header.
+// Invalid rows are skipped and counted in the returned summary.
+// It returns ErrNotFound if the job doesn't exist.
+func (j *Jobs) ImportCopyJobResults(
+	ctx context.Context,
+	params database.FindCopyJobParams,
+	r io.Reader,
+) (*ImportSummary, error) {
+	params.WithPage = nil
+
+	job, err := j.FindCopyJob(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	users, summary, err := parseUsersCSV(r)
+	if err != nil {
+		return nil, errors.Join(ErrImportCSV, err)
+	}
+
+	if len(users) == 0 {
+		return summary, nil
+	}
+
+	res := &instaproxy.Connections{
+		Next:       nil,
+		TotalCount: nil,
+		Users:      users,
+	}
+
+	if err := j.db.StoreCopyJobResults(ctx, job, res); err != nil {
+		return nil, errors.Join(ErrDBFailure, err)
+	}
+
+	return summary, nil
+}
+
+// parseUsersCSV reads the users listed in r, whose columns are `user_id,handler,full_name`.
+// Malformed rows are counted as errors, but only a failure to read r is returned.
+func parseUsersCSV(r io.Reader) ([]instaproxy.User, *ImportSummary, error) {
+	const columns = 3
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Validated row by row.
+	reader.ReuseRecord = true
+
+	summary := &ImportSummary{Errors: 0, Imported: 0}
+	users := make([]instaproxy.User, 0)
+
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+
+		var parseErr *csv.ParseError
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return users, summary, nil
+		case errors.As(err, &parseErr):
+			summary.Errors++
+
+			continue
+		case err != nil:
+			return nil, nil, err //nolint:wrapcheck // Wrapped by the caller
+		case len(record) != columns:
+			summary.Errors++
+
+			continue
+		case row == 0 && strings.TrimSpace(record[0]) == "user_id":
+			continue
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		handler := strings.TrimSpace(record[1])
+
+		if err != nil || id < 1 || handler == "" {
+			summary.Errors++
+
+			continue
+		}
+
+		users = append(users, instaproxy.User{
+			FullName:   strings.TrimSpace(record[2]),
+			Handler:    handler,
+			ID:         id,
+			PictureURL: nil,
+		})
+		summary.Imported++
+	}
+}
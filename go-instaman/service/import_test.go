@@ -0,0 +1,203 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportCopyJobResults(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	params := database.FindCopyJobParams{Direction: "followers", UserID: 123} //nolint:exhaustruct
+	job := &models.CopyJob{
+		Job: &models.Job{
+			ID:       1,
+			Checksum: "copy-followers:123",
+			Type:     models.JobTypeCopyFollowers,
+		},
+	}
+
+	type args struct {
+		csv string
+	}
+
+	type field struct {
+		db func() *mockDBJobs
+	}
+
+	type wants struct {
+		err error
+		out *service.ImportSummary
+	}
+
+	tests := map[string]struct {
+		args
+		field
+		wants
+	}{
+		"with header - ok": {
+			args{
+				csv: "user_id,handler,full_name\n11,johndoe,John Doe\n22, janedoe ,\n",
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindCopyJob", ctx, params).
+						Return(job, nil)
+					db.On("StoreCopyJobResults", ctx, job, &instaproxy.Connections{
+						Users: []instaproxy.User{
+							{ID: 11, Handler: "johndoe", FullName: "John Doe"},
+							{ID: 22, Handler: "janedoe", FullName: ""},
+						},
+					}).
+						Return(nil)
+
+					return db
+				},
+			},
+			wants{
+				out: &service.ImportSummary{Errors: 0, Imported: 2},
+			},
+		},
+		"invalid rows are skipped - ok": {
+			args{
+				csv: "11,johndoe,John Doe\nabc,janedoe,Jane Doe\n-1,nobody,\n33,,No Handler\n44,too,many,columns\n55,\"bad\"quote,x\n66,doejane,Doe Jane\n",
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindCopyJob", ctx, params).
+						Return(job, nil)
+					db.On("StoreCopyJobResults", ctx, job, &instaproxy.Connections{
+						Users: []instaproxy.User{
+							{ID: 11, Handler: "johndoe", FullName: "John Doe"},
+							{ID: 66, Handler: "doejane", FullName: "Doe Jane"},
+						},
+					}).
+						Return(nil)
+
+					return db
+				},
+			},
+			wants{
+				out: &service.ImportSummary{Errors: 5, Imported: 2},
+			},
+		},
+		"no valid rows - nothing stored": {
+			args{
+				csv: "user_id,handler,full_name\nabc,johndoe,John Doe\n",
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindCopyJob", ctx, params).
+						Return(job, nil)
+
+					return db
+				},
+			},
+			wants{
+				out: &service.ImportSummary{Errors: 1, Imported: 0},
+			},
+		},
+		"job not found - error": {
+			args{
+				csv: "11,johndoe,John Doe\n",
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					var cj *models.CopyJob
+
+					db := &mockDBJobs{}
+					db.On("FindCopyJob", ctx, params).
+						Return(cj, database.ErrJobNotFound)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrNotFound,
+			},
+		},
+		"store error": {
+			args{
+				csv: "11,johndoe,John Doe\n",
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindCopyJob", ctx, params).
+						Return(job, nil)
+					db.On("StoreCopyJobResults", ctx, job, mock.Anything).
+						Return(errMock)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrDBFailure,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := test.field.db()
+			svc := service.NewJobsService(db)
+
+			out, err := svc.ImportCopyJobResults(ctx, params, strings.NewReader(test.args.csv))
+
+			db.AssertExpectations(t)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+				assert.Nil(t, out)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
@@ -24,8 +24,11 @@ import (
 	"errors"
 
 	"github.com/luca-arch/instaman/instaproxy"
+	"golang.org/x/sync/errgroup"
 )
 
+const BatchGetUsersConcurrency = 5 // How many users BatchGetUsers fetches in parallel.
+
 var (
 	// The user ID in request's path is not a valid integer.
 	ErrInvalidUserID = errors.New("invalid user ID")
@@ -44,8 +47,10 @@ type igclient interface {
 	GetAccount(context.Context) (*instaproxy.Account, error)
 	GetFollowers(context.Context, int64, *string) (*instaproxy.Connections, error)
 	GetFollowing(context.Context, int64, *string) (*instaproxy.Connections, error)
+	GetMediaCount(context.Context, int64) (int64, error)
 	GetUser(context.Context, string) (*instaproxy.User, error)
 	GetUserByID(context.Context, int64) (*instaproxy.User, error)
+	Ping(context.Context) error
 }
 
 // GetConnectionInput defines input parameters for GetFollowers and GetFollowing methods.
@@ -71,6 +76,35 @@ func NewInstagramService(client igclient) *Instagram {
 	}
 }
 
+// BatchGetUsers calls the client's GetUserByID method for each of ids, with at most BatchGetUsersConcurrency calls
+// in flight. The returned users are in the same order as ids.
+// If any call fails, the pending ones are cancelled and the first error is returned.
+func (i *Instagram) BatchGetUsers(ctx context.Context, ids []int64) ([]*instaproxy.User, error) {
+	users := make([]*instaproxy.User, len(ids))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(BatchGetUsersConcurrency)
+
+	for n, id := range ids {
+		g.Go(func() error {
+			u, err := i.client.GetUserByID(ctx, id)
+			if err != nil {
+				return err //nolint:wrapcheck // Wraps invocation
+			}
+
+			users[n] = u
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err //nolint:wrapcheck // Wraps invocation
+	}
+
+	return users, nil
+}
+
 // GetAccount wraps the client's GetAccount method.
 func (i *Instagram) GetAccount(ctx context.Context) (*instaproxy.Account, error) {
 	return i.client.GetAccount(ctx) //nolint:wrapcheck // Wraps invocation
@@ -86,6 +120,16 @@ func (i *Instagram) GetFollowing(ctx context.Context, in GetConnectionInput) (*i
 	return i.client.GetFollowing(ctx, in.UserID, in.Cursor) //nolint:wrapcheck // Wraps invocation
 }
 
+// GetMediaCount wraps the client's GetMediaCount method.
+func (i *Instagram) GetMediaCount(ctx context.Context, in GetUserByIDInput) (*instaproxy.MediaCountResponse, error) {
+	count, err := i.client.GetMediaCount(ctx, in.UserID)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Wraps invocation
+	}
+
+	return &instaproxy.MediaCountResponse{Count: count}, nil
+}
+
 // GetUser wraps the client's GetUser method.
 func (i *Instagram) GetUser(ctx context.Context, in GetUserInput) (*instaproxy.User, error) {
 	return i.client.GetUser(ctx, in.Handler) //nolint:wrapcheck // Wraps invocation
@@ -95,3 +139,8 @@ func (i *Instagram) GetUser(ctx context.Context, in GetUserInput) (*instaproxy.U
 func (i *Instagram) GetUserByID(ctx context.Context, in GetUserByIDInput) (*instaproxy.User, error) {
 	return i.client.GetUserByID(ctx, in.UserID) //nolint:wrapcheck // Wraps invocation
 }
+
+// Ping wraps the client's Ping method.
+func (i *Instagram) Ping(ctx context.Context) error {
+	return i.client.Ping(ctx) //nolint:wrapcheck // Wraps invocation
+}
@@ -22,7 +22,9 @@ package service_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/luca-arch/instaman/instaproxy"
 	"github.com/luca-arch/instaman/service"
@@ -52,6 +54,12 @@ func (m *mockInstagramClient) GetFollowing(ctx context.Context, userID int64, cu
 	return args.Get(0).(*instaproxy.Connections), args.Error(1)
 }
 
+func (m *mockInstagramClient) GetMediaCount(ctx context.Context, userID int64) (int64, error) {
+	args := m.Called(ctx, userID)
+
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *mockInstagramClient) GetUser(ctx context.Context, username string) (*instaproxy.User, error) {
 	args := m.Called(ctx, username)
 
@@ -64,6 +72,95 @@ func (m *mockInstagramClient) GetUserByID(ctx context.Context, userID int64) (*i
 	return args.Get(0).(*instaproxy.User), args.Error(1)
 }
 
+func (m *mockInstagramClient) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+
+	return args.Error(0)
+}
+
+// countingInstagramClient records the maximum number of concurrent GetUserByID calls.
+type countingInstagramClient struct {
+	mockInstagramClient
+
+	active  atomic.Int32
+	failID  int64
+	maxSeen atomic.Int32
+}
+
+func (c *countingInstagramClient) GetUserByID(ctx context.Context, userID int64) (*instaproxy.User, error) {
+	n := c.active.Add(1)
+	defer c.active.Add(-1)
+
+	for {
+		seen := c.maxSeen.Load()
+		if n <= seen || c.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	if userID == c.failID {
+		return nil, errMock
+	}
+
+	return &instaproxy.User{ID: userID}, nil //nolint:exhaustruct
+}
+
+func TestBatchGetUsers(t *testing.T) {
+	t.Parallel()
+
+	ids := make([]int64, 4*service.BatchGetUsersConcurrency)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+
+		client := &countingInstagramClient{} //nolint:exhaustruct
+		svc := service.NewInstagramService(client)
+
+		users, err := svc.BatchGetUsers(context.TODO(), ids)
+
+		assert.NoError(t, err)
+		assert.Len(t, users, len(ids))
+
+		for i, u := range users {
+			assert.Equal(t, ids[i], u.ID)
+		}
+
+		assert.LessOrEqual(t, client.maxSeen.Load(), int32(service.BatchGetUsersConcurrency))
+		assert.Positive(t, client.maxSeen.Load())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		client := &countingInstagramClient{failID: 3} //nolint:exhaustruct
+		svc := service.NewInstagramService(client)
+
+		users, err := svc.BatchGetUsers(context.TODO(), ids)
+
+		assert.ErrorIs(t, err, errMock)
+		assert.Nil(t, users)
+		assert.LessOrEqual(t, client.maxSeen.Load(), int32(service.BatchGetUsersConcurrency))
+	})
+
+	t.Run("no ids", func(t *testing.T) {
+		t.Parallel()
+
+		users, err := service.NewInstagramService(&countingInstagramClient{}).BatchGetUsers(context.TODO(), nil) //nolint:exhaustruct
+
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	})
+}
+
 //nolint:maintidx // test all methods
 func TestMethods(t *testing.T) {
 	t.Parallel()
@@ -150,6 +247,42 @@ func TestMethods(t *testing.T) {
 				out: nil,
 			},
 		},
+		"method Ping - ok": {
+			fields{
+				callMethod: func(ic *service.Instagram) (any, error) {
+					return struct{}{}, ic.Ping(testCtx)
+				},
+				setupMock: func() *mockInstagramClient {
+					client := &mockInstagramClient{}
+					client.On("Ping", testCtx).
+						Return(nil)
+
+					return client
+				},
+			},
+			wants{
+				err: nil,
+				out: struct{}{},
+			},
+		},
+		"method Ping - error": {
+			fields{
+				callMethod: func(ic *service.Instagram) (any, error) {
+					return nil, ic.Ping(testCtx)
+				},
+				setupMock: func() *mockInstagramClient {
+					client := &mockInstagramClient{}
+					client.On("Ping", testCtx).
+						Return(stubErr)
+
+					return client
+				},
+			},
+			wants{
+				err: stubErr,
+				out: nil,
+			},
+		},
 		"method GetFollowers - ok": {
 			fields{
 				callMethod: func(ic *service.Instagram) (any, error) {
@@ -286,6 +419,44 @@ func TestMethods(t *testing.T) {
 				out: nil,
 			},
 		},
+		"method GetMediaCount - ok": {
+			fields{
+				callMethod: func(ic *service.Instagram) (any, error) {
+					return ic.GetMediaCount(testCtx, service.GetUserByIDInput{
+						UserID: 1234,
+					})
+				},
+				setupMock: func() *mockInstagramClient {
+					client := &mockInstagramClient{}
+					client.On("GetMediaCount", testCtx, int64(1234)).
+						Return(int64(42), nil)
+
+					return client
+				},
+			},
+			wants{
+				err: nil,
+				out: &instaproxy.MediaCountResponse{Count: 42},
+			},
+		},
+		"method GetMediaCount - error": {
+			fields{
+				callMethod: func(ic *service.Instagram) (any, error) {
+					return ic.GetMediaCount(testCtx, service.GetUserByIDInput{})
+				},
+				setupMock: func() *mockInstagramClient {
+					client := &mockInstagramClient{}
+					client.On("GetMediaCount", testCtx, int64(0)).
+						Return(int64(-1), stubErr)
+
+					return client
+				},
+			},
+			wants{
+				err: stubErr,
+				out: nil,
+			},
+		},
 		"method GetUserByID - ok": {
 			fields{
 				callMethod: func(ic *service.Instagram) (any, error) {
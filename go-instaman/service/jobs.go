@@ -23,39 +23,114 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
 	"github.com/luca-arch/instaman/database"
 	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/instaproxy"
 )
 
 const MaxCopyResults = 500 // The maximum number of users per page to retrieve with copy-followers and copy-following jobs.
 
-var ErrDBFailure = errors.New("db error") // Generic error wrapper for db failures.
+var (
+	ErrDBFailure      = errors.New("db error")                      // Generic error wrapper for db failures.
+	ErrInvalidNextRun = errors.New("nextRun must be in the future") // NewCopyJob() was given a past NextRun.
+	ErrNotFound       = errors.New("job not found")                 // The requested job does not exist.
+)
 
 type dbjobs interface {
+	ArchiveJob(ctx context.Context, id int64) error
+	DeleteJob(ctx context.Context, id int64) error
+	FindArchivedJobs(context.Context, database.FindJobsParams) ([]models.Job, error)
 	FindCopyJob(context.Context, database.FindCopyJobParams) (*models.CopyJob, error)
 	FindJob(context.Context, database.FindJobParams) (*models.Job, error)
+	FindJobEvents(ctx context.Context, jobID int64, page int) ([]models.JobEvent, error)
 	FindJobs(context.Context, database.FindJobsParams) ([]models.Job, error)
 	NewCopyJob(context.Context, database.NewCopyJobParams) (*models.CopyJob, error)
+	Ping(context.Context) error
+	StoreCopyJobResults(context.Context, *models.CopyJob, *instaproxy.Connections) error
+	StreamCopyJobResults(context.Context, io.Writer, *models.CopyJob, int, int, string) error
+	UpdateJob(context.Context, database.UpdateJobParams) error
+	UpdateUserPicture(ctx context.Context, accountID, userID int64, direction, picURL string) error
 }
 
 // Jobs is the service that abstracts jobs operations from the database layer.
 type Jobs struct {
-	db dbjobs
+	db      dbjobs
+	emitter EventEmitter // Optional, events are not emitted when nil.
 }
 
 // NewJobsService sets up and returns a new Job Service.
 func NewJobsService(db dbjobs) *Jobs {
 	return &Jobs{
-		db: db,
+		db:      db,
+		emitter: nil,
 	}
 }
 
+// WithEventEmitter sets the receiver of the jobs' lifecycle events.
+func (j *Jobs) WithEventEmitter(e EventEmitter) *Jobs {
+	j.emitter = e
+
+	return j
+}
+
+// ArchiveJob moves a job to the archive and returns it.
+// It returns ErrNotFound if the job doesn't exist.
+func (j *Jobs) ArchiveJob(ctx context.Context, params database.ArchiveJobParams) (*models.Job, error) {
+	job, err := j.FindJob(ctx, database.FindJobParams{ID: params.ID}) //nolint:exhaustruct
+	if err != nil {
+		return nil, err
+	}
+
+	err = j.db.ArchiveJob(ctx, job.ID)
+
+	switch {
+	case errors.Is(err, database.ErrJobNotFound):
+		// Deleted or archived in the meantime.
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, errors.Join(ErrDBFailure, err)
+	}
+
+	return job, nil
+}
+
+// DeleteJob soft-deletes a job and returns it.
+// It returns ErrNotFound if the job doesn't exist.
+func (j *Jobs) DeleteJob(ctx context.Context, params database.DeleteJobParams) (*models.Job, error) {
+	job, err := j.FindJob(ctx, database.FindJobParams{ID: params.ID}) //nolint:exhaustruct
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.db.DeleteJob(ctx, job.ID); err != nil {
+		return nil, errors.Join(ErrDBFailure, err)
+	}
+
+	return job, nil
+}
+
+// FindArchivedJobs retrieves a list of archived jobs from the database.
+func (j *Jobs) FindArchivedJobs(ctx context.Context, params database.FindJobsParams) ([]models.Job, error) {
+	jobs, err := j.db.FindArchivedJobs(ctx, params)
+	if err != nil {
+		return nil, errors.Join(ErrDBFailure, err)
+	}
+
+	return jobs, nil
+}
+
 // FindCopyJob finds a job of type `copy-followers` or `copy-following`.
-// This method does not error if the job isn't found, it returns a nil pointer.
+// It returns ErrNotFound if the job doesn't exist.
 func (j *Jobs) FindCopyJob(ctx context.Context, params database.FindCopyJobParams) (*models.CopyJob, error) {
 	cj, err := j.db.FindCopyJob(ctx, params)
-	if err != nil {
+
+	switch {
+	case errors.Is(err, database.ErrJobNotFound):
+		return nil, ErrNotFound
+	case err != nil:
 		return nil, errors.Join(ErrDBFailure, err)
 	}
 
@@ -63,16 +138,35 @@ func (j *Jobs) FindCopyJob(ctx context.Context, params database.FindCopyJobParam
 }
 
 // FindJob finds a job by its ID or checksum.
-// This method does not error if the job isn't found, it returns a nil pointer.
+// It returns ErrNotFound if the job doesn't exist.
 func (j *Jobs) FindJob(ctx context.Context, params database.FindJobParams) (*models.Job, error) {
 	jj, err := j.db.FindJob(ctx, params)
-	if err != nil {
+
+	switch {
+	case errors.Is(err, database.ErrJobNotFound):
+		return nil, ErrNotFound
+	case err != nil:
 		return nil, errors.Join(ErrDBFailure, err)
 	}
 
 	return jj, nil
 }
 
+// FindJobEvents retrieves a page of the audit logs of a job from the database.
+// It returns ErrNotFound if the job doesn't exist.
+func (j *Jobs) FindJobEvents(ctx context.Context, jobID int64, page int) ([]models.JobEvent, error) {
+	events, err := j.db.FindJobEvents(ctx, jobID, page)
+
+	switch {
+	case errors.Is(err, database.ErrJobNotFound):
+		return nil, ErrNotFound
+	case err != nil:
+		return nil, errors.Join(ErrDBFailure, err)
+	}
+
+	return events, nil
+}
+
 // FindJobs retrieves a list of jobs from the database.
 func (j *Jobs) FindJobs(ctx context.Context, params database.FindJobsParams) ([]models.Job, error) {
 	jobs, err := j.db.FindJobs(ctx, params)
@@ -84,11 +178,77 @@ func (j *Jobs) FindJobs(ctx context.Context, params database.FindJobsParams) ([]
 }
 
 // NewCopyJob creates a new CopyJob in the database and returns it.
+// It returns database.ErrInvalidLabel if the label is empty, or ErrInvalidNextRun if NextRun is in the past.
+// A nil NextRun is valid and leaves the job to be scheduled later.
 func (j *Jobs) NewCopyJob(ctx context.Context, params database.NewCopyJobParams) (*models.CopyJob, error) {
+	if params.Label == "" {
+		return nil, database.ErrInvalidLabel
+	}
+
+	if params.NextRun != nil && params.NextRun.Before(time.Now()) {
+		return nil, ErrInvalidNextRun
+	}
+
 	cj, err := j.db.NewCopyJob(ctx, params)
 	if err != nil {
 		return nil, errors.Join(ErrDBFailure, err)
 	}
 
+	emit(ctx, j.emitter, EventJobCreated, cj.ID, cj.Label)
+
 	return cj, nil
 }
+
+// Ping checks that the database can be reached.
+func (j *Jobs) Ping(ctx context.Context) error {
+	if err := j.db.Ping(ctx); err != nil {
+		return errors.Join(ErrDBFailure, err)
+	}
+
+	return nil
+}
+
+// StreamCopyJobResults writes a CopyJob and one page of its results, sorted by order, into w without buffering them.
+// Pages hold limit users, up to database.MaxCopyResults.
+func (j *Jobs) StreamCopyJobResults(ctx context.Context, w io.Writer, job *models.CopyJob, page, limit int, order string) error {
+	if err := j.db.StreamCopyJobResults(ctx, w, job, page, limit, order); err != nil {
+		return errors.Join(ErrDBFailure, err)
+	}
+
+	return nil
+}
+
+// UpdateJob updates a job in the database and returns it.
+// It returns ErrNotFound if the job doesn't exist, or database.ErrInvalidTransition if its state can't be changed to
+// the requested one.
+func (j *Jobs) UpdateJob(ctx context.Context, params database.UpdateJobParams) (*models.Job, error) {
+	err := j.db.UpdateJob(ctx, params)
+
+	switch {
+	case errors.Is(err, database.ErrJobNotFound):
+		return nil, ErrNotFound
+	case errors.Is(err, database.ErrInvalidTransition):
+		return nil, err //nolint:wrapcheck // Sentinel error
+	case err != nil:
+		return nil, errors.Join(ErrDBFailure, err)
+	}
+
+	job, err := j.FindJob(ctx, database.FindJobParams{ID: params.ID}) //nolint:exhaustruct
+	if err != nil {
+		return nil, err
+	}
+
+	emit(ctx, j.emitter, EventJobUpdated, job.ID, "state: "+job.State)
+
+	return job, nil
+}
+
+// UpdateUserPicture updates the picture URL of a user copied by a job, e.g. when it has gone stale.
+func (j *Jobs) UpdateUserPicture(ctx context.Context, params database.UpdateUserPictureParams) error {
+	err := j.db.UpdateUserPicture(ctx, params.AccountID, params.UserID, params.Direction, params.PictureURL)
+	if err != nil {
+		return errors.Join(ErrDBFailure, err)
+	}
+
+	return nil
+}
@@ -20,12 +20,16 @@
 package service_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"testing"
+	"time"
 
 	"github.com/luca-arch/instaman/database"
 	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/instaproxy"
 	"github.com/luca-arch/instaman/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -37,6 +41,24 @@ type mockDBJobs struct {
 	mock.Mock
 }
 
+func (m *mockDBJobs) ArchiveJob(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+
+	return args.Error(0)
+}
+
+func (m *mockDBJobs) DeleteJob(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+
+	return args.Error(0)
+}
+
+func (m *mockDBJobs) FindArchivedJobs(ctx context.Context, p database.FindJobsParams) ([]models.Job, error) {
+	args := m.Called(ctx, p)
+
+	return args.Get(0).([]models.Job), args.Error(1)
+}
+
 func (m *mockDBJobs) FindCopyJob(ctx context.Context, params database.FindCopyJobParams) (*models.CopyJob, error) {
 	args := m.Called(ctx, params)
 
@@ -49,6 +71,12 @@ func (m *mockDBJobs) FindJob(ctx context.Context, p database.FindJobParams) (*mo
 	return args.Get(0).(*models.Job), args.Error(1)
 }
 
+func (m *mockDBJobs) FindJobEvents(ctx context.Context, jobID int64, page int) ([]models.JobEvent, error) {
+	args := m.Called(ctx, jobID, page)
+
+	return args.Get(0).([]models.JobEvent), args.Error(1)
+}
+
 func (m *mockDBJobs) FindJobs(ctx context.Context, p database.FindJobsParams) ([]models.Job, error) {
 	args := m.Called(ctx, p)
 
@@ -61,16 +89,42 @@ func (m *mockDBJobs) NewCopyJob(ctx context.Context, p database.NewCopyJobParams
 	return args.Get(0).(*models.CopyJob), args.Error(1)
 }
 
-func TestFindCopyJob(t *testing.T) {
+func (m *mockDBJobs) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+
+	return args.Error(0)
+}
+
+func (m *mockDBJobs) UpdateJob(ctx context.Context, p database.UpdateJobParams) error {
+	args := m.Called(ctx, p)
+
+	return args.Error(0)
+}
+
+func (m *mockDBJobs) UpdateUserPicture(ctx context.Context, accountID, userID int64, direction, picURL string) error {
+	args := m.Called(ctx, accountID, userID, direction, picURL)
+
+	return args.Error(0)
+}
+
+func (m *mockDBJobs) StoreCopyJobResults(ctx context.Context, job *models.CopyJob, res *instaproxy.Connections) error {
+	args := m.Called(ctx, job, res)
+
+	return args.Error(0)
+}
+
+func (m *mockDBJobs) StreamCopyJobResults(ctx context.Context, w io.Writer, job *models.CopyJob, page, limit int, order string) error {
+	args := m.Called(ctx, w, job, page, limit, order)
+
+	return args.Error(0)
+}
+
+func TestArchiveJob(t *testing.T) {
 	t.Parallel()
 
 	ctx := context.TODO()
-
-	// Dummy params to assert FindCopyJob's specific arguments.
-	params := database.FindCopyJobParams{
-		Direction: "mock",
-		UserID:    1,
-	}
+	params := database.ArchiveJobParams{ID: 123}
+	findParams := database.FindJobParams{ID: 123} //nolint:exhaustruct
 
 	type field struct {
 		db func() *mockDBJobs
@@ -78,47 +132,77 @@ func TestFindCopyJob(t *testing.T) {
 
 	type wants struct {
 		err error
-		out *models.CopyJob
+		out *models.Job
 	}
 
 	tests := map[string]struct {
 		field
 		wants
 	}{
-		"method FindCopyJob - ok": {
+		"method ArchiveJob - ok": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
 					db := &mockDBJobs{}
-					db.On("FindCopyJob", ctx, params).
-						Return(&models.CopyJob{
-							Job: &models.Job{
-								ID:       123,
-								Checksum: "abcde",
-							},
-						}, nil)
+					db.On("FindJob", ctx, findParams).
+						Return(&models.Job{ID: 123, Checksum: "abcde"}, nil)
+					db.On("ArchiveJob", ctx, int64(123)).
+						Return(nil)
 
 					return db
 				},
 			},
 			wants{
-				out: &models.CopyJob{
-					Job: &models.Job{
-						ID:       123,
-						Checksum: "abcde",
-					},
+				out: &models.Job{ID: 123, Checksum: "abcde"},
+			},
+		},
+		"method ArchiveJob - not found": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					var j *models.Job
+
+					db := &mockDBJobs{}
+					db.On("FindJob", ctx, findParams).
+						Return(j, database.ErrJobNotFound)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrNotFound,
+			},
+		},
+		"method ArchiveJob - archived concurrently": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindJob", ctx, findParams).
+						Return(&models.Job{ID: 123, Checksum: "abcde"}, nil)
+					db.On("ArchiveJob", ctx, int64(123)).
+						Return(database.ErrJobNotFound)
+
+					return db
 				},
 			},
+			wants{
+				err: service.ErrNotFound,
+			},
 		},
-		"method FindCopyJob - error": {
+		"method ArchiveJob - error": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
 					db := &mockDBJobs{}
-					db.On("FindCopyJob", ctx, params).
-						Return(&models.CopyJob{}, errMock)
+					db.On("FindJob", ctx, findParams).
+						Return(&models.Job{ID: 123, Checksum: "abcde"}, nil)
+					db.On("ArchiveJob", ctx, int64(123)).
+						Return(errMock)
 
 					return db
 				},
@@ -133,13 +217,19 @@ func TestFindCopyJob(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			svc := service.NewJobsService(test.field.db())
+			db := test.field.db()
+			svc := service.NewJobsService(db)
 
-			out, err := svc.FindCopyJob(ctx, params)
+			out, err := svc.ArchiveJob(ctx, params)
+
+			db.AssertExpectations(t)
 
 			if test.wants.err != nil {
 				assert.ErrorIs(t, err, test.wants.err)
-				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				if !errors.Is(test.wants.err, service.ErrNotFound) {
+					assert.ErrorIs(t, err, service.ErrDBFailure)
+				}
 
 				return
 			}
@@ -150,16 +240,12 @@ func TestFindCopyJob(t *testing.T) {
 	}
 }
 
-func TestFindJob(t *testing.T) {
+func TestDeleteJob(t *testing.T) {
 	t.Parallel()
 
 	ctx := context.TODO()
-
-	// Dummy params to assert FindJob's specific arguments.
-	params := database.FindJobParams{
-		Checksum: "mock checksum",
-		ID:       1,
-	}
+	params := database.DeleteJobParams{ID: 123}
+	findParams := database.FindJobParams{ID: 123} //nolint:exhaustruct
 
 	type field struct {
 		db func() *mockDBJobs
@@ -174,36 +260,52 @@ func TestFindJob(t *testing.T) {
 		field
 		wants
 	}{
-		"method FindJob - ok": {
+		"method DeleteJob - ok": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
 					db := &mockDBJobs{}
-					db.On("FindJob", ctx, params).
-						Return(&models.Job{
-							ID:       456,
-							Checksum: "abcde",
-						}, nil)
+					db.On("FindJob", ctx, findParams).
+						Return(&models.Job{ID: 123, Checksum: "abcde"}, nil)
+					db.On("DeleteJob", ctx, int64(123)).
+						Return(nil)
 
 					return db
 				},
 			},
 			wants{
-				out: &models.Job{
-					ID:       456,
-					Checksum: "abcde",
+				out: &models.Job{ID: 123, Checksum: "abcde"},
+			},
+		},
+		"method DeleteJob - not found": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					var j *models.Job
+
+					db := &mockDBJobs{}
+					db.On("FindJob", ctx, findParams).
+						Return(j, database.ErrJobNotFound)
+
+					return db
 				},
 			},
+			wants{
+				err: service.ErrNotFound,
+			},
 		},
-		"method FindJob - error": {
+		"method DeleteJob - error": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
 					db := &mockDBJobs{}
-					db.On("FindJob", ctx, params).
-						Return(&models.Job{}, errMock)
+					db.On("FindJob", ctx, findParams).
+						Return(&models.Job{ID: 123, Checksum: "abcde"}, nil)
+					db.On("DeleteJob", ctx, int64(123)).
+						Return(errMock)
 
 					return db
 				},
@@ -218,13 +320,19 @@ func TestFindJob(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
 
-			svc := service.NewJobsService(test.field.db())
+			db := test.field.db()
+			svc := service.NewJobsService(db)
 
-			out, err := svc.FindJob(ctx, params)
+			out, err := svc.DeleteJob(ctx, params)
+
+			db.AssertExpectations(t)
 
 			if test.wants.err != nil {
 				assert.ErrorIs(t, err, test.wants.err)
-				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				if !errors.Is(test.wants.err, service.ErrNotFound) {
+					assert.ErrorIs(t, err, service.ErrDBFailure)
+				}
 
 				return
 			}
@@ -235,16 +343,40 @@ func TestFindJob(t *testing.T) {
 	}
 }
 
-func TestFindJobs(t *testing.T) {
+func TestFindArchivedJobs(t *testing.T) {
 	t.Parallel()
 
 	ctx := context.TODO()
+	params := database.FindJobsParams{Order: "label", Page: 0, State: "", States: nil, Type: ""}
 
-	// Dummy params to assert FindJob's specific arguments.
-	params := database.FindJobsParams{
-		Order: "order",
-		Page:  1,
-		State: "status",
+	db := &mockDBJobs{}
+	db.On("FindArchivedJobs", ctx, params).
+		Return([]models.Job{{ID: 123, Checksum: "abcde"}}, nil).
+		Once()
+	db.On("FindArchivedJobs", ctx, params).
+		Return([]models.Job{}, errMock).
+		Once()
+
+	svc := service.NewJobsService(db)
+
+	out, err := svc.FindArchivedJobs(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Job{{ID: 123, Checksum: "abcde"}}, out)
+
+	_, err = svc.FindArchivedJobs(ctx, params)
+	assert.ErrorIs(t, err, errMock)
+	assert.ErrorIs(t, err, service.ErrDBFailure)
+}
+
+func TestFindCopyJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	// Dummy params to assert FindCopyJob's specific arguments.
+	params := database.FindCopyJobParams{
+		Direction: "mock",
+		UserID:    1,
 	}
 
 	type field struct {
@@ -253,55 +385,65 @@ func TestFindJobs(t *testing.T) {
 
 	type wants struct {
 		err error
-		out []models.Job
+		out *models.CopyJob
 	}
 
 	tests := map[string]struct {
 		field
 		wants
 	}{
-		"method FindJobs - ok": {
+		"method FindCopyJob - ok": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
 					db := &mockDBJobs{}
-					db.On("FindJobs", ctx, params).
-						Return([]models.Job{
-							{
+					db.On("FindCopyJob", ctx, params).
+						Return(&models.CopyJob{
+							Job: &models.Job{
 								ID:       123,
 								Checksum: "abcde",
 							},
-							{
-								ID:       456,
-								Checksum: "wxyz",
-							},
 						}, nil)
 
 					return db
 				},
 			},
 			wants{
-				out: []models.Job{
-					{
+				out: &models.CopyJob{
+					Job: &models.Job{
 						ID:       123,
 						Checksum: "abcde",
 					},
-					{
-						ID:       456,
-						Checksum: "wxyz",
-					},
 				},
 			},
 		},
-		"method FindJobs - error": {
+		"method FindCopyJob - not found": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
+					var j *models.CopyJob
+
 					db := &mockDBJobs{}
-					db.On("FindJobs", ctx, params).
-						Return([]models.Job{}, errMock)
+					db.On("FindCopyJob", ctx, params).
+						Return(j, database.ErrJobNotFound)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrNotFound,
+			},
+		},
+		"method FindCopyJob - error": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindCopyJob", ctx, params).
+						Return(&models.CopyJob{}, errMock)
 
 					return db
 				},
@@ -318,11 +460,14 @@ func TestFindJobs(t *testing.T) {
 
 			svc := service.NewJobsService(test.field.db())
 
-			out, err := svc.FindJobs(ctx, params)
+			out, err := svc.FindCopyJob(ctx, params)
 
 			if test.wants.err != nil {
 				assert.ErrorIs(t, err, test.wants.err)
-				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				if !errors.Is(test.wants.err, service.ErrNotFound) {
+					assert.ErrorIs(t, err, service.ErrDBFailure)
+				}
 
 				return
 			}
@@ -333,15 +478,15 @@ func TestFindJobs(t *testing.T) {
 	}
 }
 
-func TestNewCopyJob(t *testing.T) {
+func TestFindJob(t *testing.T) {
 	t.Parallel()
 
 	ctx := context.TODO()
 
-	// Dummy params to assert NewCopyJob's specific arguments.
-	params := database.NewCopyJobParams{
-		Label: "test label",
-		Type:  "test job type",
+	// Dummy params to assert FindJob's specific arguments.
+	params := database.FindJobParams{
+		Checksum: "mock checksum",
+		ID:       1,
 	}
 
 	type field struct {
@@ -350,47 +495,61 @@ func TestNewCopyJob(t *testing.T) {
 
 	type wants struct {
 		err error
-		out *models.CopyJob
+		out *models.Job
 	}
 
 	tests := map[string]struct {
 		field
 		wants
 	}{
-		"method NewCopyJob - ok": {
+		"method FindJob - ok": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
 					db := &mockDBJobs{}
-					db.On("NewCopyJob", ctx, params).
-						Return(&models.CopyJob{
-							Job: &models.Job{
-								ID:       123,
-								Checksum: "abcde",
-							},
+					db.On("FindJob", ctx, params).
+						Return(&models.Job{
+							ID:       456,
+							Checksum: "abcde",
 						}, nil)
 
 					return db
 				},
 			},
 			wants{
-				out: &models.CopyJob{
-					Job: &models.Job{
-						ID:       123,
-						Checksum: "abcde",
-					},
+				out: &models.Job{
+					ID:       456,
+					Checksum: "abcde",
 				},
 			},
 		},
-		"method NewCopyJob - error": {
+		"method FindJob - not found": {
 			field{
 				db: func() *mockDBJobs {
 					t.Helper()
 
+					var j *models.Job
+
 					db := &mockDBJobs{}
-					db.On("NewCopyJob", ctx, params).
-						Return(&models.CopyJob{}, errMock)
+					db.On("FindJob", ctx, params).
+						Return(j, database.ErrJobNotFound)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrNotFound,
+			},
+		},
+		"method FindJob - error": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindJob", ctx, params).
+						Return(&models.Job{}, errMock)
 
 					return db
 				},
@@ -407,11 +566,14 @@ func TestNewCopyJob(t *testing.T) {
 
 			svc := service.NewJobsService(test.field.db())
 
-			out, err := svc.NewCopyJob(ctx, params)
+			out, err := svc.FindJob(ctx, params)
 
 			if test.wants.err != nil {
 				assert.ErrorIs(t, err, test.wants.err)
-				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				if !errors.Is(test.wants.err, service.ErrNotFound) {
+					assert.ErrorIs(t, err, service.ErrDBFailure)
+				}
 
 				return
 			}
@@ -421,3 +583,643 @@ func TestNewCopyJob(t *testing.T) {
 		})
 	}
 }
+
+func TestFindJobEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	type field struct {
+		db func() *mockDBJobs
+	}
+
+	type wants struct {
+		err error
+		out []models.JobEvent
+	}
+
+	tests := map[string]struct {
+		field
+		wants
+	}{
+		"method FindJobEvents - ok": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindJobEvents", ctx, int64(123), 1).
+						Return([]models.JobEvent{
+							{
+								ID:      1,
+								JobID:   123,
+								Message: "Sync completed",
+							},
+						}, nil)
+
+					return db
+				},
+			},
+			wants{
+				out: []models.JobEvent{
+					{
+						ID:      1,
+						JobID:   123,
+						Message: "Sync completed",
+					},
+				},
+			},
+		},
+		"method FindJobEvents - error": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindJobEvents", ctx, int64(123), 1).
+						Return([]models.JobEvent{}, errMock)
+
+					return db
+				},
+			},
+			wants{
+				err: errMock,
+			},
+		},
+		"method FindJobEvents - not found": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					var e []models.JobEvent
+
+					db := &mockDBJobs{}
+					db.On("FindJobEvents", ctx, int64(123), 1).
+						Return(e, database.ErrJobNotFound)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrNotFound,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := service.NewJobsService(test.field.db())
+
+			out, err := svc.FindJobEvents(ctx, 123, 1)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				if !errors.Is(test.wants.err, service.ErrNotFound) {
+					assert.ErrorIs(t, err, service.ErrDBFailure)
+				}
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
+
+func TestFindJobs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	// Dummy params to assert FindJob's specific arguments.
+	params := database.FindJobsParams{
+		Order: "order",
+		Page:  1,
+		State: "status",
+	}
+
+	type field struct {
+		db func() *mockDBJobs
+	}
+
+	type wants struct {
+		err error
+		out []models.Job
+	}
+
+	tests := map[string]struct {
+		field
+		wants
+	}{
+		"method FindJobs - ok": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindJobs", ctx, params).
+						Return([]models.Job{
+							{
+								ID:       123,
+								Checksum: "abcde",
+							},
+							{
+								ID:       456,
+								Checksum: "wxyz",
+							},
+						}, nil)
+
+					return db
+				},
+			},
+			wants{
+				out: []models.Job{
+					{
+						ID:       123,
+						Checksum: "abcde",
+					},
+					{
+						ID:       456,
+						Checksum: "wxyz",
+					},
+				},
+			},
+		},
+		"method FindJobs - error": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("FindJobs", ctx, params).
+						Return([]models.Job{}, errMock)
+
+					return db
+				},
+			},
+			wants{
+				err: errMock,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := service.NewJobsService(test.field.db())
+
+			out, err := svc.FindJobs(ctx, params)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
+
+func TestNewCopyJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	// Dummy params to assert NewCopyJob's specific arguments, with no NextRun.
+	params := database.NewCopyJobParams{
+		Label: "test label",
+		Type:  "test job type",
+	}
+
+	past, future := time.Now().Add(-24*time.Hour), time.Now().Add(24*time.Hour)
+	futureParams := database.NewCopyJobParams{
+		Label:   "test label",
+		NextRun: &future,
+		Type:    "test job type",
+	}
+
+	type args struct {
+		in database.NewCopyJobParams
+	}
+
+	type field struct {
+		db func() *mockDBJobs
+	}
+
+	type wants struct {
+		dbErr bool
+		err   error
+		out   *models.CopyJob
+	}
+
+	tests := map[string]struct {
+		args
+		field
+		wants
+	}{
+		"method NewCopyJob - ok": {
+			args{
+				in: params,
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("NewCopyJob", ctx, params).
+						Return(&models.CopyJob{
+							Job: &models.Job{
+								ID:       123,
+								Checksum: "abcde",
+							},
+						}, nil)
+
+					return db
+				},
+			},
+			wants{
+				out: &models.CopyJob{
+					Job: &models.Job{
+						ID:       123,
+						Checksum: "abcde",
+					},
+				},
+			},
+		},
+		"method NewCopyJob - error": {
+			args{
+				in: params,
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("NewCopyJob", ctx, params).
+						Return(&models.CopyJob{}, errMock)
+
+					return db
+				},
+			},
+			wants{
+				dbErr: true,
+				err:   errMock,
+			},
+		},
+		"empty label - error": {
+			args{
+				in: database.NewCopyJobParams{
+					Label: "",
+					Type:  "test job type",
+				},
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					return &mockDBJobs{}
+				},
+			},
+			wants{
+				err: database.ErrInvalidLabel,
+			},
+		},
+		"future next run - ok": {
+			args{
+				in: futureParams,
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("NewCopyJob", ctx, futureParams).
+						Return(&models.CopyJob{Job: &models.Job{ID: 456, NextRun: &future}}, nil)
+
+					return db
+				},
+			},
+			wants{
+				out: &models.CopyJob{Job: &models.Job{ID: 456, NextRun: &future}},
+			},
+		},
+		"past next run - error": {
+			args{
+				in: database.NewCopyJobParams{
+					Label:   "test label",
+					NextRun: &past,
+					Type:    "test job type",
+				},
+			},
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					return &mockDBJobs{}
+				},
+			},
+			wants{
+				err: service.ErrInvalidNextRun,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := test.field.db()
+			svc := service.NewJobsService(db)
+
+			out, err := svc.NewCopyJob(ctx, test.args.in)
+
+			db.AssertExpectations(t)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				if test.wants.dbErr {
+					assert.ErrorIs(t, err, service.ErrDBFailure)
+				} else {
+					assert.NotErrorIs(t, err, service.ErrDBFailure)
+				}
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
+
+func TestJobsPing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	db := &mockDBJobs{}
+	db.On("Ping", ctx).
+		Return(nil).
+		Once()
+	db.On("Ping", ctx).
+		Return(errMock).
+		Once()
+
+	svc := service.NewJobsService(db)
+
+	assert.NoError(t, svc.Ping(ctx))
+
+	err := svc.Ping(ctx)
+	assert.ErrorIs(t, err, errMock)
+	assert.ErrorIs(t, err, service.ErrDBFailure)
+}
+
+func TestStreamCopyJobResults(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	job := &models.CopyJob{
+		Job: &models.Job{
+			ID:       123,
+			Checksum: "abcde",
+		},
+	}
+
+	type field struct {
+		db func(io.Writer) *mockDBJobs
+	}
+
+	type wants struct {
+		err error
+	}
+
+	tests := map[string]struct {
+		field
+		wants
+	}{
+		"method StreamCopyJobResults - ok": {
+			field{
+				db: func(w io.Writer) *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("StreamCopyJobResults", ctx, w, job, 3, 50, "handler").
+						Return(nil)
+
+					return db
+				},
+			},
+			wants{
+				err: nil,
+			},
+		},
+		"method StreamCopyJobResults - error": {
+			field{
+				db: func(w io.Writer) *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("StreamCopyJobResults", ctx, w, job, 3, 50, "handler").
+						Return(errMock)
+
+					return db
+				},
+			},
+			wants{
+				err: errMock,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := &bytes.Buffer{}
+			db := test.field.db(w)
+			svc := service.NewJobsService(db)
+
+			err := svc.StreamCopyJobResults(ctx, w, job, 3, 50, "handler")
+
+			db.AssertExpectations(t)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUpdateJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	params := database.UpdateJobParams{ //nolint:exhaustruct
+		ID:    1,
+		State: models.JobStateActive,
+	}
+
+	type field struct {
+		db func() *mockDBJobs
+	}
+
+	type wants struct {
+		dbFailure bool
+		err       error
+		out       *models.Job
+	}
+
+	tests := map[string]struct {
+		field
+		wants
+	}{
+		"ok": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					find := database.FindJobParams{ID: 1} //nolint:exhaustruct
+
+					db := &mockDBJobs{}
+					db.On("UpdateJob", ctx, params).Return(nil)
+					db.On("FindJob", ctx, find).Return(&models.Job{ID: 1, State: models.JobStateActive}, nil)
+
+					return db
+				},
+			},
+			wants{
+				out: &models.Job{ID: 1, State: models.JobStateActive},
+			},
+		},
+		"not found": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("UpdateJob", ctx, params).Return(database.ErrJobNotFound)
+
+					return db
+				},
+			},
+			wants{
+				err: service.ErrNotFound,
+			},
+		},
+		"invalid transition": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("UpdateJob", ctx, params).Return(database.ErrInvalidTransition)
+
+					return db
+				},
+			},
+			wants{
+				err: database.ErrInvalidTransition,
+			},
+		},
+		"error": {
+			field{
+				db: func() *mockDBJobs {
+					t.Helper()
+
+					db := &mockDBJobs{}
+					db.On("UpdateJob", ctx, params).Return(errMock)
+
+					return db
+				},
+			},
+			wants{
+				dbFailure: true,
+				err:       errMock,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := service.NewJobsService(test.field.db())
+
+			out, err := svc.UpdateJob(ctx, params)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+				assert.Equal(t, test.wants.dbFailure, errors.Is(err, service.ErrDBFailure))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants.out, out)
+		})
+	}
+}
+
+func TestUpdateUserPicture(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	params := database.UpdateUserPictureParams{
+		AccountID:  123,
+		Direction:  "following",
+		PictureURL: "https://example.com/pic.jpeg",
+		UserID:     456,
+	}
+
+	tests := map[string]struct {
+		dbErr error
+		err   error
+	}{
+		"method UpdateUserPicture - ok": {
+			dbErr: nil,
+			err:   nil,
+		},
+		"method UpdateUserPicture - error": {
+			dbErr: errMock,
+			err:   errMock,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := &mockDBJobs{}
+			db.On("UpdateUserPicture", ctx, int64(123), int64(456), "following", "https://example.com/pic.jpeg").
+				Return(test.dbErr)
+
+			err := service.NewJobsService(db).UpdateUserPicture(ctx, params)
+
+			db.AssertExpectations(t)
+
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				assert.ErrorIs(t, err, service.ErrDBFailure)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics is a WorkerMetrics that counts the jobs executed by a worker and the users they copied.
+// It serves the counters in the Prometheus text format.
+type Metrics struct {
+	JobsCompleted atomic.Int64 // Jobs whose execution returned no error.
+	JobsFailed    atomic.Int64 // Jobs whose execution returned an error.
+	JobsStarted   atomic.Int64 // Jobs picked up for execution.
+	UsersCopied   atomic.Int64 // Users stored across all the jobs.
+}
+
+// NewMetrics returns a Metrics with all the counters set to zero.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		JobsCompleted: atomic.Int64{},
+		JobsFailed:    atomic.Int64{},
+		JobsStarted:   atomic.Int64{},
+		UsersCopied:   atomic.Int64{},
+	}
+}
+
+// JobExecuted counts a job as either completed or failed.
+func (m *Metrics) JobExecuted(_ string, _ time.Duration, err error) {
+	if err != nil {
+		m.JobsFailed.Add(1)
+
+		return
+	}
+
+	m.JobsCompleted.Add(1)
+}
+
+// JobStarted counts a job that was picked up for execution.
+func (m *Metrics) JobStarted(_ string) {
+	m.JobsStarted.Add(1)
+}
+
+// PageCopied adds the users of a stored page to the copied ones.
+func (m *Metrics) PageCopied(_ string, users int) {
+	m.UsersCopied.Add(int64(users))
+}
+
+// ServeHTTP writes the counters in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	for _, c := range []struct {
+		name, help string
+		value      int64
+	}{
+		{"instaman_jobs_completed_total", "Jobs whose execution returned no error.", m.JobsCompleted.Load()},
+		{"instaman_jobs_failed_total", "Jobs whose execution returned an error.", m.JobsFailed.Load()},
+		{"instaman_jobs_started_total", "Jobs picked up for execution.", m.JobsStarted.Load()},
+		{"instaman_users_copied_total", "Users stored across all the jobs.", m.UsersCopied.Load()},
+	} {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.name, c.help, c.name, c.name, c.value) //nolint:errcheck // Bad client!
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	type wants struct {
+		completed int64
+		failed    int64
+		started   int64
+		users     int64
+	}
+
+	tests := map[string]struct {
+		collect func(*service.Metrics)
+		wants
+	}{
+		"nothing collected": {
+			collect: func(_ *service.Metrics) {},
+			wants:   wants{completed: 0, failed: 0, started: 0, users: 0},
+		},
+		"job started": {
+			collect: func(m *service.Metrics) {
+				m.JobStarted(models.JobTypeCopyFollowers)
+			},
+			wants: wants{completed: 0, failed: 0, started: 1, users: 0},
+		},
+		"job completed": {
+			collect: func(m *service.Metrics) {
+				m.JobStarted(models.JobTypeCopyFollowers)
+				m.PageCopied(models.JobTypeCopyFollowers, 50)
+				m.PageCopied(models.JobTypeCopyFollowers, 7)
+				m.JobExecuted(models.JobTypeCopyFollowers, time.Second, nil)
+			},
+			wants: wants{completed: 1, failed: 0, started: 1, users: 57},
+		},
+		"job failed": {
+			collect: func(m *service.Metrics) {
+				m.JobStarted(models.JobTypeCopyFollowing)
+				m.PageCopied(models.JobTypeCopyFollowing, 50)
+				m.JobExecuted(models.JobTypeCopyFollowing, time.Second, errMock)
+			},
+			wants: wants{completed: 0, failed: 1, started: 1, users: 50},
+		},
+		"many jobs": {
+			collect: func(m *service.Metrics) {
+				for range 3 {
+					m.JobStarted(models.JobTypeCopyFollowers)
+					m.PageCopied(models.JobTypeCopyFollowers, 10)
+					m.JobExecuted(models.JobTypeCopyFollowers, time.Second, nil)
+				}
+
+				m.JobStarted(models.JobTypeCopyFollowing)
+				m.JobExecuted(models.JobTypeCopyFollowing, time.Second, errMock)
+			},
+			wants: wants{completed: 3, failed: 1, started: 4, users: 30},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := service.NewMetrics()
+
+			test.collect(m)
+
+			assert.Equal(t, test.wants.completed, m.JobsCompleted.Load())
+			assert.Equal(t, test.wants.failed, m.JobsFailed.Load())
+			assert.Equal(t, test.wants.started, m.JobsStarted.Load())
+			assert.Equal(t, test.wants.users, m.UsersCopied.Load())
+		})
+	}
+}
+
+func TestMetricsServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	m := service.NewMetrics()
+	m.JobStarted(models.JobTypeCopyFollowers)
+	m.PageCopied(models.JobTypeCopyFollowers, 42)
+	m.JobExecuted(models.JobTypeCopyFollowers, time.Second, nil)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `# HELP instaman_jobs_completed_total Jobs whose execution returned no error.
+# TYPE instaman_jobs_completed_total counter
+instaman_jobs_completed_total 1
+# HELP instaman_jobs_failed_total Jobs whose execution returned an error.
+# TYPE instaman_jobs_failed_total counter
+instaman_jobs_failed_total 0
+# HELP instaman_jobs_started_total Jobs picked up for execution.
+# TYPE instaman_jobs_started_total counter
+instaman_jobs_started_total 1
+# HELP instaman_users_copied_total Users stored across all the jobs.
+# TYPE instaman_users_copied_total counter
+instaman_users_copied_total 42
+`, w.Body.String())
+}
@@ -24,7 +24,11 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"math/rand/v2"
+	"os"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/luca-arch/instaman/database"
@@ -38,87 +42,399 @@ var (
 	ErrNoRetry         = errors.New("instaproxy fatal")
 )
 
+const EventJobCompleted = "job.completed" // Notified when a copy job completes a full sync.
+
+// WebhookEvents lists all the events that can be notified.
+var WebhookEvents = []string{EventJobCompleted} //nolint:gochecknoglobals // Read-only
+
 const (
-	attempts             = 4 // How many pages of followers/following to consecutively fetch before pausing the job.
-	pauseBetweenAttempts = 5 // How many seconds to sleep between each fetch.
+	defaultAttempts             = 4               // How many pages of followers/following to consecutively fetch before pausing the job.
+	defaultPauseBetweenAttempts = 5 * time.Second // How long to sleep between each fetch.
+	defaultLoopDelay            = time.Minute     // How long StartCopying waits between each poll of the database.
+
+	rateLimitPause = time.Hour // How long to wait before resuming a job that was rate limited by Instagram.
 )
 
 type dbworker interface {
-	InsertJobEvent(ctx context.Context, jobID int64, event string) error
+	InsertJobEvent(ctx context.Context, jobID int64, event, hostname string) error
+	NextImmediateJob(context.Context, string) (*models.Job, error)
 	NextJob(context.Context, string) (*models.Job, error)
 	ScheduleJob(context.Context, int64, time.Duration) error
 	StoreCopyJobResults(context.Context, *models.CopyJob, *instaproxy.Connections) error
 	TouchJob(context.Context, int64) error
 	UpdateJob(context.Context, database.UpdateJobParams) error
+	UpdateWorkerJobID(ctx context.Context, hostname string, jobID *int64) error
 }
 
+// notifier describes a service that notifies external systems about jobs' events.
+type notifier interface {
+	Notify(ctx context.Context, event string, jobID int64)
+}
+
+// WorkerMetrics describes a collector of the worker's execution metrics.
+type WorkerMetrics interface {
+	JobExecuted(jobType string, elapsed time.Duration, err error) // Called after each job execution.
+	JobStarted(jobType string)                                    // Called before each job execution.
+	PageCopied(jobType string, users int)                         // Called after each page of users is stored.
+}
+
+// JobFilter decides whether a copy job must be skipped, e.g. because its account is suspended.
+// The reason is logged when the job is skipped.
+type JobFilter func(*models.CopyJob) (skip bool, reason string)
+
+// runStats tracks the progress of a single RunCopyJob execution.
+type runStats struct {
+	totalPages int
+	totalUsers int
+}
+
+// workerSlotKey is the context key of the polling loop's slot, numbered from 1, that StartCopying runs.
+type workerSlotKey struct{}
+
 // Worker is the service that abstracts scheduled jobs operations from the database layer.
 type Worker struct {
-	db        dbworker
-	instagram igclient
-	logger    *slog.Logger
+	attempts    int
+	concurrency int
+	db          dbworker
+	emitter     EventEmitter // Optional, events are not emitted when nil.
+	filter      JobFilter    // Optional, no jobs are skipped when nil.
+	hostname    string       // Identifies this worker in the `workers` table and in the jobs' audit logs.
+	instagram   igclient
+	logger      *slog.Logger
+	loopDelay   time.Duration // How long each polling loop of StartCopying waits between iterations.
+	maxDelay    time.Duration // Optional, caps the pause that follows each job execution.
+	metrics     WorkerMetrics // Optional, metrics are not collected when nil.
+	notifier    notifier      // Optional, events are not notified when nil.
+	pause       time.Duration // How long to sleep between each page fetched by RunCopyJob.
+	version     string        // The worker's build version, recorded in the jobs' audit logs.
+}
+
+// WorkerOption configures optional Worker settings.
+type WorkerOption func(*Worker)
+
+// WithWorkerAttempts sets how many pages of users are consecutively fetched before pausing a job.
+// Values lower than 1 are ignored.
+func WithWorkerAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		w.WithAttempts(n)
+	}
+}
+
+// WithWorkerConcurrency sets how many polling loops StartCopying runs, and so how many jobs can be executed in parallel.
+// Values lower than 1 are ignored.
+func WithWorkerConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		w.WithConcurrency(n)
+	}
+}
+
+// WithWorkerEventEmitter sets the receiver of the jobs' lifecycle events.
+func WithWorkerEventEmitter(e EventEmitter) WorkerOption {
+	return func(w *Worker) {
+		w.emitter = e
+	}
+}
+
+// WithWorkerMaxDelay caps the pause that follows each job execution, which is otherwise 10~15 minutes.
+// Values lower than 1 disable the cap.
+func WithWorkerMaxDelay(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.maxDelay = max(d, 0)
+	}
+}
+
+// WithWorkerMetrics sets the collector of the worker's execution metrics.
+func WithWorkerMetrics(m WorkerMetrics) WorkerOption {
+	return func(w *Worker) {
+		w.metrics = m
+	}
 }
 
 // NewWorkerService sets up and returns a new Worker Service.
-func NewWorkerService(db dbworker, logger *slog.Logger, instagramClient igclient) *Worker {
-	return &Worker{
-		db:        db,
-		instagram: instagramClient,
-		logger:    logger,
+func NewWorkerService(db dbworker, logger *slog.Logger, instagramClient igclient, opts ...WorkerOption) *Worker {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	w := &Worker{
+		attempts:    defaultAttempts,
+		concurrency: 1,
+		db:          db,
+		emitter:     nil,
+		filter:      nil,
+		hostname:    hostname,
+		instagram:   instagramClient,
+		logger:      logger,
+		loopDelay:   defaultLoopDelay,
+		maxDelay:    0,
+		metrics:     nil,
+		notifier:    nil,
+		pause:       defaultPauseBetweenAttempts,
+		version:     buildVersion(),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// WithAttempts sets how many pages of users are consecutively fetched before pausing a job.
+// Values lower than 1 are ignored.
+func (w *Worker) WithAttempts(n int) *Worker {
+	if n > 0 {
+		w.attempts = n
+	}
+
+	return w
+}
+
+// WithLoopDelay sets how long each polling loop of StartCopying waits between iterations, which is one minute by default.
+// Values lower than 1 are ignored.
+func (w *Worker) WithLoopDelay(d time.Duration) *Worker {
+	if d > 0 {
+		w.loopDelay = d
+	}
+
+	return w
+}
+
+// WithPauseBetweenAttempts sets how long to sleep between each page of users fetched for a job.
+// Negative values are ignored.
+func (w *Worker) WithPauseBetweenAttempts(d time.Duration) *Worker {
+	if d >= 0 {
+		w.pause = d
 	}
+
+	return w
+}
+
+// WithConcurrency sets how many polling loops StartCopying runs, and so how many jobs can be executed in parallel.
+// Values lower than 1 are ignored.
+func (w *Worker) WithConcurrency(n int) *Worker {
+	if n > 0 {
+		w.concurrency = n
+	}
+
+	return w
+}
+
+// WithJobFilter sets the filter that StartCopying uses to skip jobs.
+// Skipped jobs are rescheduled as if they had completed a full sync.
+func (w *Worker) WithJobFilter(f JobFilter) *Worker {
+	w.filter = f
+
+	return w
+}
+
+// WithNotifier sets the service that is notified when a job completes.
+func (w *Worker) WithNotifier(n notifier) *Worker {
+	w.notifier = n
+
+	return w
 }
 
+// StartCopying runs as many loops as the configured concurrency, each polling the database for scheduled copy jobs and
+// executing them one at a time. Jobs are claimed atomically, so that no two loops (or workers) pick up the same one.
+// It blocks until the context is cancelled and all the running jobs have returned.
 func (w *Worker) StartCopying(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for slot := range w.concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			w.copyLoop(context.WithValue(ctx, workerSlotKey{}, slot+1))
+		}()
+	}
+
+	<-ctx.Done()
+	w.logger.Info("shutting down worker...")
+	wg.Wait()
+}
+
+// workerName returns the name that identifies the polling loop running ctx in the `workers` table, so that each loop
+// records its own current job. It is the hostname, suffixed with the loop's slot when StartCopying runs more than one.
+func (w *Worker) workerName(ctx context.Context) string {
+	slot, ok := ctx.Value(workerSlotKey{}).(int)
+	if !ok || w.concurrency == 1 {
+		return w.hostname
+	}
+
+	return fmt.Sprintf("%s#%d", w.hostname, slot)
+}
+
+// copyLoop polls the database for scheduled copy jobs and executes them, until the context is cancelled.
+func (w *Worker) copyLoop(ctx context.Context) {
 	// Start first loop immediately.
 	delay := time.Millisecond
 
 	for {
 		select {
 		case <-ctx.Done():
-			w.logger.Info("shutting down worker...")
-
 			return
 		case <-time.After(delay):
-			job, err := w.NextCopyJob(ctx)
+			delay = w.loopDelay
 
-			// Wait one minute between each iteration.
-			delay = time.Minute
+			job, err := w.NextCopyJob(ctx)
+			start := time.Now()
 
 			switch {
 			case err != nil:
 				w.logger.Error("could not fetch job", "error", err)
 			case job == nil:
-				continue
+			case w.skipJob(ctx, job):
 			case w.db.TouchJob(ctx, job.ID) != nil:
-				w.logger.Error("could not update job timestamp", "job.id", job.ID, "job.label", job.Label)
+				w.logger.Error("could not update job timestamp", slog.Any("job", job))
 			default:
-				w.logger.Info("starting job", "job.id", job.ID, "job.label", job.Label, "job.type", job.Type)
+				w.logPickedUp(ctx, job)
+				w.runJob(ctx, job, start)
+			}
+		}
+	}
+}
 
-				if err := w.RunCopyJob(ctx, job); err != nil {
-					w.logger.Error("could not execute job", "error", err, "job.id", job.ID, "job.label", job.Label)
+// RunOnce executes the active copy job with the earliest `next_run`, even if it is not due yet, and returns once it
+// has completed. This way a job that an operator has just resumed does not have to wait for its schedule.
+// It reports whether a job was executed; the returned error is the job's execution error, if any.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	job, err := w.nextCopyJob(ctx, w.db.NextImmediateJob)
+	start := time.Now()
 
-					if err := w.db.InsertJobEvent(ctx, job.ID, err.Error()); err != nil {
-						w.logger.Error("could not log job event", "error", err)
-					}
-				}
+	switch {
+	case err != nil:
+		return false, err
+	case job == nil, w.skipJob(ctx, job):
+		return false, nil
+	}
 
-				//nolint:durationcheck // Pause for 10~15 minutes not to flood the api.
-				sleep := time.Minute * randDuration(10, 15) //nolint:mnd
-				time.Sleep(sleep)
-			}
+	if err := w.db.TouchJob(ctx, job.ID); err != nil {
+		return false, errors.Join(ErrDBFailure, err)
+	}
+
+	w.logPickedUp(ctx, job)
+
+	return true, w.executeJob(ctx, job, start)
+}
+
+// logPickedUp logs the event of a job being picked up, before it starts running.
+func (w *Worker) logPickedUp(ctx context.Context, job *models.CopyJob) {
+	event := fmt.Sprintf("job picked up for execution by %s (version %s)", w.hostname, w.version)
+	if err := w.db.InsertJobEvent(ctx, job.ID, event, w.hostname); err != nil {
+		w.logger.Error("could not log job event", "error", err)
+	}
+}
+
+// skipJob reports whether the job filter rejected a job, in which case the job is rescheduled.
+func (w *Worker) skipJob(ctx context.Context, job *models.CopyJob) bool {
+	if w.filter == nil {
+		return false
+	}
+
+	skip, reason := w.filter(job)
+	if !skip {
+		return false
+	}
+
+	w.logger.Info("skipping job", "reason", reason, slog.Any("job", job))
+
+	if err := w.db.ScheduleJob(ctx, job.ID, syncInterval(job.Metadata.Frequency)); err != nil {
+		w.logger.Error("could not reschedule skipped job", "error", err, slog.Any("job", job))
+	}
+
+	return true
+}
+
+// runJob executes a CopyJob that was picked up at start, and then pauses before returning, unless the context is
+// cancelled.
+func (w *Worker) runJob(ctx context.Context, job *models.CopyJob, start time.Time) {
+	_ = w.executeJob(ctx, job, start)
+
+	//nolint:durationcheck // Pause for 10~15 minutes not to flood the api.
+	sleep := time.Minute * randDuration(10, 15) //nolint:mnd
+	if w.maxDelay > 0 {
+		sleep = min(sleep, w.maxDelay)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(sleep):
+	}
+}
+
+// executeJob executes a CopyJob that was picked up at start, and logs its execution error in the job's audit logs.
+func (w *Worker) executeJob(ctx context.Context, job *models.CopyJob, start time.Time) error {
+	w.logger.Info("starting job", slog.Any("job", job))
+
+	if w.metrics != nil {
+		w.metrics.JobStarted(job.Type)
+	}
+
+	err := w.RunCopyJob(ctx, job, start)
+
+	if w.metrics != nil {
+		w.metrics.JobExecuted(job.Type, time.Since(start), err)
+	}
+
+	if err != nil {
+		w.logger.Error("could not execute job", "error", err, slog.Any("job", job))
+
+		// Don't bother logging the event if the worker is shutting down.
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if err := w.db.InsertJobEvent(ctx, job.ID, err.Error(), w.hostname); err != nil {
+			w.logger.Error("could not log job event", "error", err)
 		}
 	}
+
+	return err
+}
+
+// failJob marks a job as errored after instaproxy failed with err, so that it's not picked up again.
+// It returns an ErrDBFailure if the job's state could not be updated, as the job would then be retried.
+func (w *Worker) failJob(ctx context.Context, cj *models.CopyJob, err error) error {
+	updateErr := w.db.UpdateJob(ctx, database.UpdateJobParams{ //nolint:exhaustruct
+		ID:    cj.ID,
+		State: models.JobStateError,
+	})
+	if updateErr != nil {
+		w.logger.Error("could not mark job as errored", "error", updateErr, slog.Any("job", cj))
+	}
+
+	if err := w.db.InsertJobEvent(ctx, cj.ID, err.Error(), w.hostname); err != nil {
+		w.logger.Error("could not log job event", "error", err)
+	}
+
+	if updateErr != nil {
+		return errors.Join(ErrDBFailure, updateErr, err)
+	}
+
+	return errors.Join(err, ErrNoRetry)
 }
 
 // NextCopyJob returns the next scheduled CopyJob that is ready for execution.
 func (w *Worker) NextCopyJob(ctx context.Context) (*models.CopyJob, error) {
-	j, err := w.db.NextJob(ctx, models.JobTypeCopyFollowers)
+	return w.nextCopyJob(ctx, w.db.NextJob)
+}
+
+// nextCopyJob returns the first CopyJob that next finds, looking for copy-followers jobs before copy-following ones.
+func (w *Worker) nextCopyJob(
+	ctx context.Context,
+	next func(context.Context, string) (*models.Job, error),
+) (*models.CopyJob, error) {
+	j, err := next(ctx, models.JobTypeCopyFollowers)
 
 	switch {
 	case err != nil:
 		return nil, errors.Join(ErrDBFailure, err)
 	case j == nil:
-		j, err = w.db.NextJob(ctx, models.JobTypeCopyFollowing)
+		j, err = next(ctx, models.JobTypeCopyFollowing)
 	}
 
 	switch {
@@ -136,27 +452,67 @@ func (w *Worker) NextCopyJob(ctx context.Context) (*models.CopyJob, error) {
 	return cj, nil
 }
 
-// RunCopyJob executes a CopyJob.
-func (w *Worker) RunCopyJob(ctx context.Context, cj *models.CopyJob) error {
-	if err := w.db.InsertJobEvent(ctx, cj.ID, "job picked up for execution"); err != nil {
-		w.logger.Error("could not log job event", "error", err)
+// RunCopyJob executes a CopyJob that was picked up at start, which is when the sync summary's elapsed time begins.
+// The job is recorded as the worker's current one until RunCopyJob returns.
+func (w *Worker) RunCopyJob(ctx context.Context, cj *models.CopyJob, start time.Time) error {
+	name := w.workerName(ctx)
+
+	if err := w.db.UpdateWorkerJobID(ctx, name, &cj.ID); err != nil {
+		w.logger.Error("could not update worker's current job", "error", err, slog.Any("job", cj))
 	}
 
+	defer func() {
+		if err := w.db.UpdateWorkerJobID(ctx, name, nil); err != nil {
+			w.logger.Error("could not update worker's current job", "error", err, slog.Any("job", cj))
+		}
+	}()
+
+	emit(ctx, w.emitter, EventJobStarted, cj.ID, cj.Label)
+
 	cursor, done := cj.Metadata.Cursor, false
+	stats := runStats{totalPages: 0, totalUsers: 0}
 
 Loop:
-	for a := range attempts {
-		res, err := w.instagram.GetFollowers(ctx, cj.Metadata.UserID, cursor)
+	for a := range w.attempts {
+		var (
+			res *instaproxy.Connections
+			err error
+		)
+
+		if cj.Type == models.JobTypeCopyFollowing {
+			res, err = w.instagram.GetFollowing(ctx, cj.Metadata.UserID, cursor)
+		} else {
+			res, err = w.instagram.GetFollowers(ctx, cj.Metadata.UserID, cursor)
+		}
+
+		// Rate limits are temporary, so the job is resumed later rather than marked as errored.
+		if errors.Is(err, instaproxy.ErrRateLimited) {
+			w.logger.Warn("rate limited by instaproxy, job will resume later",
+				"error", err, slog.Any("job", cj), "resumeIn", rateLimitPause)
+
+			if err := w.db.ScheduleJob(ctx, cj.ID, rateLimitPause); err != nil {
+				return errors.Join(ErrDBFailure, err)
+			}
+
+			w.logSummary(ctx, cj, stats, start, false)
+
+			return nil
+		}
+
 		if err != nil {
-			return errors.Join(
-				w.db.UpdateJob(ctx, database.UpdateJobParams{ //nolint:exhaustruct
-					ID:    cj.ID,
-					State: models.JobStateError,
-				}),
-				w.db.InsertJobEvent(ctx, cj.ID, err.Error()),
-				err,
-				ErrNoRetry,
-			)
+			emit(ctx, w.emitter, EventJobFailed, cj.ID, err.Error())
+
+			return w.failJob(ctx, cj, err)
+		}
+
+		// Surface the total size reported by Instagram as soon as a new sync starts, so that its progress can be
+		// tracked before it completes.
+		if a == 0 && cj.Metadata.Cursor == nil && res.TotalCount != nil {
+			cj.Total = int32(min(*res.TotalCount, math.MaxInt32)) //nolint:gosec // Capped
+
+			if err := w.db.InsertJobEvent(ctx, cj.ID, fmt.Sprintf("Sync started: %d users to copy", *res.TotalCount), w.hostname); err != nil {
+				w.logger.Error("could not log job event", "error", err)
+			}
 		}
 
 		cursor = res.Next
@@ -165,7 +521,14 @@ Loop:
 			return errors.Join(ErrDBFailure, err)
 		}
 
-		if err := w.db.InsertJobEvent(ctx, cj.ID, fmt.Sprintf("Copied %d users. Next cursor: %v", len(res.Users), cursor)); err != nil {
+		stats.totalPages++
+		stats.totalUsers += len(res.Users)
+
+		if w.metrics != nil {
+			w.metrics.PageCopied(cj.Type, len(res.Users))
+		}
+
+		if err := w.db.InsertJobEvent(ctx, cj.ID, fmt.Sprintf("Copied %d users. Next cursor: %v", len(res.Users), cursor), w.hostname); err != nil {
 			w.logger.Error("could not log job event", "error", err)
 		}
 
@@ -174,34 +537,80 @@ Loop:
 			done = true
 
 			break Loop
-		case a != attempts:
-			time.Sleep(time.Duration(pauseBetweenAttempts) * time.Second)
+		case a != w.attempts-1:
+			time.Sleep(w.pause)
 		}
 	}
 
-	//nolint:durationcheck // Pause for 20~30 minutes not to flood the api.
-	freq := time.Minute * randDuration(20, 30) //nolint:mnd
-
+	freq := pauseInterval()
 	if done {
-		if err := w.db.InsertJobEvent(ctx, cj.ID, "Sync completed"); err != nil {
-			w.logger.Error("could not log job event", "error", err)
-		}
-
-		switch cj.Metadata.Frequency {
-		case models.JobFrequencyDaily:
-			freq = time.Hour * 24 //nolint:mnd
-		case models.JobFrequencyWeekly:
-			freq = time.Hour * 24 * 7 //nolint:mnd
-		}
+		freq = syncInterval(cj.Metadata.Frequency)
 	}
 
 	if err := w.db.ScheduleJob(ctx, cj.ID, freq); err != nil {
 		return errors.Join(ErrDBFailure, err)
 	}
 
+	w.logSummary(ctx, cj, stats, start, done)
+
+	if done && w.notifier != nil {
+		w.notifier.Notify(ctx, EventJobCompleted, cj.ID)
+	}
+
 	return nil
 }
 
+// logSummary logs the summary of a job's execution in its audit logs, and emits the matching lifecycle event.
+// A job that is not done was paused, either after its attempts or because Instagram rate limited it.
+func (w *Worker) logSummary(ctx context.Context, cj *models.CopyJob, stats runStats, start time.Time, done bool) {
+	summary := "Sync paused"
+	if done {
+		summary = "Sync completed"
+	}
+
+	elapsed := time.Since(start).Round(time.Second)
+	summary = fmt.Sprintf("%s: %d users across %d pages in %s", summary, stats.totalUsers, stats.totalPages, elapsed)
+
+	if err := w.db.InsertJobEvent(ctx, cj.ID, summary, w.hostname); err != nil {
+		w.logger.Error("could not log job event", "error", err)
+	}
+
+	if done {
+		emit(ctx, w.emitter, EventJobCompleted, cj.ID, summary)
+	} else {
+		emit(ctx, w.emitter, EventJobPaused, cj.ID, summary)
+	}
+}
+
+// pauseInterval returns how long to wait before resuming a job that has not completed a full sync yet.
+func pauseInterval() time.Duration {
+	//nolint:durationcheck // Pause for 20~30 minutes not to flood the api.
+	return time.Minute * randDuration(20, 30) //nolint:mnd
+}
+
+// syncInterval returns how long to wait before running again a job that has completed a full sync.
+func syncInterval(frequency string) time.Duration {
+	switch frequency {
+	case models.JobFrequencyDaily:
+		return time.Hour * 24 //nolint:mnd
+	case models.JobFrequencyWeekly:
+		return time.Hour * 24 * 7 //nolint:mnd
+	case models.JobFrequencyMonthly:
+		return time.Hour * 24 * 30 //nolint:mnd
+	default:
+		return pauseInterval()
+	}
+}
+
+// buildVersion returns the version of the main module the worker was built from.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	return "unknown"
+}
+
 // randDuration returns a random duration in between two values.
 func randDuration(from, to int) time.Duration {
 	d := from + rand.IntN(to-from) //nolint:gosec
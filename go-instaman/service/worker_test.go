@@ -0,0 +1,828 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDBWorker struct {
+	mock.Mock
+}
+
+func (m *mockDBWorker) InsertJobEvent(ctx context.Context, jobID int64, event, hostname string) error {
+	args := m.Called(ctx, jobID, event, hostname)
+
+	return args.Error(0)
+}
+
+func (m *mockDBWorker) NextImmediateJob(ctx context.Context, jobType string) (*models.Job, error) {
+	args := m.Called(ctx, jobType)
+
+	return args.Get(0).(*models.Job), args.Error(1)
+}
+
+func (m *mockDBWorker) NextJob(ctx context.Context, jobType string) (*models.Job, error) {
+	args := m.Called(ctx, jobType)
+
+	return args.Get(0).(*models.Job), args.Error(1)
+}
+
+func (m *mockDBWorker) ScheduleJob(ctx context.Context, jobID int64, nextRun time.Duration) error {
+	args := m.Called(ctx, jobID, nextRun)
+
+	return args.Error(0)
+}
+
+func (m *mockDBWorker) StoreCopyJobResults(ctx context.Context, cj *models.CopyJob, res *instaproxy.Connections) error {
+	args := m.Called(ctx, cj, res)
+
+	return args.Error(0)
+}
+
+func (m *mockDBWorker) TouchJob(ctx context.Context, jobID int64) error {
+	args := m.Called(ctx, jobID)
+
+	return args.Error(0)
+}
+
+func (m *mockDBWorker) UpdateJob(ctx context.Context, params database.UpdateJobParams) error {
+	args := m.Called(ctx, params)
+
+	return args.Error(0)
+}
+
+func (m *mockDBWorker) UpdateWorkerJobID(ctx context.Context, hostname string, jobID *int64) error {
+	args := m.Called(ctx, hostname, jobID)
+
+	return args.Error(0)
+}
+
+// concurrentDBWorker is a dbworker whose NextJob finds no jobs, and records how many of its calls overlap.
+// Each call blocks until `concurrency` calls are in flight, or the test's context is done.
+type concurrentDBWorker struct {
+	mockDBWorker
+
+	active      atomic.Int32
+	concurrency int32
+	maxSeen     atomic.Int32
+	once        sync.Once
+	reached     chan struct{} // Closed once `concurrency` calls are in flight.
+}
+
+func (m *concurrentDBWorker) NextJob(ctx context.Context, _ string) (*models.Job, error) {
+	n := m.active.Add(1)
+	defer m.active.Add(-1)
+
+	for {
+		seen := m.maxSeen.Load()
+		if n <= seen || m.maxSeen.CompareAndSwap(seen, n) {
+			break
+		}
+	}
+
+	if n == m.concurrency {
+		m.once.Do(func() { close(m.reached) })
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-m.reached:
+	}
+
+	return nil, nil //nolint:nilnil // It means not found.
+}
+
+func TestWorkerConcurrency(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		concurrency int
+	}{
+		"default": {
+			concurrency: 0,
+		},
+		"one loop": {
+			concurrency: 1,
+		},
+		"three loops": {
+			concurrency: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			t.Cleanup(cancel)
+
+			expected := int32(max(test.concurrency, 1))
+			db := &concurrentDBWorker{concurrency: expected, reached: make(chan struct{})}
+
+			w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), nil,
+				service.WithWorkerConcurrency(test.concurrency))
+
+			done := make(chan struct{})
+
+			go func() {
+				w.StartCopying(ctx)
+				close(done)
+			}()
+
+			select {
+			case <-db.reached:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("NextJob was not called by %d loops at once", expected)
+			}
+
+			cancel()
+			<-done
+
+			assert.Equal(t, expected, db.maxSeen.Load())
+			assert.Zero(t, db.active.Load())
+		})
+	}
+}
+
+func TestWorkerSlots(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	var (
+		conns *instaproxy.Connections
+		noJob *models.Job
+	)
+
+	db := &mockDBWorker{}
+	db.On("NextJob", mock.Anything, models.JobTypeCopyFollowers).
+		Return(&models.Job{BinData: []byte(`{"userID":111, "frequency":"daily"}`), ID: 1, Type: models.JobTypeCopyFollowers}, nil).
+		Once()
+	db.On("NextJob", mock.Anything, models.JobTypeCopyFollowers).
+		Return(&models.Job{BinData: []byte(`{"userID":222, "frequency":"daily"}`), ID: 2, Type: models.JobTypeCopyFollowers}, nil).
+		Once()
+	db.On("NextJob", mock.Anything, mock.Anything).Return(noJob, nil)
+	db.On("TouchJob", mock.Anything, mock.Anything).Return(nil)
+	db.On("InsertJobEvent", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	db.On("UpdateWorkerJobID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	db.On("UpdateJob", mock.Anything, mock.Anything).Return(nil)
+
+	called := make(chan struct{}, 2)
+
+	ig := &mockInstagramClient{}
+	ig.On("GetFollowers", mock.Anything, mock.Anything, mock.Anything).
+		Return(conns, errMock).
+		Run(func(mock.Arguments) { called <- struct{}{} })
+
+	// Each loop pauses for minutes after a job, so the two jobs are executed by different loops.
+	w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig,
+		service.WithWorkerConcurrency(2))
+
+	done := make(chan struct{})
+
+	go func() {
+		w.StartCopying(ctx)
+		close(done)
+	}()
+
+	for range 2 {
+		select {
+		case <-called:
+		case <-time.After(5 * time.Second):
+			t.Fatal("jobs were not executed")
+		}
+	}
+
+	cancel()
+	<-done
+
+	// Each loop records its current job under its own name, so that they don't overwrite each other.
+	names := map[int64]string{}
+
+	for _, c := range db.Calls {
+		if c.Method != "UpdateWorkerJobID" {
+			continue
+		}
+
+		if jobID, ok := c.Arguments.Get(2).(*int64); ok && jobID != nil {
+			names[*jobID] = c.Arguments.String(1)
+		}
+	}
+
+	require.Len(t, names, 2)
+	assert.ElementsMatch(t, []string{hostname + "#1", hostname + "#2"}, []string{names[1], names[2]})
+}
+
+func TestWorkerLoopDelay(t *testing.T) {
+	t.Parallel()
+
+	var noJob *models.Job
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	t.Cleanup(cancel)
+
+	db := &mockDBWorker{}
+	db.On("NextJob", mock.Anything, models.JobTypeCopyFollowers).Return(noJob, nil)
+	db.On("NextJob", mock.Anything, models.JobTypeCopyFollowing).Return(noJob, nil)
+
+	w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), nil).
+		WithLoopDelay(10 * time.Millisecond)
+
+	w.StartCopying(ctx)
+
+	// Each call to NextCopyJob looks for both job types, so this counts how many times it was called.
+	polls := 0
+
+	for _, c := range db.Calls {
+		if c.Method == "NextJob" && c.Arguments.String(1) == models.JobTypeCopyFollowers {
+			polls++
+		}
+	}
+
+	assert.GreaterOrEqual(t, polls, 2)
+}
+
+func TestWorkerJobFilter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	scheduled := make(chan struct{})
+
+	db := &mockDBWorker{}
+	db.On("NextJob", mock.Anything, models.JobTypeCopyFollowers).
+		Return(&models.Job{
+			BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+			ID:      1,
+			Type:    models.JobTypeCopyFollowers,
+		}, nil).
+		Once()
+	db.On("ScheduleJob", mock.Anything, int64(1), 24*time.Hour).
+		Return(nil).
+		Run(func(mock.Arguments) { close(scheduled) }).
+		Once()
+
+	filter := func(cj *models.CopyJob) (bool, string) {
+		return cj.Metadata.UserID == 111, "account suspended"
+	}
+
+	w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), nil).
+		WithJobFilter(filter)
+
+	done := make(chan struct{})
+
+	go func() {
+		w.StartCopying(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-scheduled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("skipped job was not rescheduled")
+	}
+
+	cancel()
+	<-done
+
+	db.AssertExpectations(t)
+	db.AssertNotCalled(t, "TouchJob", mock.Anything, mock.Anything)
+	db.AssertNotCalled(t, "UpdateWorkerJobID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWorkerEventsOrder(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	db := &mockDBWorker{}
+	db.On("NextJob", mock.Anything, models.JobTypeCopyFollowers).
+		Return(&models.Job{
+			BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+			ID:      1,
+			Type:    models.JobTypeCopyFollowers,
+		}, nil).
+		Once()
+	db.On("TouchJob", mock.Anything, int64(1)).Return(nil)
+	db.On("InsertJobEvent", mock.Anything, int64(1), mock.Anything, hostname).Return(nil)
+	db.On("UpdateWorkerJobID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	db.On("UpdateJob", mock.Anything, mock.Anything).Return(nil)
+
+	called := make(chan struct{})
+
+	var conns *instaproxy.Connections
+
+	ig := &mockInstagramClient{}
+	ig.On("GetFollowers", mock.Anything, int64(111), mock.Anything).
+		Return(conns, errMock).
+		Run(func(mock.Arguments) { close(called) }).
+		Once()
+
+	w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig,
+		service.WithWorkerMaxDelay(time.Millisecond))
+
+	done := make(chan struct{})
+
+	go func() {
+		w.StartCopying(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not executed")
+	}
+
+	cancel()
+	<-done
+
+	methods := make([]string, 0, len(db.Calls))
+	for _, c := range db.Calls {
+		methods = append(methods, c.Method)
+	}
+
+	require.GreaterOrEqual(t, len(methods), 4)
+	assert.Equal(t, []string{"NextJob", "TouchJob", "InsertJobEvent", "UpdateWorkerJobID"}, methods[:4])
+	assert.True(t, strings.HasPrefix(db.Calls[2].Arguments.String(2), "job picked up for execution by "+hostname+" (version "))
+}
+
+func TestWorkerRunOnce(t *testing.T) {
+	t.Parallel()
+
+	var noJob *models.Job
+
+	pausedJob := &models.Job{
+		BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+		ID:      1,
+		Type:    models.JobTypeCopyFollowers,
+	}
+
+	type fields struct {
+		db func() *mockDBWorker
+		ig func() *mockInstagramClient
+	}
+
+	type wants struct {
+		err     error
+		failed  int64
+		ran     bool
+		started int64
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"no active jobs": {
+			fields{
+				db: func() *mockDBWorker {
+					db := &mockDBWorker{}
+					db.On("NextImmediateJob", mock.Anything, models.JobTypeCopyFollowers).Return(noJob, nil).Once()
+					db.On("NextImmediateJob", mock.Anything, models.JobTypeCopyFollowing).Return(noJob, nil).Once()
+
+					return db
+				},
+				ig: func() *mockInstagramClient {
+					return &mockInstagramClient{}
+				},
+			},
+			wants{err: nil, ran: false},
+		},
+		"db error": {
+			fields{
+				db: func() *mockDBWorker {
+					db := &mockDBWorker{}
+					db.On("NextImmediateJob", mock.Anything, models.JobTypeCopyFollowers).Return(noJob, errMock).Once()
+
+					return db
+				},
+				ig: func() *mockInstagramClient {
+					return &mockInstagramClient{}
+				},
+			},
+			wants{err: service.ErrDBFailure, ran: false},
+		},
+		"job executed with error": {
+			fields{
+				db: func() *mockDBWorker {
+					db := &mockDBWorker{}
+					db.On("NextImmediateJob", mock.Anything, models.JobTypeCopyFollowers).Return(pausedJob, nil).Once()
+					db.On("TouchJob", mock.Anything, int64(1)).Return(nil).Once()
+					db.On("InsertJobEvent", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil)
+					db.On("UpdateWorkerJobID", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+					db.On("UpdateJob", mock.Anything, mock.Anything).Return(nil)
+
+					return db
+				},
+				ig: func() *mockInstagramClient {
+					var conns *instaproxy.Connections
+
+					ig := &mockInstagramClient{}
+					ig.On("GetFollowers", mock.Anything, int64(111), mock.Anything).Return(conns, errMock).Once()
+
+					return ig
+				},
+			},
+			wants{err: errMock, failed: 1, ran: true, started: 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := test.fields.db()
+			ig := test.fields.ig()
+
+			metrics := service.NewMetrics()
+
+			w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig, service.WithWorkerMetrics(metrics))
+
+			ran, err := w.RunOnce(context.TODO())
+
+			db.AssertExpectations(t)
+			ig.AssertExpectations(t)
+			db.AssertNotCalled(t, "NextJob", mock.Anything, mock.Anything)
+
+			assert.Equal(t, test.wants.ran, ran)
+			assert.Equal(t, test.wants.started, metrics.JobsStarted.Load())
+			assert.Equal(t, test.wants.failed, metrics.JobsFailed.Load())
+			assert.Zero(t, metrics.JobsCompleted.Load())
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRunCopyJobDirection(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	type wants struct {
+		called    string
+		notCalled string
+	}
+
+	tests := map[string]struct {
+		jobType string
+		wants
+	}{
+		"copy-followers": {
+			jobType: models.JobTypeCopyFollowers,
+			wants:   wants{called: "GetFollowers", notCalled: "GetFollowing"},
+		},
+		"copy-following": {
+			jobType: models.JobTypeCopyFollowing,
+			wants:   wants{called: "GetFollowing", notCalled: "GetFollowers"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cj, err := models.NewCopyJob(&models.Job{
+				BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+				ID:      1,
+				Type:    test.jobType,
+			})
+			require.NoError(t, err)
+
+			conns := &instaproxy.Connections{
+				Users: []instaproxy.User{{ID: 222, Handler: "john_doe"}},
+			}
+
+			// The database layer picks the table to write from the job type, so the job must be passed as is.
+			// The job is recorded as the worker's current one, which is then reset once the job has completed.
+			db := &mockDBWorker{}
+			db.On("UpdateWorkerJobID", ctx, hostname, &cj.ID).Return(nil).Once()
+			db.On("UpdateWorkerJobID", ctx, hostname, (*int64)(nil)).Return(nil).Once()
+			db.On("StoreCopyJobResults", ctx, cj, conns).Return(nil).Once()
+			db.On("InsertJobEvent", ctx, int64(1), mock.Anything, mock.Anything).Return(nil)
+			db.On("ScheduleJob", ctx, int64(1), 24*time.Hour).Return(nil).Once()
+
+			ig := &mockInstagramClient{}
+			ig.On(test.wants.called, ctx, int64(111), mock.Anything).Return(conns, nil).Once()
+
+			w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig)
+
+			require.NoError(t, w.RunCopyJob(ctx, cj, time.Now()))
+
+			db.AssertExpectations(t)
+			ig.AssertExpectations(t)
+			ig.AssertNotCalled(t, test.wants.notCalled, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRunCopyJobFrequency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	tests := map[string]struct {
+		frequency string
+		wants     time.Duration
+	}{
+		"daily": {
+			frequency: models.JobFrequencyDaily,
+			wants:     24 * time.Hour,
+		},
+		"weekly": {
+			frequency: models.JobFrequencyWeekly,
+			wants:     7 * 24 * time.Hour,
+		},
+		"monthly": {
+			frequency: models.JobFrequencyMonthly,
+			wants:     30 * 24 * time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cj, err := models.NewCopyJob(&models.Job{
+				BinData: []byte(`{"userID":111, "frequency":"` + test.frequency + `"}`),
+				ID:      1,
+				Type:    models.JobTypeCopyFollowers,
+			})
+			require.NoError(t, err)
+
+			// There is no next page, so the job is rescheduled according to its frequency.
+			conns := &instaproxy.Connections{
+				Users: []instaproxy.User{{ID: 222, Handler: "john_doe"}},
+			}
+
+			db := &mockDBWorker{}
+			db.On("UpdateWorkerJobID", ctx, mock.Anything, mock.Anything).Return(nil)
+			db.On("StoreCopyJobResults", ctx, cj, conns).Return(nil).Once()
+			db.On("InsertJobEvent", ctx, int64(1), mock.Anything, mock.Anything).Return(nil)
+			db.On("ScheduleJob", ctx, int64(1), test.wants).Return(nil).Once()
+
+			ig := &mockInstagramClient{}
+			ig.On("GetFollowers", ctx, int64(111), mock.Anything).Return(conns, nil).Once()
+
+			w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig)
+
+			require.NoError(t, w.RunCopyJob(ctx, cj, time.Now()))
+
+			db.AssertExpectations(t)
+			ig.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRunCopyJobAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	next := "next-cursor"
+
+	tests := map[string]struct {
+		attempts int
+		wants    int
+	}{
+		"one attempt": {
+			attempts: 1,
+			wants:    1,
+		},
+		"default attempts": {
+			attempts: 0,
+			wants:    4,
+		},
+		"many attempts": {
+			attempts: 10,
+			wants:    10,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cj, err := models.NewCopyJob(&models.Job{
+				BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+				ID:      1,
+				Type:    models.JobTypeCopyFollowers,
+			})
+			require.NoError(t, err)
+
+			// There is always a next page, so the job is paused once all the attempts are spent.
+			conns := &instaproxy.Connections{
+				Next:  &next,
+				Users: []instaproxy.User{{ID: 222, Handler: "john_doe"}},
+			}
+
+			db := &mockDBWorker{}
+			db.On("UpdateWorkerJobID", ctx, mock.Anything, mock.Anything).Return(nil)
+			db.On("StoreCopyJobResults", ctx, cj, conns).Return(nil).Times(test.wants)
+			db.On("InsertJobEvent", ctx, int64(1), mock.Anything, mock.Anything).Return(nil)
+			db.On("ScheduleJob", ctx, int64(1), mock.Anything).Return(nil).Once()
+
+			ig := &mockInstagramClient{}
+			ig.On("GetFollowers", ctx, int64(111), mock.Anything).Return(conns, nil).Times(test.wants)
+
+			w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig).
+				WithAttempts(test.attempts).
+				WithPauseBetweenAttempts(0)
+
+			require.NoError(t, w.RunCopyJob(ctx, cj, time.Now()))
+
+			db.AssertExpectations(t)
+			ig.AssertExpectations(t)
+			ig.AssertNumberOfCalls(t, "GetFollowers", test.wants)
+		})
+	}
+}
+
+func TestRunCopyJobFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+	dbErr := errors.New("mock db error") //nolint:err113
+
+	type fields struct {
+		eventErr  error
+		updateErr error
+	}
+
+	type wants struct {
+		dbFailure bool
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"job marked as errored": {
+			fields{eventErr: nil, updateErr: nil},
+			wants{dbFailure: false},
+		},
+		"event not logged": {
+			fields{eventErr: dbErr, updateErr: nil},
+			wants{dbFailure: false},
+		},
+		"job not marked as errored": {
+			fields{eventErr: nil, updateErr: dbErr},
+			wants{dbFailure: true},
+		},
+		"job not marked as errored, event not logged": {
+			fields{eventErr: dbErr, updateErr: dbErr},
+			wants{dbFailure: true},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cj, err := models.NewCopyJob(&models.Job{
+				BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+				ID:      1,
+				Type:    models.JobTypeCopyFollowers,
+			})
+			require.NoError(t, err)
+
+			var conns *instaproxy.Connections
+
+			errored := database.UpdateJobParams{ID: 1, State: models.JobStateError} //nolint:exhaustruct
+
+			db := &mockDBWorker{}
+			db.On("UpdateWorkerJobID", ctx, mock.Anything, mock.Anything).Return(nil)
+			db.On("UpdateJob", ctx, errored).Return(test.fields.updateErr).Once()
+			db.On("InsertJobEvent", ctx, int64(1), errMock.Error(), mock.Anything).Return(test.fields.eventErr).Once()
+
+			ig := &mockInstagramClient{}
+			ig.On("GetFollowers", ctx, int64(111), mock.Anything).Return(conns, errMock).Once()
+
+			w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig)
+
+			err = w.RunCopyJob(ctx, cj, time.Now())
+
+			db.AssertExpectations(t)
+			ig.AssertExpectations(t)
+
+			require.ErrorIs(t, err, errMock)
+
+			if test.wants.dbFailure {
+				assert.ErrorIs(t, err, service.ErrDBFailure)
+				assert.ErrorIs(t, err, dbErr)
+				assert.NotErrorIs(t, err, service.ErrNoRetry)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, service.ErrNoRetry)
+			assert.NotErrorIs(t, err, service.ErrDBFailure)
+		})
+	}
+}
+
+func TestRunCopyJobRateLimited(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.TODO()
+
+	cj, err := models.NewCopyJob(&models.Job{
+		BinData: []byte(`{"userID":111, "frequency":"daily"}`),
+		ID:      1,
+		Type:    models.JobTypeCopyFollowers,
+	})
+	require.NoError(t, err)
+
+	var noConns *instaproxy.Connections
+
+	next := "cursor-2"
+	conns := &instaproxy.Connections{
+		Next:  &next,
+		Users: []instaproxy.User{{ID: 222, Handler: "john_doe"}},
+	}
+
+	// The job is rescheduled rather than marked as errored, and its execution is summarised as paused.
+	db := &mockDBWorker{}
+	db.On("UpdateWorkerJobID", ctx, mock.Anything, mock.Anything).Return(nil)
+	db.On("StoreCopyJobResults", ctx, cj, conns).Return(nil).Once()
+	db.On("InsertJobEvent", ctx, int64(1), mock.Anything, mock.Anything).Return(nil)
+	db.On("ScheduleJob", ctx, int64(1), time.Hour).Return(nil).Once()
+
+	ig := &mockInstagramClient{}
+	ig.On("GetFollowers", ctx, int64(111), mock.Anything).Return(conns, nil).Once()
+	ig.On("GetFollowers", ctx, int64(111), &next).Return(noConns, instaproxy.ErrRateLimited).Once()
+
+	emitter := &recordingEmitter{}
+
+	w := service.NewWorkerService(db, slog.New(slog.NewTextHandler(io.Discard, nil)), ig,
+		service.WithWorkerEventEmitter(emitter)).
+		WithPauseBetweenAttempts(0)
+
+	require.NoError(t, w.RunCopyJob(ctx, cj, time.Now()))
+
+	db.AssertExpectations(t)
+	db.AssertNotCalled(t, "UpdateJob", mock.Anything, mock.Anything)
+	ig.AssertExpectations(t)
+
+	summary := "Sync paused: 1 users across 1 pages in 0s"
+
+	db.AssertCalled(t, "InsertJobEvent", ctx, int64(1), summary, mock.Anything)
+
+	require.Len(t, emitter.events, 2)
+	assert.Equal(t, service.EventJobStarted, emitter.events[0].Type)
+	assert.Equal(t, service.EventJobPaused, emitter.events[1].Type)
+	assert.Equal(t, summary, emitter.events[1].Details)
+}
@@ -0,0 +1,264 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	RedisKeyPrefix = "instaman:picture:" // Prefix of the keys stored in Redis.
+	RedisTimeout   = 2 * time.Second     // Maximum time each Redis command can take.
+)
+
+// PictureCache describes a storage for the pictures served by PicturesRelay.
+type PictureCache interface {
+	Get(key string) ([]byte, string, bool)
+	Set(key, contentType string, data []byte)
+	Delete(key string)
+}
+
+// expirable is implemented by caches whose items' lifespan can be changed.
+type expirable interface {
+	TTL(ttl time.Duration)
+}
+
+// boundable is implemented by caches whose number of items can be capped.
+type boundable interface {
+	MaxEntries(n int)
+}
+
+// flushable is implemented by caches that must be periodically purged of their expired items.
+type flushable interface {
+	Flush() int
+}
+
+// cacheEntry defines how a picture should be stored in the cached.
+type cacheEntry struct {
+	contentType string    // File's content type
+	data        []byte    // File's binary content
+	expiry      time.Time // Entry's expiry date
+}
+
+// MemoryCache is a PictureCache that keeps the pictures in memory.
+// It is safe for concurrent use: reads do not contend for a lock, while writes are serialised to keep track of the
+// items' insertion order.
+type MemoryCache struct {
+	entries    sync.Map     // Cache items map, of type map[string]cacheEntry
+	maxEntries int          // Optional, the cache is unbounded when lower than 1
+	mu         sync.Mutex   // Guards maxEntries and order
+	order      []string     // Keys, from the oldest to the newest inserted
+	ttl        atomic.Int64 // Items' TTL.
+}
+
+// NewMemoryCache returns an empty and unbounded MemoryCache.
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	m := &MemoryCache{
+		entries:    sync.Map{},
+		maxEntries: 0,
+		mu:         sync.Mutex{},
+		order:      nil,
+		ttl:        atomic.Int64{},
+	}
+
+	m.ttl.Store(int64(ttl))
+
+	return m
+}
+
+// Delete removes a picture from the cache.
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, found := m.entries.LoadAndDelete(key); found {
+		m.order = slices.DeleteFunc(m.order, func(k string) bool { return k == key })
+	}
+}
+
+// Flush removes expired items from the cache and returns how many were removed.
+func (m *MemoryCache) Flush() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	before := len(m.order)
+
+	m.order = slices.DeleteFunc(m.order, func(key string) bool {
+		value, _ := m.entries.Load(key)
+		item, _ := value.(cacheEntry)
+
+		if now.Compare(item.expiry) == 1 {
+			m.entries.Delete(key)
+
+			return true
+		}
+
+		return false
+	})
+
+	return before - len(m.order)
+}
+
+// Get retrieves a picture and its content type from the cache.
+func (m *MemoryCache) Get(key string) ([]byte, string, bool) {
+	value, found := m.entries.Load(key)
+	if !found {
+		return nil, "", false
+	}
+
+	item, _ := value.(cacheEntry)
+
+	return item.data, item.contentType, true
+}
+
+// MaxEntries caps the number of pictures in the cache: once it is full, the oldest inserted ones are evicted to make
+// room for the new ones. Values lower than 1 make the cache unbounded.
+func (m *MemoryCache) MaxEntries(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.maxEntries = n
+	m.evict(0)
+}
+
+// Set stores a picture and its content type in the cache.
+// Replacing a picture that is already cached does not change its insertion order.
+func (m *MemoryCache) Set(key, contentType string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, found := m.entries.Swap(key, cacheEntry{
+		contentType: contentType,
+		data:        data,
+		expiry:      time.Now().Add(time.Duration(m.ttl.Load())),
+	})
+
+	if !found {
+		m.evict(1)
+		m.order = append(m.order, key)
+	}
+}
+
+// TTL sets the lifespan of the next cached items.
+func (m *MemoryCache) TTL(ttl time.Duration) {
+	m.ttl.Store(int64(ttl))
+}
+
+// evict removes the oldest inserted items until there is room for n more. It must be called with mu held.
+func (m *MemoryCache) evict(n int) {
+	if m.maxEntries < 1 {
+		return
+	}
+
+	excess := len(m.order) + n - m.maxEntries
+	if excess <= 0 {
+		return
+	}
+
+	for _, key := range m.order[:excess] {
+		m.entries.Delete(key)
+	}
+
+	m.order = slices.Delete(m.order, 0, excess)
+}
+
+// RedisCache is a PictureCache that keeps the pictures in Redis, so they can be shared among several api-server instances.
+// Items are stored as hashes and their expiry is handled by Redis itself.
+type RedisCache struct {
+	client redis.UniversalClient
+	logger *slog.Logger
+	ttl    time.Duration
+}
+
+// NewRedisCache returns a RedisCache that uses the provided client.
+func NewRedisCache(client redis.UniversalClient, ttl time.Duration, logger *slog.Logger) *RedisCache {
+	return &RedisCache{
+		client: client,
+		logger: logger,
+		ttl:    ttl,
+	}
+}
+
+// Delete removes a picture from Redis.
+func (r *RedisCache) Delete(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+
+	if err := r.client.Del(ctx, RedisKeyPrefix+key).Err(); err != nil {
+		r.logger.Warn("could not delete cached picture", "error", err)
+	}
+}
+
+// Get retrieves a picture and its content type from Redis.
+// Any Redis failure is logged and treated as a cache miss.
+func (r *RedisCache) Get(key string) ([]byte, string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+
+	vals, err := r.client.HMGet(ctx, RedisKeyPrefix+key, "contentType", "data").Result()
+
+	switch {
+	case errors.Is(err, redis.Nil):
+		return nil, "", false
+	case err != nil:
+		r.logger.Warn("could not read cached picture", "error", err)
+
+		return nil, "", false
+	}
+
+	ctype, okType := vals[0].(string)
+	data, okData := vals[1].(string)
+
+	if !okType || !okData {
+		return nil, "", false
+	}
+
+	return []byte(data), ctype, true
+}
+
+// Set stores a picture and its content type in Redis.
+func (r *RedisCache) Set(key, contentType string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), RedisTimeout)
+	defer cancel()
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, RedisKeyPrefix+key, "contentType", contentType, "data", data)
+		pipe.Expire(ctx, RedisKeyPrefix+key, r.ttl)
+
+		return nil
+	})
+	if err != nil {
+		r.logger.Warn("could not cache picture", "error", err)
+	}
+}
+
+// TTL sets the lifespan of the next cached items.
+func (r *RedisCache) TTL(ttl time.Duration) {
+	r.ttl = ttl
+}
@@ -0,0 +1,219 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"io"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPictureCaches(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		cache func(*testing.T) webserver.PictureCache
+	}{
+		"MemoryCache": {
+			cache: func(t *testing.T) webserver.PictureCache {
+				t.Helper()
+
+				return webserver.NewMemoryCache(time.Hour)
+			},
+		},
+		"RedisCache": {
+			cache: func(t *testing.T) webserver.PictureCache {
+				t.Helper()
+
+				return redisCache(t)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cache := test.cache(t)
+
+			cache.Set("key-0", "image/png", pic0)
+			cache.Set("key-1", "image/jpeg", pic1)
+
+			data, ctype, found := cache.Get("key-0")
+			assert.True(t, found)
+			assert.Equal(t, pic0, data)
+			assert.Equal(t, "image/png", ctype)
+
+			data, ctype, found = cache.Get("key-1")
+			assert.True(t, found)
+			assert.Equal(t, pic1, data)
+			assert.Equal(t, "image/jpeg", ctype)
+
+			cache.Delete("key-0")
+
+			data, ctype, found = cache.Get("key-0")
+			assert.False(t, found)
+			assert.Empty(t, data)
+			assert.Empty(t, ctype)
+
+			_, _, found = cache.Get("non existent key")
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestMemoryCacheFlush(t *testing.T) {
+	t.Parallel()
+
+	cache := webserver.NewMemoryCache(time.Hour)
+
+	cache.Set("key-0", "image/png", pic0)
+	cache.TTL(0)
+	cache.Set("key-1", "image/png", pic1)
+
+	assert.Equal(t, 1, cache.Flush())
+
+	_, _, found := cache.Get("key-0")
+	assert.True(t, found)
+
+	_, _, found = cache.Get("key-1")
+	assert.False(t, found)
+}
+
+func TestMemoryCacheMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	cache := webserver.NewMemoryCache(time.Hour)
+	cache.MaxEntries(1000)
+
+	for i := range 1001 {
+		cache.Set("key-"+strconv.Itoa(i), "image/png", pic0)
+	}
+
+	_, _, found := cache.Get("key-0")
+	assert.False(t, found)
+
+	_, _, found = cache.Get("key-1")
+	assert.True(t, found)
+
+	_, _, found = cache.Get("key-1000")
+	assert.True(t, found)
+
+	// Replacing a cached item must not evict anything.
+	cache.Set("key-1", "image/jpeg", pic1)
+
+	data, ctype, found := cache.Get("key-1")
+	assert.True(t, found)
+	assert.Equal(t, pic1, data)
+	assert.Equal(t, "image/jpeg", ctype)
+
+	_, _, found = cache.Get("key-2")
+	assert.True(t, found)
+
+	// Deleting an item frees a slot.
+	cache.Delete("key-500")
+	cache.Set("key-1001", "image/png", pic0)
+
+	_, _, found = cache.Get("key-1")
+	assert.True(t, found)
+
+	// Lowering the cap evicts the oldest items straight away.
+	cache.MaxEntries(2)
+
+	_, _, found = cache.Get("key-999")
+	assert.False(t, found)
+
+	_, _, found = cache.Get("key-1000")
+	assert.True(t, found)
+
+	_, _, found = cache.Get("key-1001")
+	assert.True(t, found)
+}
+
+func TestRedisCacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	server := miniredis.RunT(t)
+	cache := webserver.NewRedisCache(
+		redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		time.Minute,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	cache.Set("key-0", "image/png", pic0)
+	assert.Equal(t, time.Minute, server.TTL(webserver.RedisKeyPrefix+"key-0"))
+
+	server.FastForward(time.Minute)
+
+	_, _, found := cache.Get("key-0")
+	assert.False(t, found)
+}
+
+func TestRelayWithPictureCache(t *testing.T) {
+	t.Parallel()
+
+	cache := redisCache(t)
+	relay := webserver.DefaultPicturesRelay(slog.New(slog.NewTextHandler(io.Discard, nil)), webserver.WithPictureCache(cache))
+
+	relay.Cache("key-2", "image/png", pic2)
+
+	data, ctype, found := cache.Get("key-2")
+	assert.True(t, found)
+	assert.Equal(t, pic2, data)
+	assert.Equal(t, "image/png", ctype)
+}
+
+func TestRelayWithMaxEntries(t *testing.T) {
+	t.Parallel()
+
+	relay := webserver.DefaultPicturesRelay(slog.New(slog.NewTextHandler(io.Discard, nil))).WithMaxEntries(2)
+
+	relay.Cache("key-0", "image/png", pic0)
+	relay.Cache("key-1", "image/png", pic1)
+	relay.Cache("key-2", "image/png", pic2)
+
+	_, _, found := relay.Cached("key-0")
+	assert.False(t, found)
+
+	_, _, found = relay.Cached("key-1")
+	assert.True(t, found)
+
+	_, _, found = relay.Cached("key-2")
+	assert.True(t, found)
+}
+
+func redisCache(t *testing.T) *webserver.RedisCache {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+
+	return webserver.NewRedisCache(
+		redis.NewClient(&redis.Options{Addr: server.Addr()}),
+		time.Hour,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
@@ -21,8 +21,13 @@ package webserver_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -117,6 +122,10 @@ func (c *igservice) GetFollowing(_ context.Context, _ service.GetConnectionInput
 	}, nil
 }
 
+func (c *igservice) GetMediaCount(_ context.Context, in service.GetUserByIDInput) (*instaproxy.MediaCountResponse, error) {
+	return &instaproxy.MediaCountResponse{Count: in.UserID * 2}, nil
+}
+
 func (c *igservice) GetUser(_ context.Context, _ service.GetUserInput) (*instaproxy.User, error) {
 	picURL, _ := url.Parse("https://example.com/user.png")
 
@@ -139,9 +148,59 @@ func (c *igservice) GetUserByID(_ context.Context, _ service.GetUserByIDInput) (
 	}, nil
 }
 
+func (c *igservice) Ping(_ context.Context) error {
+	return nil
+}
+
 // jobsvc implements webserver.jobservice.
 type jobsvc struct{}
 
+func (j *jobsvc) ArchiveJob(_ context.Context, params database.ArchiveJobParams) (*models.Job, error) {
+	if params.ID == 404 {
+		return nil, service.ErrNotFound
+	}
+
+	return &models.Job{
+		ID:       params.ID,
+		Checksum: "test:123456",
+		Type:     "jobtype",
+		Label:    "Test label",
+		LastRun:  nil,
+		NextRun:  nil,
+		State:    "paused",
+	}, nil
+}
+
+func (j *jobsvc) DeleteJob(_ context.Context, params database.DeleteJobParams) (*models.Job, error) {
+	if params.ID == 404 {
+		return nil, service.ErrNotFound
+	}
+
+	return &models.Job{
+		ID:       params.ID,
+		Checksum: "test:123456",
+		Type:     "jobtype",
+		Label:    "Test label",
+		LastRun:  nil,
+		NextRun:  nil,
+		State:    "paused",
+	}, nil
+}
+
+func (j *jobsvc) FindArchivedJobs(context.Context, database.FindJobsParams) ([]models.Job, error) {
+	return []models.Job{
+		{
+			ID:       789,
+			Checksum: "test:archived",
+			Type:     "jobtype",
+			Label:    "Archived job",
+			LastRun:  nil,
+			NextRun:  nil,
+			State:    "error",
+		},
+	}, nil
+}
+
 func (j *jobsvc) FindCopyJob(context.Context, database.FindCopyJobParams) (*models.CopyJob, error) {
 	t, err := time.Parse(time.RFC3339, "2025-01-01T12:00:00Z")
 	if err != nil {
@@ -163,7 +222,11 @@ func (j *jobsvc) FindCopyJob(context.Context, database.FindCopyJobParams) (*mode
 	}, nil
 }
 
-func (j *jobsvc) FindJob(context.Context, database.FindJobParams) (*models.Job, error) {
+func (j *jobsvc) FindJob(_ context.Context, params database.FindJobParams) (*models.Job, error) {
+	if params.ID == 404 {
+		return nil, service.ErrNotFound
+	}
+
 	t, err := time.Parse(time.RFC3339, "2026-01-01T12:00:00Z")
 	if err != nil {
 		panic(err)
@@ -180,6 +243,28 @@ func (j *jobsvc) FindJob(context.Context, database.FindJobParams) (*models.Job,
 	}, nil
 }
 
+func (j *jobsvc) FindJobEvents(_ context.Context, jobID int64, _ int) ([]models.JobEvent, error) {
+	t, err := time.Parse(time.RFC3339, "2026-01-01T12:00:00Z")
+	if err != nil {
+		panic(err)
+	}
+
+	return []models.JobEvent{
+		{
+			ID:        2,
+			JobID:     jobID,
+			Message:   "Sync completed",
+			Timestamp: t,
+		},
+		{
+			ID:        1,
+			JobID:     jobID,
+			Message:   "job picked up for execution",
+			Timestamp: t,
+		},
+	}, nil
+}
+
 func (j *jobsvc) FindJobs(context.Context, database.FindJobsParams) ([]models.Job, error) {
 	t, err := time.Parse(time.RFC3339, "2026-01-01T12:00:00Z")
 	if err != nil {
@@ -208,7 +293,30 @@ func (j *jobsvc) FindJobs(context.Context, database.FindJobsParams) ([]models.Jo
 	}, nil
 }
 
-func (j *jobsvc) NewCopyJob(context.Context, database.NewCopyJobParams) (*models.CopyJob, error) {
+func (j *jobsvc) ImportCopyJobResults(
+	_ context.Context,
+	params database.FindCopyJobParams,
+	r io.Reader,
+) (*service.ImportSummary, error) {
+	if params.UserID == 404 {
+		return nil, service.ErrNotFound
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Join(service.ErrImportCSV, err)
+	}
+
+	lines := strings.Count(string(data), "\n")
+
+	return &service.ImportSummary{Errors: 0, Imported: lines}, nil
+}
+
+func (j *jobsvc) NewCopyJob(_ context.Context, params database.NewCopyJobParams) (*models.CopyJob, error) {
+	if params.Label == "" {
+		return nil, database.ErrInvalidLabel
+	}
+
 	t, err := time.Parse(time.RFC3339, "2025-01-01T12:00:00Z")
 	if err != nil {
 		panic(err)
@@ -230,6 +338,91 @@ func (j *jobsvc) NewCopyJob(context.Context, database.NewCopyJobParams) (*models
 	}, nil
 }
 
+func (j *jobsvc) Ping(_ context.Context) error {
+	return nil
+}
+
+func (j *jobsvc) StreamCopyJobResults(_ context.Context, w io.Writer, job *models.CopyJob, page, _ int, _ string) error {
+	t, err := time.Parse(time.RFC3339, "2025-01-01T12:00:00Z")
+	if err != nil {
+		panic(err)
+	}
+
+	job.Results = []models.User{
+		{
+			ID:        int64(page),
+			FirstSeen: t,
+			Handler:   "john_doe",
+			LastSeen:  t,
+		},
+	}
+
+	return json.NewEncoder(w).Encode(job)
+}
+
+func (j *jobsvc) UpdateJob(_ context.Context, params database.UpdateJobParams) (*models.Job, error) {
+	switch {
+	case params.ID == 404:
+		return nil, service.ErrNotFound
+	case params.State == models.JobStateNew:
+		return nil, database.ErrInvalidTransition
+	}
+
+	return &models.Job{
+		ID:       params.ID,
+		Checksum: "test:123456",
+		Type:     "jobtype",
+		Label:    params.Label,
+		LastRun:  nil,
+		NextRun:  nil,
+		State:    params.State,
+	}, nil
+}
+
+func (j *jobsvc) UpdateUserPicture(_ context.Context, params database.UpdateUserPictureParams) error {
+	switch {
+	case params.Direction != "followers" && params.Direction != "following":
+		return errors.Join(service.ErrDBFailure, database.ErrFindCopyJobParams)
+	case params.UserID == 500:
+		return service.ErrDBFailure
+	}
+
+	return nil
+}
+
+// loglevels implements webserver.loglevelsetter.
+type loglevels struct {
+	level slog.Level
+}
+
+func (l *loglevels) SetLogLevel(level slog.Level) {
+	l.level = level
+}
+
+// webhookstore implements webserver.webhookstore.
+type webhookstore struct {
+	webhooks []models.Webhook
+}
+
+func (s *webhookstore) FindWebhooks(context.Context, string) ([]models.Webhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *webhookstore) NewWebhook(_ context.Context, p database.NewWebhookParams) (*models.Webhook, error) {
+	t, err := time.Parse(time.RFC3339, "2025-01-01T12:00:00Z")
+	if err != nil {
+		panic(err)
+	}
+
+	return &models.Webhook{
+		ID:        1,
+		CreatedAt: t,
+		Events:    p.Events,
+		Secret:    p.Secret,
+		URL:       p.URL,
+	}, nil
+}
+
 func fixture(t *testing.T, path string) []byte {
 	t.Helper()
 
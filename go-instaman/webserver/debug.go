@@ -0,0 +1,57 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+var ErrLogLevel = errors.New("log level must be one of debug, info, warn, error")
+
+// loglevelsetter describes a service whose log level can be changed at runtime.
+type loglevelsetter interface {
+	SetLogLevel(level slog.Level)
+}
+
+// LogLevelConfig defines the input data to change the log level.
+type LogLevelConfig struct {
+	Level string `json:"level"`
+}
+
+// HandleSetLogLevel returns an http.Handler that changes the log level of the provided services.
+func HandleSetLogLevel(logger *slog.Logger, setters ...loglevelsetter) http.Handler {
+	return HandleWithInput(logger, func(_ context.Context, cfg LogLevelConfig) (*LogLevelConfig, error) {
+		var level slog.Level
+
+		if cfg.Level == "" || level.UnmarshalText([]byte(cfg.Level)) != nil {
+			return nil, ErrLogLevel
+		}
+
+		for _, s := range setters {
+			s.SetLogLevel(level)
+		}
+
+		return &LogLevelConfig{Level: strings.ToLower(level.String())}, nil
+	})
+}
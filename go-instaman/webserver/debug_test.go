@@ -0,0 +1,98 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleSetLogLevel(t *testing.T) {
+	t.Parallel()
+
+	type wants struct {
+		body   string
+		level  slog.Level
+		status int
+	}
+
+	tests := map[string]struct {
+		body string
+		wants
+	}{
+		"debug": {
+			body: `{"level":"debug"}`,
+			wants: wants{
+				body:   `{"level":"debug"}` + "\n",
+				level:  slog.LevelDebug,
+				status: http.StatusOK,
+			},
+		},
+		"uppercase": {
+			body: `{"level":"WARN"}`,
+			wants: wants{
+				body:   `{"level":"warn"}` + "\n",
+				level:  slog.LevelWarn,
+				status: http.StatusOK,
+			},
+		},
+		"empty": {
+			body: `{}`,
+			wants: wants{
+				body:   `{"error":"log level must be one of debug, info, warn, error"}` + "\n",
+				level:  slog.LevelInfo,
+				status: http.StatusBadRequest,
+			},
+		},
+		"invalid": {
+			body: `{"level":"verbose"}`,
+			wants: wants{
+				body:   `{"error":"log level must be one of debug, info, warn, error"}` + "\n",
+				level:  slog.LevelInfo,
+				status: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			setter := &loglevels{level: slog.LevelInfo}
+			handler := webserver.HandleSetLogLevel(slog.New(slog.NewTextHandler(io.Discard, nil)), setter)
+
+			req := httptest.NewRequest(http.MethodPost, "/instaman/debug/log-level", strings.NewReader(test.body))
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wants.status, rec.Code)
+			assert.Equal(t, test.wants.body, rec.Body.String())
+			assert.Equal(t, test.wants.level, setter.level)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/service"
+)
+
+// errorStatus maps a sentinel error to the HTTP status code of the responses that carry it.
+type errorStatus struct {
+	err    error
+	status int
+}
+
+// errorRegistry holds the errors known to writeResponse, in registration order.
+// It is seeded with the sentinel errors of this package and of the packages it depends on, which can't register their
+// own errors as they can't import this package.
+//
+//nolint:gochecknoglobals // Guarded by errorRegistryMu
+var errorRegistry = []errorStatus{
+	{ErrInvalidUserID, http.StatusBadRequest},
+	{ErrInvalidUserName, http.StatusBadRequest},
+	{ErrLogLevel, http.StatusBadRequest},
+	{ErrPictureURL, http.StatusBadRequest},
+	{ErrWebhookEvents, http.StatusBadRequest},
+	{ErrWebhookSecret, http.StatusBadRequest},
+	{ErrWebhookURL, http.StatusBadRequest},
+	{database.ErrFindCopyJobParams, http.StatusBadRequest},
+	{database.ErrFindJobParams, http.StatusBadRequest},
+	{database.ErrInvalidChecksum, http.StatusBadRequest},
+	{database.ErrInvalidID, http.StatusBadRequest},
+	{database.ErrInvalidLabel, http.StatusBadRequest},
+	{database.ErrInvalidState, http.StatusBadRequest},
+	{database.ErrInvalidType, http.StatusBadRequest},
+	{database.ErrInvalidWebhook, http.StatusBadRequest},
+	{database.ErrInvalidTransition, http.StatusConflict},
+	{database.ErrJobNotFound, http.StatusNotFound},
+	{instaproxy.ErrInvalidStatus, http.StatusBadGateway},
+	{instaproxy.ErrNotFound, http.StatusNotFound},
+	{service.ErrInvalidNextRun, http.StatusBadRequest},
+	{service.ErrNotFound, http.StatusNotFound},
+}
+
+var errorRegistryMu sync.RWMutex //nolint:gochecknoglobals // Guards errorRegistry
+
+// RegisterErrorStatus makes the handlers respond with httpStatus to any error that matches err, according to errors.Is.
+// The errors of the packages this one depends on are registered by default. Packages that build on top of it, and so
+// can import it, are meant to register their own sentinel errors at init time, e.g.:
+//
+//	func init() {
+//		webserver.RegisterErrorStatus(ErrSomething, http.StatusConflict)
+//	}
+//
+// Registering the same error again replaces its status code. When more errors match, the first registered wins.
+// Errors that were not registered are served as 500 Internal Server Error.
+func RegisterErrorStatus(err error, httpStatus int) {
+	errorRegistryMu.Lock()
+	defer errorRegistryMu.Unlock()
+
+	for i := range errorRegistry {
+		if errorRegistry[i].err == err { //nolint:errorlint // Sentinels are compared by identity.
+			errorRegistry[i].status = httpStatus
+
+			return
+		}
+	}
+
+	errorRegistry = append(errorRegistry, errorStatus{err: err, status: httpStatus})
+}
+
+// statusFromError returns the HTTP status code registered for err, or 500 if there is none.
+func statusFromError(err error) int {
+	errorRegistryMu.RLock()
+	defer errorRegistryMu.RUnlock()
+
+	for _, e := range errorRegistry {
+		if errors.Is(err, e.err) {
+			return e.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
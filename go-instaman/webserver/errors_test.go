@@ -0,0 +1,127 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/service"
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errConflict   = errors.New("mock conflict")   //nolint:err113
+	errOverridden = errors.New("mock overridden") //nolint:err113
+	errUnknown    = errors.New("mock unknown")    //nolint:err113
+)
+
+func TestRegisterErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	webserver.RegisterErrorStatus(errConflict, http.StatusConflict)
+	webserver.RegisterErrorStatus(errOverridden, http.StatusConflict)
+	webserver.RegisterErrorStatus(errOverridden, http.StatusTeapot)
+
+	type wants struct {
+		body   string
+		status int
+	}
+
+	tests := map[string]struct {
+		err error
+		wants
+	}{
+		"registered error": {
+			err: errConflict,
+			wants: wants{
+				body:   `{"error":"mock conflict"}` + "\n",
+				status: http.StatusConflict,
+			},
+		},
+		"wrapped registered error": {
+			err: fmt.Errorf("wrapped: %w", errConflict),
+			wants: wants{
+				body:   `{"error":"wrapped: mock conflict"}` + "\n",
+				status: http.StatusConflict,
+			},
+		},
+		"overridden registration": {
+			err: errOverridden,
+			wants: wants{
+				body:   `{"error":"mock overridden"}` + "\n",
+				status: http.StatusTeapot,
+			},
+		},
+		"unregistered error": {
+			err: errUnknown,
+			wants: wants{
+				body:   `{"error":"mock unknown"}` + "\n",
+				status: http.StatusInternalServerError,
+			},
+		},
+		"default registration": {
+			err: service.ErrNotFound,
+			wants: wants{
+				body:   `{"error":"job not found"}` + "\n",
+				status: http.StatusNotFound,
+			},
+		},
+		"default registration, wrapped by the service layer": {
+			err: errors.Join(service.ErrDBFailure, database.ErrInvalidID),
+			wants: wants{
+				body:   `{"error":"db error\ninvalid ID"}` + "\n",
+				status: http.StatusBadRequest,
+			},
+		},
+		"upstream failure has no body": {
+			err: errors.Join(instaproxy.ErrInvalidStatus, errUnknown),
+			wants: wants{
+				body:   "",
+				status: http.StatusBadGateway,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := webserver.Handle(slog.New(slog.NewTextHandler(io.Discard, nil)), func(context.Context) (any, error) {
+				return nil, test.err
+			})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, test.wants.status, w.Code)
+			assert.Equal(t, test.wants.body, w.Body.String())
+		})
+	}
+}
@@ -25,11 +25,18 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/luca-arch/instaman/instaproxy"
 	"github.com/luca-arch/instaman/internal"
 )
 
+const (
+	ContentTypeNDJSON = "application/x-ndjson" // Newline delimited JSON.
+	NextCursorHeader  = "X-Next-Cursor"        // Carries the pagination cursor of NDJSON responses.
+)
+
 type errResponse struct {
 	Error string `json:"error"`
 }
@@ -75,7 +82,7 @@ func HandleWithInput[In any, Out any](logger *slog.Logger, f TargetFuncWithInput
 		}
 
 		if err != nil {
-			writeErrResponse(w, err, http.StatusBadRequest)
+			writeErrResponse(w, err, decodeErrStatus(err))
 
 			return
 		}
@@ -83,7 +90,21 @@ func HandleWithInput[In any, Out any](logger *slog.Logger, f TargetFuncWithInput
 		// Call out to target function.
 		out, err := f(r.Context(), in)
 
-		// Serve response.
+		// Serve response, streaming lists of users if the client asked for it.
+		if err == nil && acceptsNDJSON(r) {
+			if conns, ok := any(out).(*instaproxy.Connections); ok && conns != nil {
+				if conns.Next != nil {
+					w.Header().Set(NextCursorHeader, *conns.Next)
+				}
+
+				if err := writeStreamResponse(w, conns.Users); err != nil {
+					logger.Warn("failed to stream HTTP response", "error", err)
+				}
+
+				return
+			}
+		}
+
 		writeResponse(w, logger, out, err)
 	})
 }
@@ -104,24 +125,168 @@ func HandleWithRequest[Out any](logger *slog.Logger, f TargetFuncWithRequest[Out
 	})
 }
 
+// FetchFunc retrieves the current value of the resource identified by id.
+type FetchFunc[In any] func(context.Context, int64) (In, error)
+
+// HandleWithPatch takes a FetchFunc and a TargetFuncWithInput and uses them to create an HTTP handler with
+// JSON merge patch semantics (RFC 7396): the resource identified by the `{id}` path value is fetched, the request's
+// body is merged onto it, and the merged value is passed to the target function.
+// Fields absent from the body are left untouched, fields set to `null` are reset to their zero value.
+func HandleWithPatch[In any, Out any](logger *slog.Logger, fetchFn FetchFunc[In], f TargetFuncWithInput[In, Out]) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var patch map[string]any
+
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			writeErrResponse(w, errors.New("invalid number for field: id"), http.StatusBadRequest) //nolint:err113
+
+			return
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			writeErrResponse(w, err, decodeErrStatus(err))
+
+			return
+		}
+
+		current, err := fetchFn(r.Context(), id)
+		if err != nil {
+			writeResponse[any](w, logger, nil, err)
+
+			return
+		}
+
+		in, err := mergePatch(current, patch)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		// Call out to target function.
+		out, err := f(r.Context(), in)
+
+		// Serve response.
+		writeResponse(w, logger, out, err)
+	})
+}
+
+// mergePatch applies a JSON merge patch onto the JSON representation of target, and returns the merged value.
+func mergePatch[T any](target T, patch map[string]any) (T, error) {
+	var (
+		doc map[string]any
+		out T
+	)
+
+	data, err := json.Marshal(target)
+	if err != nil {
+		return out, err //nolint:wrapcheck // Pass-through
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return out, err //nolint:wrapcheck // Pass-through
+	}
+
+	if data, err = json.Marshal(mergeObjects(doc, patch)); err != nil {
+		return out, err //nolint:wrapcheck // Pass-through
+	}
+
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, err //nolint:wrapcheck // Pass-through
+	}
+
+	return out, nil
+}
+
+// mergeObjects recursively merges patch into doc, as described by RFC 7396.
+func mergeObjects(doc, patch map[string]any) map[string]any {
+	if doc == nil {
+		doc = make(map[string]any, len(patch))
+	}
+
+	for k, v := range patch {
+		switch pv := v.(type) {
+		case nil:
+			delete(doc, k)
+		case map[string]any:
+			dv, _ := doc[k].(map[string]any)
+			doc[k] = mergeObjects(dv, pv)
+		default:
+			doc[k] = v
+		}
+	}
+
+	return doc
+}
+
+// decodeErrStatus returns the HTTP status code matching an error that occurred while reading the request.
+func decodeErrStatus(err error) int {
+	var maxErr *http.MaxBytesError
+
+	if errors.As(err, &maxErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+
+	return http.StatusBadRequest
+}
+
+// acceptsNDJSON returns whether the client asked for a newline delimited JSON response.
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			mediaType, _, _ = strings.Cut(mediaType, ";")
+
+			if strings.TrimSpace(mediaType) == ContentTypeNDJSON {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// writeStreamResponse writes each item as a JSON object on its own line, flushing them as they are encoded so that
+// clients can parse the stream incrementally.
+func writeStreamResponse[T any](w http.ResponseWriter, items []T) error {
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err //nolint:wrapcheck // Pass-through
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	return nil
+}
+
 // writeResponse is an helper that writes JSON-encoded data into the ResponseWriter.
+// Errors are served with the status code set by RegisterErrorStatus.
 func writeResponse[T any](w http.ResponseWriter, logger *slog.Logger, out T, err error) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var wErr error
 
-	switch {
-	case err == nil:
+	if err == nil {
 		w.WriteHeader(http.StatusOK)
 		wErr = json.NewEncoder(w).Encode(out)
-	case errors.Is(err, instaproxy.ErrInvalidStatus):
-		w.WriteHeader(http.StatusBadGateway)
-	case errors.Is(err, instaproxy.ErrNotFound):
-		w.WriteHeader(http.StatusNotFound)
-		wErr = json.NewEncoder(w).Encode(errResponse{Error: err.Error()})
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
-		wErr = json.NewEncoder(w).Encode(errResponse{Error: err.Error()})
+	} else {
+		status := statusFromError(err)
+		w.WriteHeader(status)
+
+		// Upstream failures are not detailed to the client.
+		if status != http.StatusBadGateway {
+			wErr = json.NewEncoder(w).Encode(errResponse{Error: err.Error()})
+		}
 	}
 
 	if wErr != nil {
@@ -0,0 +1,170 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/instaman/service"
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+type patchable struct {
+	ID    int64          `json:"id"`
+	Label string         `json:"label"`
+	State string         `json:"state"`
+	Meta  map[string]any `json:"meta"`
+}
+
+func TestHandleWithPatch(t *testing.T) {
+	t.Parallel()
+
+	fetch := func(_ context.Context, id int64) (patchable, error) {
+		if id == 404 {
+			return patchable{}, service.ErrNotFound
+		}
+
+		return patchable{
+			ID:    id,
+			Label: "old label",
+			State: "new",
+			Meta:  map[string]any{"frequency": "daily", "userID": 123},
+		}, nil
+	}
+
+	update := func(_ context.Context, in patchable) (patchable, error) {
+		if in.State == "fail" {
+			return in, errors.New("mock error") //nolint:err113
+		}
+
+		return in, nil
+	}
+
+	mux := &http.ServeMux{}
+	mux.Handle("PATCH /items/{id}", webserver.HandleWithPatch(slog.New(slog.NewTextHandler(io.Discard, nil)), fetch, update))
+
+	type args struct {
+		body string
+		path string
+	}
+
+	type wants struct {
+		body   string
+		status int
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"partial update - ok": {
+			args{
+				body: `{"label":"new label"}`,
+				path: "/items/1",
+			},
+			wants{
+				body:   `{"id":1,"label":"new label","state":"new","meta":{"frequency":"daily","userID":123}}` + "\n",
+				status: http.StatusOK,
+			},
+		},
+		"nested update - ok": {
+			args{
+				body: `{"meta":{"frequency":"weekly"}}`,
+				path: "/items/2",
+			},
+			wants{
+				body:   `{"id":2,"label":"old label","state":"new","meta":{"frequency":"weekly","userID":123}}` + "\n",
+				status: http.StatusOK,
+			},
+		},
+		"null removes - ok": {
+			args{
+				body: `{"label":null,"meta":{"userID":null}}`,
+				path: "/items/3",
+			},
+			wants{
+				body:   `{"id":3,"label":"","state":"new","meta":{"frequency":"daily"}}` + "\n",
+				status: http.StatusOK,
+			},
+		},
+		"invalid id": {
+			args{
+				body: `{}`,
+				path: "/items/abc",
+			},
+			wants{
+				body:   `{"error":"invalid number for field: id"}` + "\n",
+				status: http.StatusBadRequest,
+			},
+		},
+		"invalid body": {
+			args{
+				body: `[1, 2]`,
+				path: "/items/1",
+			},
+			wants{
+				body:   `{"error":"json: cannot unmarshal array into Go value of type map[string]interface {}"}` + "\n",
+				status: http.StatusBadRequest,
+			},
+		},
+		"not found": {
+			args{
+				body: `{}`,
+				path: "/items/404",
+			},
+			wants{
+				body:   `{"error":"job not found"}` + "\n",
+				status: http.StatusNotFound,
+			},
+		},
+		"target error": {
+			args{
+				body: `{"state":"fail"}`,
+				path: "/items/1",
+			},
+			wants{
+				body:   `{"error":"mock error"}` + "\n",
+				status: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPatch, test.args.path, strings.NewReader(test.args.body))
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wants.status, rec.Code)
+			assert.Equal(t, test.wants.body, rec.Body.String())
+		})
+	}
+}
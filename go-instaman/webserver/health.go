@@ -0,0 +1,75 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+const (
+	HealthStatusOK          = "ok"          // All the dependencies can be reached.
+	HealthStatusUnavailable = "unavailable" // At least one dependency can't be reached.
+)
+
+// pinger is implemented by the services whose dependencies can be checked.
+type pinger interface {
+	Ping(context.Context) error
+}
+
+// HealthResponse is the body of the health check responses.
+type HealthResponse struct {
+	Failing []string `json:"failing,omitempty"` // The dependencies that can't be reached.
+	Status  string   `json:"status"`
+}
+
+// HandleHealth creates the HTTP handler that checks that both the database and instaproxy can be reached.
+// It responds with HTTP 200 if they both can, or with HTTP 503 and the names of the failing ones otherwise.
+func HandleHealth(logger *slog.Logger, db pinger, instaproxy pinger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out := HealthResponse{Failing: nil, Status: HealthStatusOK}
+		status := http.StatusOK
+
+		for _, dep := range []struct {
+			name string
+			p    pinger
+		}{
+			{"database", db},
+			{"instaproxy", instaproxy},
+		} {
+			if err := dep.p.Ping(r.Context()); err != nil {
+				logger.Warn("health check failed", "dependency", dep.name, "error", err)
+
+				out.Failing = append(out.Failing, dep.name)
+				out.Status = HealthStatusUnavailable
+				status = http.StatusServiceUnavailable
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			logger.Warn("failed to serve HTTP response", "error", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubPinger is a dependency whose Ping returns err.
+type stubPinger struct {
+	err error
+}
+
+func (s *stubPinger) Ping(_ context.Context) error {
+	return s.err
+}
+
+func TestHandleHealth(t *testing.T) {
+	t.Parallel()
+
+	errUnreachable := errors.New("unreachable")
+
+	type fields struct {
+		db         *stubPinger
+		instaproxy *stubPinger
+	}
+
+	type wants struct {
+		body   string
+		status int
+	}
+
+	tests := map[string]struct {
+		fields
+		wants
+	}{
+		"all up": {
+			fields{
+				db:         &stubPinger{err: nil},
+				instaproxy: &stubPinger{err: nil},
+			},
+			wants{
+				body:   `{"status":"ok"}` + "\n",
+				status: http.StatusOK,
+			},
+		},
+		"database down": {
+			fields{
+				db:         &stubPinger{err: errUnreachable},
+				instaproxy: &stubPinger{err: nil},
+			},
+			wants{
+				body:   `{"failing":["database"],"status":"unavailable"}` + "\n",
+				status: http.StatusServiceUnavailable,
+			},
+		},
+		"instaproxy down": {
+			fields{
+				db:         &stubPinger{err: nil},
+				instaproxy: &stubPinger{err: errUnreachable},
+			},
+			wants{
+				body:   `{"failing":["instaproxy"],"status":"unavailable"}` + "\n",
+				status: http.StatusServiceUnavailable,
+			},
+		},
+		"all down": {
+			fields{
+				db:         &stubPinger{err: errUnreachable},
+				instaproxy: &stubPinger{err: errUnreachable},
+			},
+			wants{
+				body:   `{"failing":["database","instaproxy"],"status":"unavailable"}` + "\n",
+				status: http.StatusServiceUnavailable,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := webserver.HandleHealth(slog.New(slog.NewTextHandler(io.Discard, nil)), test.fields.db, test.fields.instaproxy)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			assert.Equal(t, test.wants.status, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, test.wants.body, w.Body.String())
+		})
+	}
+}
+
+func TestHealthRoute(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		webserver.WithMiddleware(webserver.WithAPIKey("secret")))
+	assert.NoError(t, err)
+
+	// The health check needs no API key.
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"status":"ok"}`+"\n", w.Body.String())
+
+	// Built-in middlewares still apply.
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+
+	// Other routes still do.
+	w = httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
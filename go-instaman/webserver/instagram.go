@@ -22,6 +22,8 @@ package webserver
 import (
 	"context"
 	"errors"
+	"net/http"
+	"strconv"
 
 	"github.com/luca-arch/instaman/instaproxy"
 	"github.com/luca-arch/instaman/service"
@@ -40,6 +42,106 @@ type igservice interface {
 	GetAccount(context.Context) (*instaproxy.Account, error)
 	GetFollowers(context.Context, service.GetConnectionInput) (*instaproxy.Connections, error)
 	GetFollowing(context.Context, service.GetConnectionInput) (*instaproxy.Connections, error)
+	GetMediaCount(context.Context, service.GetUserByIDInput) (*instaproxy.MediaCountResponse, error)
 	GetUser(context.Context, service.GetUserInput) (*instaproxy.User, error)
 	GetUserByID(context.Context, service.GetUserByIDInput) (*instaproxy.User, error)
+	Ping(context.Context) error
+}
+
+// InstagramClient exposes an igservice's methods as TargetFuncWithRequest, reading their input from the HTTP request,
+// so that they can be mounted with HandleWithRequest on a router other than the one set up by Create.
+type InstagramClient struct {
+	svc igservice
+}
+
+// WrapInstagramClient returns an InstagramClient that forwards the requests to svc, e.g. a *service.Instagram.
+func WrapInstagramClient(svc igservice) *InstagramClient {
+	return &InstagramClient{svc: svc}
+}
+
+// GetAccount returns the information of the account instaproxy is logged in with.
+func (c *InstagramClient) GetAccount(r *http.Request) (*instaproxy.Account, error) {
+	return c.svc.GetAccount(r.Context()) //nolint:wrapcheck // Wraps invocation
+}
+
+// GetFollowers returns the first page of followers of the user whose ID is the `{id}` path value.
+// It returns ErrInvalidUserID if the ID is not a positive integer.
+func (c *InstagramClient) GetFollowers(r *http.Request) (*instaproxy.Connections, error) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.svc.GetFollowers(r.Context(), service.GetConnectionInput{UserID: userID}) //nolint:exhaustruct,wrapcheck
+}
+
+// GetFollowersWithCursor is like GetFollowers, but returns the page pointed by the optional `next_cursor` query
+// parameter, which is the cursor returned along with the previous page.
+func (c *InstagramClient) GetFollowersWithCursor(r *http.Request) (*instaproxy.Connections, error) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	in := service.GetConnectionInput{UserID: userID} //nolint:exhaustruct
+
+	if cursor := r.URL.Query().Get("next_cursor"); cursor != "" {
+		in.Cursor = &cursor
+	}
+
+	return c.svc.GetFollowers(r.Context(), in) //nolint:wrapcheck // Wraps invocation
+}
+
+// GetFollowing returns the first page of users followed by the user whose ID is the `{id}` path value.
+// It returns ErrInvalidUserID if the ID is not a positive integer.
+func (c *InstagramClient) GetFollowing(r *http.Request) (*instaproxy.Connections, error) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.svc.GetFollowing(r.Context(), service.GetConnectionInput{UserID: userID}) //nolint:exhaustruct,wrapcheck
+}
+
+// GetMediaCount returns the number of posts of the user whose ID is the `{id}` path value.
+// It returns ErrInvalidUserID if the ID is not a positive integer.
+func (c *InstagramClient) GetMediaCount(r *http.Request) (*instaproxy.MediaCountResponse, error) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.svc.GetMediaCount(r.Context(), service.GetUserByIDInput{UserID: userID}) //nolint:wrapcheck
+}
+
+// GetUser returns the information of the user whose handler is the `{name}` path value.
+// It returns ErrInvalidUserName if the handler is empty.
+func (c *InstagramClient) GetUser(r *http.Request) (*instaproxy.User, error) {
+	name := r.PathValue("name")
+	if name == "" {
+		return nil, ErrInvalidUserName
+	}
+
+	return c.svc.GetUser(r.Context(), service.GetUserInput{Handler: name}) //nolint:wrapcheck // Wraps invocation
+}
+
+// GetUserByID returns the information of the user whose ID is the `{id}` path value.
+// It returns ErrInvalidUserID if the ID is not a positive integer.
+func (c *InstagramClient) GetUserByID(r *http.Request) (*instaproxy.User, error) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.svc.GetUserByID(r.Context(), service.GetUserByIDInput{UserID: userID}) //nolint:wrapcheck
+}
+
+// userIDFromPath parses the `{id}` path value of r, returning ErrInvalidUserID if it is not a positive integer.
+func userIDFromPath(r *http.Request) (int64, error) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || userID < 1 {
+		return 0, ErrInvalidUserID
+	}
+
+	return userID, nil
 }
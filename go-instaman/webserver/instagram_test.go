@@ -0,0 +1,235 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/instaman/instaproxy"
+	"github.com/luca-arch/instaman/service"
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+// cursorIG is an igservice that records the input of its GetFollowers calls.
+type cursorIG struct {
+	igservice
+
+	in *service.GetConnectionInput
+}
+
+func (c *cursorIG) GetFollowers(ctx context.Context, in service.GetConnectionInput) (*instaproxy.Connections, error) {
+	c.in = &in
+
+	return c.igservice.GetFollowers(ctx, in)
+}
+
+func TestMethods(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	type args struct {
+		handler func(*webserver.InstagramClient) http.Handler
+		pattern string
+		url     string
+	}
+
+	type wants struct {
+		body     string
+		followed *service.GetConnectionInput // Input of GetFollowers, nil when not called.
+		status   int
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"GetAccount": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetAccount)
+				},
+				pattern: "GET /me",
+				url:     "/me",
+			},
+			wants{
+				body:   `"handler":"john_doe"`,
+				status: http.StatusOK,
+			},
+		},
+		"GetFollowers": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetFollowers)
+				},
+				pattern: "GET /followers/{id}",
+				url:     "/followers/123?next_cursor=ignored",
+			},
+			wants{
+				body:     `"next":"next-cursor-001"`,
+				followed: &service.GetConnectionInput{Cursor: nil, UserID: 123},
+				status:   http.StatusOK,
+			},
+		},
+		"GetFollowers - invalid ID": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetFollowers)
+				},
+				pattern: "GET /followers/{id}",
+				url:     "/followers/abc",
+			},
+			wants{
+				body:   `{"error":"invalid user ID"}`,
+				status: http.StatusBadRequest,
+			},
+		},
+		"GetFollowersWithCursor": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetFollowersWithCursor)
+				},
+				pattern: "GET /followers/{id}",
+				url:     "/followers/123?next_cursor=cursor-001",
+			},
+			wants{
+				body:     `"next":"next-cursor-001"`,
+				followed: &service.GetConnectionInput{Cursor: strPtr("cursor-001"), UserID: 123},
+				status:   http.StatusOK,
+			},
+		},
+		"GetFollowersWithCursor - first page": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetFollowersWithCursor)
+				},
+				pattern: "GET /followers/{id}",
+				url:     "/followers/123",
+			},
+			wants{
+				body:     `"next":"next-cursor-001"`,
+				followed: &service.GetConnectionInput{Cursor: nil, UserID: 123},
+				status:   http.StatusOK,
+			},
+		},
+		"GetFollowersWithCursor - invalid ID": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetFollowersWithCursor)
+				},
+				pattern: "GET /followers/{id}",
+				url:     "/followers/0?next_cursor=cursor-001",
+			},
+			wants{
+				body:   `{"error":"invalid user ID"}`,
+				status: http.StatusBadRequest,
+			},
+		},
+		"GetFollowing": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetFollowing)
+				},
+				pattern: "GET /following/{id}",
+				url:     "/following/123",
+			},
+			wants{
+				body:   `"next":"next-cursor-002"`,
+				status: http.StatusOK,
+			},
+		},
+		"GetMediaCount": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetMediaCount)
+				},
+				pattern: "GET /media-count/{id}",
+				url:     "/media-count/21",
+			},
+			wants{
+				body:   `"count":42`,
+				status: http.StatusOK,
+			},
+		},
+		"GetUser": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetUser)
+				},
+				pattern: "GET /account/{name}",
+				url:     "/account/user_name",
+			},
+			wants{
+				body:   `"handler":"user_name"`,
+				status: http.StatusOK,
+			},
+		},
+		"GetUserByID": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetUserByID)
+				},
+				pattern: "GET /account-id/{id}",
+				url:     "/account-id/456",
+			},
+			wants{
+				body:   `"id":456`,
+				status: http.StatusOK,
+			},
+		},
+		"GetUserByID - invalid ID": {
+			args{
+				handler: func(c *webserver.InstagramClient) http.Handler {
+					return webserver.HandleWithRequest(logger, c.GetUserByID)
+				},
+				pattern: "GET /account-id/{id}",
+				url:     "/account-id/-1",
+			},
+			wants{
+				body:   `{"error":"invalid user ID"}`,
+				status: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc := &cursorIG{} //nolint:exhaustruct
+
+			mux := http.NewServeMux()
+			mux.Handle(test.args.pattern, test.args.handler(webserver.WrapInstagramClient(svc)))
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.args.url, nil))
+
+			assert.Equal(t, test.wants.status, w.Code)
+			assert.Contains(t, w.Body.String(), test.wants.body)
+			assert.Equal(t, test.wants.followed, svc.in)
+		})
+	}
+}
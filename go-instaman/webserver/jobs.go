@@ -22,15 +22,205 @@ package webserver
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
 
 	"github.com/luca-arch/instaman/database"
 	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/internal"
+	"github.com/luca-arch/instaman/service"
 )
 
+const MaxImportSize = 5 << 20 // The maximum size of the CSV files uploaded to the import endpoint (5 MB).
+
 // jobservice describes a service that can access and manipulate jobs.
 type jobservice interface {
+	ArchiveJob(context.Context, database.ArchiveJobParams) (*models.Job, error)
+	DeleteJob(context.Context, database.DeleteJobParams) (*models.Job, error)
+	FindArchivedJobs(context.Context, database.FindJobsParams) ([]models.Job, error)
 	FindCopyJob(context.Context, database.FindCopyJobParams) (*models.CopyJob, error)
 	FindJob(context.Context, database.FindJobParams) (*models.Job, error)
+	FindJobEvents(ctx context.Context, jobID int64, page int) ([]models.JobEvent, error)
 	FindJobs(context.Context, database.FindJobsParams) ([]models.Job, error)
+	ImportCopyJobResults(context.Context, database.FindCopyJobParams, io.Reader) (*service.ImportSummary, error)
 	NewCopyJob(context.Context, database.NewCopyJobParams) (*models.CopyJob, error)
+	Ping(context.Context) error
+	StreamCopyJobResults(context.Context, io.Writer, *models.CopyJob, int, int, string) error
+	UpdateJob(context.Context, database.UpdateJobParams) (*models.Job, error)
+	UpdateUserPicture(context.Context, database.UpdateUserPictureParams) error
+}
+
+// copyJobResponse is a CopyJob with the number of pages its results are split into.
+type copyJobResponse struct {
+	*models.CopyJob
+
+	TotalPages int `json:"totalPages"`
+}
+
+// HandleFindCopyJob creates the HTTP handler that serves a CopyJob.
+// When a page is requested, its results are streamed straight from the database instead of being buffered in memory.
+func HandleFindCopyJob(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.FindCopyJobParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		page, limit := in.WithPage, database.CopyResultsLimit(in.Limit)
+		in.WithPage = nil
+
+		job, err := svc.FindCopyJob(r.Context(), in)
+		if err != nil {
+			writeResponse[any](w, logger, nil, err)
+
+			return
+		}
+
+		if page == nil || *page < 0 {
+			writeResponse(w, logger, copyJobResponse{CopyJob: job, TotalPages: job.TotalPages(limit)}, nil)
+
+			return
+		}
+
+		sw := &streamWriter{ResponseWriter: w, written: false}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		err = svc.StreamCopyJobResults(r.Context(), sw, job, *page, limit, in.ResultsOrder)
+
+		switch {
+		case err == nil:
+		case !sw.written:
+			writeResponse[any](w, logger, nil, err)
+		default:
+			logger.Warn("failed to stream HTTP response", "error", err, slog.Any("job", job))
+		}
+	})
+}
+
+// HandleImportCopyJob creates the HTTP handler that seeds the results of a copy job with the users listed in a CSV file.
+// The file is uploaded as the `file` field of a multipart form, while the job is identified by the query parameters.
+func HandleImportCopyJob(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.FindCopyJobParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeErrResponse(w, err, decodeErrStatus(err))
+
+			return
+		}
+
+		defer file.Close()
+
+		summary, err := svc.ImportCopyJobResults(r.Context(), in, file)
+		if errors.Is(err, service.ErrImportCSV) {
+			writeErrResponse(w, err, decodeErrStatus(err))
+
+			return
+		}
+
+		writeResponse(w, logger, summary, err)
+	})
+}
+
+// HandleArchiveJob creates the HTTP handler that archives the job identified by the request path, and serves it.
+func HandleArchiveJob(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.ArchiveJobParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		job, err := svc.ArchiveJob(r.Context(), in)
+
+		writeResponse(w, logger, job, err)
+	})
+}
+
+// HandleDeleteJob creates the HTTP handler that deletes the job identified by the request path, and serves it.
+func HandleDeleteJob(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.DeleteJobParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		job, err := svc.DeleteJob(r.Context(), in)
+
+		writeResponse(w, logger, job, err)
+	})
+}
+
+// HandleFindJobEvents creates the HTTP handler that serves a page of a job's audit logs.
+func HandleFindJobEvents(logger *slog.Logger, svc jobservice) http.Handler {
+	return HandleWithInput(logger, func(ctx context.Context, in database.FindJobEventsParams) ([]models.JobEvent, error) {
+		return svc.FindJobEvents(ctx, in.JobID, in.Page)
+	})
+}
+
+// HandleUpdateJob creates the HTTP handler that updates the job identified by the request path, and serves it.
+// The request body holds the fields to update: empty and invalid values are ignored, and its `id` is overridden by the
+// path's.
+func HandleUpdateJob(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.UpdateJobParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		id := in.ID
+
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeErrResponse(w, err, decodeErrStatus(err))
+
+			return
+		}
+
+		in.ID = id
+
+		job, err := svc.UpdateJob(r.Context(), in)
+
+		writeResponse(w, logger, job, err)
+	})
+}
+
+// streamWriter is an http.ResponseWriter that keeps track of whether the response body has been started.
+type streamWriter struct {
+	http.ResponseWriter
+
+	written bool
+}
+
+// Write satisfies io.Writer interface.
+func (s *streamWriter) Write(data []byte) (int, error) {
+	s.written = true
+
+	return s.ResponseWriter.Write(data) //nolint:wrapcheck // Pass-through
 }
@@ -0,0 +1,257 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCopyJob(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testServer := httptest.NewServer(server.Handler)
+
+	t.Cleanup(testServer.Close)
+	t.Cleanup(cancel)
+
+	// multipartBody returns a multipart form with file uploaded as its `file` field, along with its content type.
+	multipartBody := func(t *testing.T, field string, file []byte) (*bytes.Buffer, string) {
+		t.Helper()
+
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+
+		fw, err := mw.CreateFormFile(field, "followers.csv")
+		require.NoError(t, err)
+
+		_, err = fw.Write(file)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+
+		return body, mw.FormDataContentType()
+	}
+
+	type args struct {
+		field string
+		file  []byte
+		query string
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"ok": {
+			args{
+				field: "file",
+				file:  fixture(t, "testdata/jobs-copy-import.csv"),
+				query: "?direction=followers&userID=123",
+			},
+			wants{
+				body:   []byte(`{"errors":0,"imported":4}` + "\n"),
+				status: http.StatusOK,
+			},
+		},
+		"job not found": {
+			args{
+				field: "file",
+				file:  fixture(t, "testdata/jobs-copy-import.csv"),
+				query: "?direction=followers&userID=404",
+			},
+			wants{
+				body:   expectedErr(t, "job not found"),
+				status: http.StatusNotFound,
+			},
+		},
+		"missing file": {
+			args{
+				field: "upload",
+				file:  fixture(t, "testdata/jobs-copy-import.csv"),
+				query: "?direction=followers&userID=123",
+			},
+			wants{
+				body:   expectedErr(t, "http: no such file"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"missing direction": {
+			args{
+				field: "file",
+				file:  fixture(t, "testdata/jobs-copy-import.csv"),
+				query: "?userID=123",
+			},
+			wants{
+				body:   expectedErr(t, "missing required field: direction"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"file too large": {
+			args{
+				field: "file",
+				file:  bytes.Repeat([]byte("11,johndoe,John Doe\n"), webserver.MaxImportSize/10),
+				query: "?direction=followers&userID=123",
+			},
+			wants{
+				body:   expectedErr(t, "request body too large"),
+				status: http.StatusRequestEntityTooLarge,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			body, contentType := multipartBody(t, test.args.field, test.args.file)
+
+			//nolint:noctx,bodyclose // Ok when testing
+			res, err := http.Post(testServer.URL+"/instaman/jobs/copy/import"+test.args.query, contentType, body)
+			require.NoError(t, err)
+
+			out, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			res.Body.Close()
+
+			assert.Equal(t, test.wants.status, res.StatusCode)
+			assert.Equal(t, test.wants.body, out, "Expected: "+string(test.wants.body)+"\nActual: "+string(out))
+		})
+	}
+}
+
+func TestUpdateJob(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testServer := httptest.NewServer(server.Handler)
+
+	t.Cleanup(testServer.Close)
+	t.Cleanup(cancel)
+
+	type args struct {
+		body     string
+		endpoint string
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"ok": {
+			args{
+				body:     `{"frequency":"weekly","label":"New label","state":"pause"}`,
+				endpoint: "/instaman/jobs/123",
+			},
+			wants{
+				body: []byte(`{"metadata":null,"id":123,"checksum":"test:123456","type":"jobtype","label":"New label",` +
+					`"lastRun":null,"nextRun":null,"state":"pause"}` + "\n"),
+				status: http.StatusOK,
+			},
+		},
+		"body id is ignored - ok": {
+			args{
+				body:     `{"id":456,"label":"New label","state":"active"}`,
+				endpoint: "/instaman/jobs/123",
+			},
+			wants{
+				body: []byte(`{"metadata":null,"id":123,"checksum":"test:123456","type":"jobtype","label":"New label",` +
+					`"lastRun":null,"nextRun":null,"state":"active"}` + "\n"),
+				status: http.StatusOK,
+			},
+		},
+		"not found": {
+			args{
+				body:     `{"label":"New label"}`,
+				endpoint: "/instaman/jobs/404",
+			},
+			wants{
+				body:   expectedErr(t, "job not found"),
+				status: http.StatusNotFound,
+			},
+		},
+		"invalid transition": {
+			args{
+				body:     `{"state":"new"}`,
+				endpoint: "/instaman/jobs/123",
+			},
+			wants{
+				body:   expectedErr(t, "invalid transition"),
+				status: http.StatusConflict,
+			},
+		},
+		"invalid id": {
+			args{
+				body:     `{"label":"New label"}`,
+				endpoint: "/instaman/jobs/abc",
+			},
+			wants{
+				body:   expectedErr(t, "invalid number for field: id"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"invalid body": {
+			args{
+				body:     `{"label":`,
+				endpoint: "/instaman/jobs/123",
+			},
+			wants{
+				body:   expectedErr(t, "unexpected EOF"),
+				status: http.StatusBadRequest,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPatch, testServer.URL+test.args.endpoint, strings.NewReader(test.args.body))
+			require.NoError(t, err)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			res.Body.Close()
+
+			assert.Equal(t, test.wants.status, res.StatusCode)
+			assert.Equal(t, test.wants.body, body, "Expected: "+string(test.wants.body)+"\nActual: "+string(body))
+		})
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	CORSAllowedMethods = "GET, POST, PATCH, DELETE" // Methods that cross-origin requests may use.
+	CORSMaxAge         = "600"                      // How many seconds browsers may cache a preflight response for.
+	DefaultMaxBodySize = 1 << 20                    // The default maximum size of request bodies (1 MB).
+	corsAnyOrigin      = "*"                        // Allows requests from any origin.
+)
+
+var (
+	ErrBodyTooLarge = errors.New("request body too large")
+	ErrUnauthorized = errors.New("missing or invalid API key")
+)
+
+// WithAPIKey rejects with HTTP 401 the requests whose X-Api-Key header is missing or does not match key.
+// The middleware is a no-op when key is empty.
+func WithAPIKey(key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if key == "" {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Api-Key")), []byte(key)) != 1 {
+				writeErrResponse(w, ErrUnauthorized, http.StatusUnauthorized)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// WithCORS allows cross-origin requests from the listed origins, or from any origin if the list contains "*".
+// Preflight requests from an allowed origin are answered straight away with HTTP 204, while requests from any other
+// origin are served without CORS headers, so that browsers block them.
+// The middleware is a no-op when origins is empty.
+func WithCORS(origins []string) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(origins, corsAnyOrigin)
+
+	return func(next http.Handler) http.Handler {
+		if len(origins) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			if origin == "" || (!anyOrigin && !slices.Contains(origins, origin)) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Expose-Headers", "X-Response-Time")
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			h.Set("Access-Control-Allow-Headers", "Content-Type, X-Api-Key")
+			h.Set("Access-Control-Allow-Methods", CORSAllowedMethods)
+			h.Set("Access-Control-Max-Age", CORSMaxAge)
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// MaxBodySizeMiddleware limits the size of request bodies to limit bytes.
+// Requests that declare a larger Content-Length are rejected straight away with HTTP 413, the others have their body
+// wrapped with http.MaxBytesReader so that handlers fail to read past the limit.
+func MaxBodySizeMiddleware(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				writeErrResponse(w, ErrBodyTooLarge, http.StatusRequestEntityTooLarge)
+
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SecurityHeadersMiddleware sets the security-related HTTP headers on every response.
+func SecurityHeadersMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", "default-src 'none'")
+		h.Set("Referrer-Policy", "no-referrer")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// TimingMiddleware measures how long requests take to be served.
+// The elapsed time is sent in the X-Response-Time header, which is set right before the response headers are written,
+// and the total duration is logged once the handler returns.
+func TimingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tw := &timingWriter{ResponseWriter: w, start: time.Now(), wroteHeader: false}
+
+			next.ServeHTTP(tw, r)
+
+			logger.DebugContext(r.Context(), "Request served",
+				"http.method", r.Method,
+				"http.url", r.URL.String(),
+				"http.request.duration_ms", elapsedMillis(tw.start),
+			)
+		})
+	}
+}
+
+// timingWriter wraps an http.ResponseWriter to set the X-Response-Time header before the headers are sent.
+type timingWriter struct {
+	http.ResponseWriter
+
+	start       time.Time
+	wroteHeader bool
+}
+
+// Flush satisfies http.Flusher interface, so that streamed responses keep working.
+func (t *timingWriter) Flush() {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := t.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter, for http.ResponseController.
+func (t *timingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+// Write satisfies io.Writer interface.
+func (t *timingWriter) Write(data []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+
+	return t.ResponseWriter.Write(data) //nolint:wrapcheck // Pass-through
+}
+
+// WriteHeader sets the X-Response-Time header and sends the response headers.
+func (t *timingWriter) WriteHeader(statusCode int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		t.Header().Set("X-Response-Time", strconv.FormatFloat(elapsedMillis(t.start), 'f', 3, 64)+"ms")
+	}
+
+	t.ResponseWriter.WriteHeader(statusCode)
+}
+
+// elapsedMillis returns the milliseconds elapsed since start.
+func elapsedMillis(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
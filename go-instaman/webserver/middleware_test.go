@@ -0,0 +1,374 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAPIKey(t *testing.T) {
+	t.Parallel()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	type wants struct {
+		body   []byte
+		status int
+	}
+
+	tests := map[string]struct {
+		header *string
+		key    string
+		wants
+	}{
+		"no key": {
+			header: nil,
+			key:    "",
+			wants: wants{
+				body:   nil,
+				status: http.StatusOK,
+			},
+		},
+		"missing header": {
+			header: nil,
+			key:    "secret",
+			wants: wants{
+				body:   expectedErr(t, "missing or invalid API key"),
+				status: http.StatusUnauthorized,
+			},
+		},
+		"wrong key": {
+			header: strPtr("not-the-secret"),
+			key:    "secret",
+			wants: wants{
+				body:   expectedErr(t, "missing or invalid API key"),
+				status: http.StatusUnauthorized,
+			},
+		},
+		"correct key": {
+			header: strPtr("secret"),
+			key:    "secret",
+			wants: wants{
+				body:   nil,
+				status: http.StatusOK,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil)
+			if test.header != nil {
+				r.Header.Set("X-Api-Key", *test.header)
+			}
+
+			w := httptest.NewRecorder()
+			webserver.WithAPIKey(test.key)(ok).ServeHTTP(w, r)
+
+			assert.Equal(t, test.wants.status, w.Code)
+			assert.Equal(t, test.wants.body, w.Body.Bytes())
+		})
+	}
+}
+
+func TestWithCORS(t *testing.T) {
+	t.Parallel()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	type args struct {
+		method    string
+		origin    string
+		origins   []string
+		preflight bool
+	}
+
+	type wants struct {
+		allowMethods string
+		allowOrigin  string
+		status       int
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"same origin request": {
+			args{
+				method:  http.MethodGet,
+				origin:  "",
+				origins: []string{"https://dashboard.example.com"},
+			},
+			wants{
+				allowOrigin: "",
+				status:      http.StatusOK,
+			},
+		},
+		"allowed origin": {
+			args{
+				method:  http.MethodGet,
+				origin:  "https://dashboard.example.com",
+				origins: []string{"http://localhost:3000", "https://dashboard.example.com"},
+			},
+			wants{
+				allowOrigin: "https://dashboard.example.com",
+				status:      http.StatusOK,
+			},
+		},
+		"disallowed origin": {
+			args{
+				method:  http.MethodGet,
+				origin:  "https://evil.example.com",
+				origins: []string{"https://dashboard.example.com"},
+			},
+			wants{
+				allowOrigin: "",
+				status:      http.StatusOK,
+			},
+		},
+		"any origin": {
+			args{
+				method:  http.MethodPost,
+				origin:  "https://evil.example.com",
+				origins: []string{"*"},
+			},
+			wants{
+				allowOrigin: "https://evil.example.com",
+				status:      http.StatusOK,
+			},
+		},
+		"preflight from allowed origin": {
+			args{
+				method:    http.MethodOptions,
+				origin:    "https://dashboard.example.com",
+				origins:   []string{"https://dashboard.example.com"},
+				preflight: true,
+			},
+			wants{
+				allowMethods: webserver.CORSAllowedMethods,
+				allowOrigin:  "https://dashboard.example.com",
+				status:       http.StatusNoContent,
+			},
+		},
+		"preflight from disallowed origin": {
+			args{
+				method:    http.MethodOptions,
+				origin:    "https://evil.example.com",
+				origins:   []string{"https://dashboard.example.com"},
+				preflight: true,
+			},
+			wants{
+				allowMethods: "",
+				allowOrigin:  "",
+				status:       http.StatusOK,
+			},
+		},
+		"options without preflight headers": {
+			args{
+				method:  http.MethodOptions,
+				origin:  "https://dashboard.example.com",
+				origins: []string{"https://dashboard.example.com"},
+			},
+			wants{
+				allowMethods: "",
+				allowOrigin:  "https://dashboard.example.com",
+				status:       http.StatusOK,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(test.args.method, "/instaman/jobs/all", nil)
+			if test.args.origin != "" {
+				r.Header.Set("Origin", test.args.origin)
+			}
+
+			if test.args.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+			}
+
+			w := httptest.NewRecorder()
+			webserver.WithCORS(test.args.origins)(ok).ServeHTTP(w, r)
+
+			assert.Equal(t, test.wants.status, w.Code)
+			assert.Equal(t, test.wants.allowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, test.wants.allowMethods, w.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, "Origin", w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestWithCORSNoOrigins(t *testing.T) {
+	t.Parallel()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	r := httptest.NewRequest(http.MethodOptions, "/instaman/jobs/all", nil)
+	r.Header.Set("Origin", "https://dashboard.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+
+	w := httptest.NewRecorder()
+	webserver.WithCORS(nil)(ok).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header())
+}
+
+func TestMaxBodySizeMiddleware(t *testing.T) {
+	t.Parallel()
+
+	echo := func(_ context.Context, in map[string]string) (map[string]string, error) {
+		return in, nil
+	}
+
+	handler := webserver.MaxBodySizeMiddleware(16)(
+		webserver.HandleWithInput(slog.New(slog.NewTextHandler(io.Discard, nil)), echo),
+	)
+
+	type args struct {
+		body    string
+		chunked bool
+	}
+
+	type wants struct {
+		body   string
+		status int
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"below the limit": {
+			args{
+				body: `{"a":"b"}`,
+			},
+			wants{
+				body:   `{"a":"b"}` + "\n",
+				status: http.StatusOK,
+			},
+		},
+		"at the limit": {
+			args{
+				body: `{"abc":"defghi"}`,
+			},
+			wants{
+				body:   `{"abc":"defghi"}` + "\n",
+				status: http.StatusOK,
+			},
+		},
+		"above the limit": {
+			args{
+				body: `{"abc":"defghij"}`,
+			},
+			wants{
+				body:   `{"error":"request body too large"}` + "\n",
+				status: http.StatusRequestEntityTooLarge,
+			},
+		},
+		"above the limit, unknown length": {
+			args{
+				body:    `{"abc":"defghij"}`,
+				chunked: true,
+			},
+			wants{
+				body:   `{"error":"http: request body too large"}` + "\n",
+				status: http.StatusRequestEntityTooLarge,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.args.body))
+			if test.args.chunked {
+				req.ContentLength = -1
+			}
+
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.wants.status, rec.Code)
+			assert.Equal(t, test.wants.body, rec.Body.String())
+		})
+	}
+}
+
+func TestTimingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]http.HandlerFunc{
+		"explicit status": func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(time.Millisecond)
+			w.WriteHeader(http.StatusNoContent)
+		},
+		"implicit status": func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(time.Millisecond)
+			_, _ = w.Write([]byte("ok"))
+		},
+		"flushed": func(w http.ResponseWriter, _ *http.Request) {
+			time.Sleep(time.Millisecond)
+			w.(http.Flusher).Flush()
+		},
+	}
+
+	for name, next := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := webserver.TimingMiddleware(slog.New(slog.NewTextHandler(io.Discard, nil)))(next)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			header := rec.Result().Header.Get("X-Response-Time") //nolint:bodyclose // Recorder
+			assert.True(t, strings.HasSuffix(header, "ms"), header)
+
+			ms, err := strconv.ParseFloat(strings.TrimSuffix(header, "ms"), 64)
+			assert.NoError(t, err)
+			assert.Positive(t, ms)
+		})
+	}
+}
@@ -21,21 +21,32 @@ package webserver
 
 import (
 	"context"
+	"crypto/md5" //nolint:gosec // Only used to derive file names
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/luca-arch/instaman/internal"
 )
 
 const (
+	DefaultCacheSize    = 1000                                                                       // Maximum number of pictures kept in memory.
 	DefaultCacheTTL     = time.Hour                                                                  // Cached items' expiry.
 	FlushFrequency      = 5 * time.Minute                                                            // How often the cache should be checked for stale items.
 	InstagramCDNDomain  = ".cdninstagram.com"                                                        // Default domain whence Instagram pictures are served.
 	InstagramCDNTimeout = 10 * time.Second                                                           // Maximum time Instagram CDN can take to serve a picture.
 	UserAgent           = "YahooMailProxy; https://help.yahoo.com/kb/yahoo-mail-proxy-SLN28749.html" // User-Agent header to use when downloading from Instagram
+
+	diskCacheDirPerm = 0o750 // Permissions of the disk cache directory, when it's created.
 )
 
 // httpDoer defines an interface to make HTTP requests.
@@ -43,39 +54,68 @@ type httpDoer interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
-// cacheEntry defines how a picture should be stored in the cached.
-type cacheEntry struct {
-	contentType string    // File's content type
-	data        []byte    // File's binary content
-	expiry      time.Time // Entry's expiry date
-}
-
 // PicturesRelay is an helper that acts as a proxy for Instagram CDN, working around their CORS restrictions.
 type PicturesRelay struct {
-	cache    map[string]cacheEntry // Cache items map
-	httpDoer httpDoer              // HTTP client
-	lock     sync.Mutex            // Lock for flush() method
-	logger   *slog.Logger          // Logger
-	ttl      time.Duration         // Items' TTL.
+	cache    PictureCache // Cache storage
+	diskDir  string       // Optional, pictures are not persisted on disk when empty
+	diskTTL  atomic.Int64 // Lifespan of the pictures persisted on disk
+	httpDoer httpDoer     // HTTP client
+	logger   *slog.Logger // Logger
 }
 
-// Cache stores a picture and its content type in the cache.
+// diskEntry is the sidecar file that describes a picture persisted on disk.
+type diskEntry struct {
+	ContentType string    `json:"contentType"`
+	Expiry      time.Time `json:"expiry"`
+	URL         string    `json:"url"`
+}
+
+// RelayOption configures optional PicturesRelay settings.
+type RelayOption func(*PicturesRelay)
+
+// WithPictureCache replaces the default in-memory cache.
+func WithPictureCache(cache PictureCache) RelayOption {
+	return func(p *PicturesRelay) {
+		p.cache = cache
+	}
+}
+
+// Cache stores a picture and its content type in the cache, and on disk when the disk cache is enabled.
 func (p *PicturesRelay) Cache(url, contentType string, picture []byte) {
-	p.cache[url] = cacheEntry{
-		contentType: contentType,
-		data:        picture,
-		expiry:      time.Now().Add(p.ttl),
+	p.cache.Set(url, contentType, picture)
+
+	if p.diskDir == "" {
+		return
+	}
+
+	if err := p.writeDisk(url, contentType, picture); err != nil {
+		p.logger.Warn("could not persist cached picture", "error", err)
 	}
 }
 
 // Cached retrieves a picture and its content type from the cache.
+// When the disk cache is enabled, pictures that are not in memory are looked up on disk and, if found, cached again.
 func (p *PicturesRelay) Cached(url string) ([]byte, string, bool) {
-	item, found := p.cache[url]
-	if !found {
+	if data, ctype, found := p.cache.Get(url); found || p.diskDir == "" {
+		return data, ctype, found
+	}
+
+	entry, data, err := p.readDisk(p.diskPath(url))
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, "", false
+	case err != nil:
+		p.logger.Warn("could not read persisted picture", "error", err)
+
+		return nil, "", false
+	case entry.URL != url, time.Now().After(entry.Expiry):
 		return nil, "", false
 	}
 
-	return item.data, item.contentType, true
+	p.cache.Set(url, entry.ContentType, data)
+
+	return data, entry.ContentType, true
 }
 
 // Client overrides the defautl HTTP client that will be downloading files from Instagram.
@@ -85,6 +125,47 @@ func (p *PicturesRelay) Client(client httpDoer) *PicturesRelay {
 	return p
 }
 
+// LoadDiskCache pre-warms the cache with the pictures persisted on disk that have not expired yet, and returns how
+// many were loaded. Expired pictures are removed from disk.
+// It does nothing if the disk cache is not enabled.
+func (p *PicturesRelay) LoadDiskCache(ctx context.Context) (int, error) {
+	if p.diskDir == "" {
+		return 0, nil
+	}
+
+	sidecars, err := filepath.Glob(filepath.Join(p.diskDir, "*.json"))
+	if err != nil {
+		return 0, err //nolint:wrapcheck // Only returned for malformed patterns
+	}
+
+	loaded := 0
+
+	for _, sidecar := range sidecars {
+		if err := ctx.Err(); err != nil {
+			return loaded, err //nolint:wrapcheck // Context errors are not wrapped
+		}
+
+		base := strings.TrimSuffix(sidecar, ".json")
+
+		entry, data, err := p.readDisk(base)
+
+		switch {
+		case err != nil:
+			p.logger.Warn("could not load persisted picture", "error", err, "file", sidecar)
+		case time.Now().After(entry.Expiry):
+			removeDisk(base)
+		default:
+			p.cache.Set(entry.URL, entry.ContentType, data)
+
+			loaded++
+		}
+	}
+
+	p.logger.Debug("loaded persisted pictures", "count", loaded, "dir", p.diskDir)
+
+	return loaded, nil
+}
+
 // ServeHTTP implements the HandlerFunc interface.
 // It reads the picture's URL from the GET querystring (key: pictureURL) and then performs a lookup into its cache.
 // If the picture is cached, it will be downloaded from Instagram, stored in the cache, and served to the client as is.
@@ -163,53 +244,163 @@ func (p *PicturesRelay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // TTL sets the lifespan of the next cached items.
+// It has no effect if the underlying cache does not support it, except for the pictures persisted on disk.
 func (p *PicturesRelay) TTL(ttl time.Duration) {
-	p.ttl = ttl
+	p.diskTTL.Store(int64(ttl))
+
+	if c, ok := p.cache.(expirable); ok {
+		c.TTL(ttl)
+	}
+}
+
+// WithMaxEntries caps the number of pictures in the cache, evicting the oldest ones when it is full.
+// It has no effect if the underlying cache does not support it.
+func (p *PicturesRelay) WithMaxEntries(n int) *PicturesRelay {
+	if c, ok := p.cache.(boundable); ok {
+		c.MaxEntries(n)
+	}
+
+	return p
 }
 
 // Watch starts a go routine that watches the cache and removes any expire entry.
 // The goroutine will automatically terminate when the context is cancelled.
+// It does nothing if the underlying cache handles expiry by itself.
 func (p *PicturesRelay) Watch(ctx context.Context, freq time.Duration) {
+	cache, ok := p.cache.(flushable)
+	if !ok {
+		return
+	}
+
 	go func() {
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-time.After(freq):
-				p.flush()
+				p.flush(cache)
 			}
 		}
 	}()
 }
 
-// flush removes expired items from the cache.
-func (p *PicturesRelay) flush() {
-	p.logger.Debug("start flushing")
+// WithDiskCache persists the cached pictures into dir, so that they survive server restarts.
+// Each picture is stored as `<md5(url)>.bin`, next to a `<md5(url)>.json` file that holds its content type and expiry.
+func (p *PicturesRelay) WithDiskCache(dir string) *PicturesRelay {
+	p.diskDir = dir
 
-	start := time.Now()
-	flushed := 0
+	return p
+}
 
-	p.lock.Lock()
-	defer p.lock.Unlock()
+// diskPath returns the path, without extension, of the files where a picture is persisted.
+func (p *PicturesRelay) diskPath(url string) string {
+	sum := md5.Sum([]byte(url)) //nolint:gosec // Only used to derive file names
 
-	for pictureURL, item := range p.cache {
-		if start.Compare(item.expiry) == 1 {
-			delete(p.cache, pictureURL)
+	return filepath.Join(p.diskDir, hex.EncodeToString(sum[:]))
+}
 
-			flushed++
-		}
+// readDisk reads a picture and its sidecar file from disk.
+func (p *PicturesRelay) readDisk(base string) (*diskEntry, []byte, error) {
+	meta, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // Callers check for os.ErrNotExist
+	}
+
+	var entry diskEntry
+
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return nil, nil, err //nolint:wrapcheck // Logged as is
 	}
 
+	data, err := os.ReadFile(base + ".bin")
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // Callers check for os.ErrNotExist
+	}
+
+	return &entry, data, nil
+}
+
+// writeDisk persists a picture and its sidecar file on disk.
+// The sidecar is written last, so that a picture is never read before it is complete.
+func (p *PicturesRelay) writeDisk(url, contentType string, picture []byte) error {
+	meta, err := json.Marshal(diskEntry{
+		ContentType: contentType,
+		Expiry:      time.Now().Add(time.Duration(p.diskTTL.Load())),
+		URL:         url,
+	})
+	if err != nil {
+		return err //nolint:wrapcheck // Logged as is
+	}
+
+	if err := os.MkdirAll(p.diskDir, diskCacheDirPerm); err != nil {
+		return err //nolint:wrapcheck // Logged as is
+	}
+
+	base := p.diskPath(url)
+
+	if err := writeFileAtomic(base+".bin", picture); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(base+".json", meta)
+}
+
+// removeDisk deletes a persisted picture and its sidecar file.
+func removeDisk(base string) {
+	_ = os.Remove(base + ".json")
+	_ = os.Remove(base + ".bin")
+}
+
+// writeFileAtomic writes data into a temporary file and then renames it, so that readers never see a partial file.
+func writeFileAtomic(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err //nolint:wrapcheck // Logged as is
+	}
+
+	defer os.Remove(tmp.Name()) //nolint:errcheck // Fails when already renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err //nolint:wrapcheck // Logged as is
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err //nolint:wrapcheck // Logged as is
+	}
+
+	return os.Rename(tmp.Name(), name) //nolint:wrapcheck // Logged as is
+}
+
+// flush removes expired items from the cache.
+func (p *PicturesRelay) flush(cache flushable) {
+	p.logger.Debug("start flushing")
+
+	start := time.Now()
+	flushed := cache.Flush()
+
 	p.logger.Debug("done flushing", "count", flushed, "time.ms", time.Since(start).Milliseconds())
 }
 
 // DefaultPicturesRelay returns a PicturesRelay with default configuration.
-func DefaultPicturesRelay(logger *slog.Logger) *PicturesRelay {
-	return &PicturesRelay{
-		cache:    make(map[string]cacheEntry, 0),
-		httpDoer: &http.Client{Timeout: InstagramCDNTimeout}, //nolint:exhaustruct // defaults are ok
-		lock:     sync.Mutex{},
+func DefaultPicturesRelay(logger *slog.Logger, opts ...RelayOption) *PicturesRelay {
+	cache := NewMemoryCache(DefaultCacheTTL)
+	cache.MaxEntries(DefaultCacheSize)
+
+	p := &PicturesRelay{
+		cache:    cache,
+		diskDir:  "",
+		diskTTL:  atomic.Int64{},
+		httpDoer: internal.NewHTTPClient(InstagramCDNTimeout, internal.DefaultMaxIdleConns),
 		logger:   logger,
-		ttl:      DefaultCacheTTL,
 	}
+
+	p.diskTTL.Store(int64(DefaultCacheTTL))
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
@@ -29,11 +29,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/luca-arch/instaman/webserver"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -92,6 +96,150 @@ func TestCache(t *testing.T) {
 	assert.Empty(t, cachedContentType)
 }
 
+// TestRacePicturesRelay is only meaningful when run with `go test -race`.
+func TestDiskCache(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	data := []byte("binary data")
+	key := "https://scontent.cdninstagram.com/picture.jpg"
+
+	t.Run("cached across restarts", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).Cache(key, "image/jpeg", data)
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		// Memory miss, disk hit.
+		cachedData, cachedContentType, found := webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).Cached(key)
+
+		assert.True(t, found)
+		assert.Equal(t, data, cachedData)
+		assert.Equal(t, "image/jpeg", cachedContentType)
+
+		_, _, found = webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).Cached("non existent key")
+		assert.False(t, found)
+	})
+
+	t.Run("pre-warmed from disk", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).Cache(key, "image/jpeg", data)
+
+		relay := webserver.DefaultPicturesRelay(logger).WithDiskCache(dir)
+
+		loaded, err := relay.LoadDiskCache(context.TODO())
+		require.NoError(t, err)
+		assert.Equal(t, 1, loaded)
+
+		// Served from memory even after the files are gone.
+		require.NoError(t, os.RemoveAll(dir))
+
+		cachedData, cachedContentType, found := relay.Cached(key)
+
+		assert.True(t, found)
+		assert.Equal(t, data, cachedData)
+		assert.Equal(t, "image/jpeg", cachedContentType)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		relay := webserver.DefaultPicturesRelay(logger).WithDiskCache(dir)
+		relay.TTL(-time.Second)
+		relay.Cache(key, "image/jpeg", data)
+
+		_, _, found := webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).Cached(key)
+		assert.False(t, found)
+
+		loaded, err := webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).LoadDiskCache(context.TODO())
+		require.NoError(t, err)
+		assert.Zero(t, loaded)
+
+		files, err := filepath.Glob(filepath.Join(dir, "*"))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		loaded, err := webserver.DefaultPicturesRelay(logger).LoadDiskCache(context.TODO())
+		require.NoError(t, err)
+		assert.Zero(t, loaded)
+	})
+}
+
+func TestCreateWithRelay(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	pictureURL := "https://example" + webserver.InstagramCDNDomain + "/pic0.png"
+
+	// A picture persisted by a previous server is served without downloading it again.
+	webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).Cache(pictureURL, "image/png", pic0)
+
+	relay := webserver.DefaultPicturesRelay(logger).WithDiskCache(dir).
+		Client(&mockHTTPDoer{body: "", err: errors.New("not cached"), status: 0}) //nolint:err113
+
+	server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, relay, logger)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/instaman/instagram/picture?pictureURL="+url.QueryEscape(pictureURL), nil)
+	rec := httptest.NewRecorder()
+
+	server.Handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, pic0, rec.Body.Bytes())
+}
+
+func TestRacePicturesRelay(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	relay := picturesRelay(t, &mockHTTPDoer{body: "downloaded binary content", status: http.StatusOK})
+	relay.TTL(0)
+	relay.Watch(ctx, time.Millisecond)
+
+	wg := sync.WaitGroup{}
+
+	for i := range 50 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			pictureURL := fmt.Sprintf("https://example%s/pic-%d.png", webserver.InstagramCDNDomain, i%5)
+			req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/instaman/instagram/picture?pictureURL="+url.QueryEscape(pictureURL), nil)
+			rr := httptest.NewRecorder()
+
+			relay.ServeHTTP(rr, req)
+			relay.Cached(pictureURL)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}()
+	}
+
+	wg.Wait()
+}
+
 func TestServeHTTP(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,92 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/internal"
+)
+
+const UIRefreshInterval = 30 * time.Second // How often the management UI reloads itself.
+
+//go:embed templates/jobs.html
+var templatesFS embed.FS
+
+var jobsTemplate = template.Must(template.ParseFS(templatesFS, "templates/jobs.html")) //nolint:gochecknoglobals // Read-only
+
+// uiJobsPage is the data rendered by the jobs template.
+type uiJobsPage struct {
+	Jobs           []models.Job
+	RefreshSeconds int
+	UpdatedAt      time.Time
+}
+
+// HandleUI creates the HTTP handler that serves a read-only HTML page listing the jobs.
+// The query args are the same as the `GET /instaman/jobs/all` endpoint's.
+func HandleUI(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.FindJobsParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		jobs, err := svc.FindJobs(r.Context(), in)
+		if err != nil {
+			writeResponse[any](w, logger, nil, err)
+
+			return
+		}
+
+		// Render into a buffer so that template errors can still be served as such.
+		var buf bytes.Buffer
+
+		err = jobsTemplate.Execute(&buf, uiJobsPage{
+			Jobs:           jobs,
+			RefreshSeconds: int(UIRefreshInterval.Seconds()),
+			UpdatedAt:      time.Now().UTC(),
+		})
+		if err != nil {
+			writeResponse[any](w, logger, nil, err)
+
+			return
+		}
+
+		// The page only needs its own inline stylesheet.
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := buf.WriteTo(w); err != nil {
+			logger.Warn("failed to serve HTTP response", "error", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleUI(t *testing.T) {
+	t.Parallel()
+
+	handler := webserver.HandleUI(slog.New(slog.NewTextHandler(io.Discard, nil)), &jobsvc{})
+
+	tests := map[string]struct {
+		url          string
+		expectStatus int
+		expectType   string
+		expectBody   []string
+	}{
+		"ok": {
+			url:          "/instaman/ui",
+			expectStatus: http.StatusOK,
+			expectType:   "text/html; charset=utf-8",
+			expectBody: []string{
+				`<meta http-equiv="refresh" content="30">`,
+				"<td>Test label</td>",
+				"<td>Test job</td>",
+				`<td class="state-paused">paused</td>`,
+				"<td>2026-01-01 12:00:00</td>",
+			},
+		},
+		"error, invalid page": {
+			url:          "/instaman/ui?page=abc",
+			expectStatus: http.StatusBadRequest,
+			expectType:   "application/json",
+			expectBody:   []string{"invalid number for field: page"},
+		},
+	}
+
+	for testName, test := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.url, nil))
+
+			assert.Equal(t, test.expectStatus, rec.Code)
+			assert.Equal(t, test.expectType, rec.Header().Get("Content-Type"))
+
+			for _, s := range test.expectBody {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
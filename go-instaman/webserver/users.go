@@ -0,0 +1,74 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package webserver provides an http.Server that relays HTTP requests to the instaproxy service.
+package webserver
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/internal"
+)
+
+var ErrPictureURL = errors.New("pictureURL must be an absolute http(s) URL")
+
+// HandleUpdateUserPicture creates the HTTP handler that updates the stored picture URL of a follower or followed user.
+// The user is identified by the request's path and query, while the new URL is read from the request's body.
+func HandleUpdateUserPicture(logger *slog.Logger, svc jobservice) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			PictureURL string `json:"pictureURL"` //nolint:tagliatelle // Make it consistent
+		}
+
+		logger.Info("HTTP request", "http.method", r.Method, "http.url", r.URL)
+
+		in, err := internal.InputFromRequest[database.UpdateUserPictureParams](r)
+		if err != nil {
+			writeErrResponse(w, err, http.StatusBadRequest)
+
+			return
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeErrResponse(w, err, decodeErrStatus(err))
+
+			return
+		}
+
+		if body.PictureURL != "" {
+			u, err := url.Parse(body.PictureURL)
+			if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+				writeErrResponse(w, ErrPictureURL, http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		in.PictureURL = body.PictureURL
+
+		err = svc.UpdateUserPicture(r.Context(), in)
+
+		writeResponse(w, logger, &in, err)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserPicture(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testServer := httptest.NewServer(server.Handler)
+
+	t.Cleanup(testServer.Close)
+	t.Cleanup(cancel)
+
+	type args struct {
+		body     string
+		endpoint string
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"followers - ok": {
+			args{
+				body:     `{"pictureURL":"https://example.com/pic.jpeg"}`,
+				endpoint: "/instaman/users/456/picture?accountID=123&direction=followers",
+			},
+			wants{
+				body:   []byte(`{"accountID":123,"direction":"followers","pictureURL":"https://example.com/pic.jpeg","userID":456}` + "\n"),
+				status: http.StatusOK,
+			},
+		},
+		"following, reset picture - ok": {
+			args{
+				body:     `{"pictureURL":""}`,
+				endpoint: "/instaman/users/456/picture?accountID=123&direction=following",
+			},
+			wants{
+				body:   []byte(`{"accountID":123,"direction":"following","pictureURL":"","userID":456}` + "\n"),
+				status: http.StatusOK,
+			},
+		},
+		"missing accountID": {
+			args{
+				body:     `{"pictureURL":"https://example.com/pic.jpeg"}`,
+				endpoint: "/instaman/users/456/picture?direction=followers",
+			},
+			wants{
+				body:   expectedErr(t, "missing required field: accountID"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"invalid direction": {
+			args{
+				body:     `{"pictureURL":"https://example.com/pic.jpeg"}`,
+				endpoint: "/instaman/users/456/picture?accountID=123&direction=fololo",
+			},
+			wants{
+				body:   expectedErr(t, "db error\ninvalid direction"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"invalid picture URL": {
+			args{
+				body:     `{"pictureURL":"ftp://example.com/pic.jpeg"}`,
+				endpoint: "/instaman/users/456/picture?accountID=123&direction=followers",
+			},
+			wants{
+				body:   expectedErr(t, "pictureURL must be an absolute http(s) URL"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"service error": {
+			args{
+				body:     `{"pictureURL":"https://example.com/pic.jpeg"}`,
+				endpoint: "/instaman/users/500/picture?accountID=123&direction=followers",
+			},
+			wants{
+				body:   expectedErr(t, "db error"),
+				status: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPatch, testServer.URL+test.args.endpoint, strings.NewReader(test.args.body))
+			require.NoError(t, err)
+
+			res, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			res.Body.Close()
+
+			assert.Equal(t, test.wants.status, res.StatusCode)
+			assert.Equal(t, test.wants.body, body, "Expected: "+string(test.wants.body)+"\nActual: "+string(body))
+		})
+	}
+}
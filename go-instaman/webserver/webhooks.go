@@ -0,0 +1,192 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/internal"
+	"github.com/luca-arch/instaman/service"
+)
+
+const (
+	WebhookSignatureHeader = "X-Instaman-Signature" // Header carrying the payload's HMAC-SHA256 signature.
+	WebhookEventHeader     = "X-Instaman-Event"     // Header carrying the payload's event name.
+	WebhookTimeout         = 10 * time.Second       // The timeout of each webhook request.
+)
+
+var (
+	ErrWebhookEvents = errors.New("unsupported webhook event")
+	ErrWebhookSecret = errors.New("webhook secret is required")
+	ErrWebhookURL    = errors.New("webhook URL must be absolute and use http or https")
+)
+
+// webhookstore describes a storage for webhooks.
+type webhookstore interface {
+	FindWebhooks(ctx context.Context, event string) ([]models.Webhook, error)
+	NewWebhook(context.Context, database.NewWebhookParams) (*models.Webhook, error)
+}
+
+// WebhookConfig defines the input data to register a webhook.
+type WebhookConfig struct {
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	URL    string   `json:"url"`
+}
+
+// WebhookPayload is the body sent to the registered webhooks.
+type WebhookPayload struct {
+	Event     string    `json:"event"`
+	JobID     int64     `json:"jobID"` //nolint:tagliatelle // Always capitalise ID suffix.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookManager registers webhooks and notifies them about jobs' events.
+// Each request is signed with HMAC-SHA256 using the webhook's secret, and the hex-encoded signature is sent in
+// the `X-Instaman-Signature` header as `sha256=<signature>`.
+type WebhookManager struct {
+	httpDoer httpDoer
+	logger   *slog.Logger
+	store    webhookstore
+}
+
+// NewWebhookManager sets up and returns a new WebhookManager.
+func NewWebhookManager(store webhookstore, logger *slog.Logger) *WebhookManager {
+	return &WebhookManager{
+		httpDoer: internal.NewHTTPClient(WebhookTimeout, internal.DefaultMaxIdleConns),
+		logger:   logger,
+		store:    store,
+	}
+}
+
+// Client sets the HTTP client.
+func (m *WebhookManager) Client(client httpDoer) *WebhookManager {
+	m.httpDoer = client
+
+	return m
+}
+
+// Register validates and stores a new webhook.
+func (m *WebhookManager) Register(ctx context.Context, cfg WebhookConfig) (*models.Webhook, error) {
+	u, err := url.Parse(cfg.URL)
+
+	switch {
+	case err != nil, u.Scheme != "http" && u.Scheme != "https", u.Host == "":
+		return nil, ErrWebhookURL
+	case cfg.Secret == "":
+		return nil, ErrWebhookSecret
+	case len(cfg.Events) == 0:
+		return nil, ErrWebhookEvents
+	}
+
+	for _, e := range cfg.Events {
+		if !slices.Contains(service.WebhookEvents, e) {
+			return nil, ErrWebhookEvents
+		}
+	}
+
+	webhook, err := m.store.NewWebhook(ctx, database.NewWebhookParams{
+		Events: cfg.Events,
+		Secret: cfg.Secret,
+		URL:    cfg.URL,
+	})
+	if err != nil {
+		return nil, errors.Join(service.ErrDBFailure, err)
+	}
+
+	return webhook, nil
+}
+
+// Notify sends the event to all the webhooks that subscribed to it.
+// Failures are logged and never returned, so that they can't affect the job's execution.
+func (m *WebhookManager) Notify(ctx context.Context, event string, jobID int64) {
+	webhooks, err := m.store.FindWebhooks(ctx, event)
+	if err != nil {
+		m.logger.Error("could not fetch webhooks", "error", err, "webhook.event", event)
+
+		return
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		Event:     event,
+		JobID:     jobID,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		m.logger.Error("could not encode webhook payload", "error", err)
+
+		return
+	}
+
+	for _, wh := range webhooks {
+		if err := m.post(ctx, wh, event, body); err != nil {
+			m.logger.Warn("could not notify webhook", "error", err, "webhook.id", wh.ID, "webhook.event", event)
+		}
+	}
+}
+
+// post sends a signed payload to a webhook.
+func (m *WebhookManager) post(ctx context.Context, wh models.Webhook, event string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err //nolint:wrapcheck // Logged by the caller
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, event)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(wh.Secret, body))
+
+	res, err := m.httpDoer.Do(req)
+	if err != nil {
+		return err //nolint:wrapcheck // Logged by the caller
+	}
+
+	defer res.Body.Close()
+
+	io.Copy(io.Discard, res.Body) //nolint:errcheck // Drain for connection reuse
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return errors.New("unexpected status code: " + res.Status) //nolint:err113
+	}
+
+	return nil
+}
+
+// SignWebhook returns the hex-encoded HMAC-SHA256 signature of body.
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,167 @@
+/*
+ * Instaman - Simple Instagram account manager.
+ *
+ * Copyright (C) 2024 Luca Contini
+ *
+ * This program is free software: you can redistribute it and/or modify it
+ * under the terms of the GNU General Public License as published by the Free
+ * Software Foundation, either version 3 of the License, or (at your option)
+ * any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT
+ * ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or
+ * FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for
+ * more details.
+ *
+ * You should have received a copy of the GNU General Public License along with
+ * this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package webserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/luca-arch/instaman/database/models"
+	"github.com/luca-arch/instaman/webserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookManagerRegister(t *testing.T) {
+	t.Parallel()
+
+	manager := webserver.NewWebhookManager(&webhookstore{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	type wants struct {
+		err error
+	}
+
+	tests := map[string]struct {
+		in webserver.WebhookConfig
+		wants
+	}{
+		"valid": {
+			in: webserver.WebhookConfig{
+				Events: []string{"job.completed"},
+				Secret: "s3cr3t",
+				URL:    "https://example.com/hook",
+			},
+		},
+		"relative url": {
+			in: webserver.WebhookConfig{
+				Events: []string{"job.completed"},
+				Secret: "s3cr3t",
+				URL:    "/hook",
+			},
+			wants: wants{err: webserver.ErrWebhookURL},
+		},
+		"ftp url": {
+			in: webserver.WebhookConfig{
+				Events: []string{"job.completed"},
+				Secret: "s3cr3t",
+				URL:    "ftp://example.com/hook",
+			},
+			wants: wants{err: webserver.ErrWebhookURL},
+		},
+		"no secret": {
+			in: webserver.WebhookConfig{
+				Events: []string{"job.completed"},
+				URL:    "https://example.com/hook",
+			},
+			wants: wants{err: webserver.ErrWebhookSecret},
+		},
+		"no events": {
+			in: webserver.WebhookConfig{
+				Secret: "s3cr3t",
+				URL:    "https://example.com/hook",
+			},
+			wants: wants{err: webserver.ErrWebhookEvents},
+		},
+		"unknown event": {
+			in: webserver.WebhookConfig{
+				Events: []string{"job.completed", "job.exploded"},
+				Secret: "s3cr3t",
+				URL:    "https://example.com/hook",
+			},
+			wants: wants{err: webserver.ErrWebhookEvents},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := manager.Register(context.TODO(), test.in)
+
+			if test.wants.err != nil {
+				assert.ErrorIs(t, err, test.wants.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.in.URL, out.URL)
+			assert.Equal(t, test.in.Events, out.Events)
+		})
+	}
+}
+
+func TestWebhookManagerNotify(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan *http.Request, 2)
+	bodies := make(chan []byte, 2)
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		received <- r
+		bodies <- body
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(target.Close)
+
+	store := &webhookstore{
+		webhooks: []models.Webhook{
+			{ID: 1, Events: []string{"job.completed"}, Secret: "secret-1", URL: target.URL + "/one"},
+			{ID: 2, Events: []string{"job.completed"}, Secret: "secret-2", URL: target.URL + "/two"},
+		},
+	}
+
+	manager := webserver.NewWebhookManager(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	manager.Notify(context.TODO(), "job.completed", 123)
+
+	secrets := map[string]string{"/one": "secret-1", "/two": "secret-2"}
+
+	for range 2 {
+		req, body := <-received, <-bodies
+
+		var payload webserver.WebhookPayload
+
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, "job.completed", payload.Event)
+		assert.Equal(t, int64(123), payload.JobID)
+		assert.False(t, payload.Timestamp.IsZero())
+
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "job.completed", req.Header.Get(webserver.WebhookEventHeader))
+		assert.Equal(t, "sha256="+webserver.SignWebhook(secrets[req.URL.Path], body), req.Header.Get(webserver.WebhookSignatureHeader))
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	t.Parallel()
+
+	// echo -n '{"event":"job.completed"}' | openssl dgst -sha256 -hmac "key"
+	assert.Equal(t,
+		"d301389cf9c509bca218b9ea11e18b2efffcf5b3ff057d2a4fad5fa006a8de10",
+		webserver.SignWebhook("key", []byte(`{"event":"job.completed"}`)),
+	)
+}
@@ -22,12 +22,18 @@ package webserver
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"log/slog"
 	"net"
 	"net/http"
 	"time"
+
+	"golang.org/x/net/http2"
 )
 
+const DefaultAddr = ":10000" // The address the server listens on, unless configured with WithAddr.
+
 const (
 	// Permissive http.Server timeout values.
 	serverIdleTimeout  = 120
@@ -35,30 +41,131 @@ const (
 	serverWriteTimeout = 10
 )
 
+var ErrTLSConfig = errors.New("invalid TLS configuration")
+
+// ServerOption configures optional http.Server settings.
+type ServerOption func(*http.Server) error
+
+// Middleware wraps an http.Handler, e.g. to add authentication, tracing, or tenant injection.
+type Middleware func(http.Handler) http.Handler
+
+// WithAddr sets the TCP address the server listens on, eg: "127.0.0.1:8080".
+// The option is a no-op when addr is empty.
+func WithAddr(addr string) ServerOption {
+	return func(s *http.Server) error {
+		if addr != "" {
+			s.Addr = addr
+		}
+
+		return nil
+	}
+}
+
+// WithMetrics serves metrics at `/metrics`, guarded by an optional middleware (e.g. WithAPIKey) when not nil.
+// The route is mounted in front of the handler set up so far, so it should be the last option to be applied in order to
+// bypass the other middlewares; the built-in ones still wrap it.
+func WithMetrics(metrics http.Handler, guard Middleware) ServerOption {
+	return func(s *http.Server) error {
+		if guard != nil {
+			metrics = guard(metrics)
+		}
+
+		mux := &http.ServeMux{}
+		mux.Handle("GET /metrics", metrics)
+		mux.Handle("/", s.Handler)
+
+		s.Handler = mux
+
+		return nil
+	}
+}
+
+// WithMiddleware wraps the app routes with user-defined middlewares, applied in order: the first one is the outermost,
+// so it sees each request first. They run inside the built-in middlewares, which time and secure every response.
+func WithMiddleware(middlewares ...Middleware) ServerOption {
+	return func(s *http.Server) error {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			s.Handler = middlewares[i](s.Handler)
+		}
+
+		return nil
+	}
+}
+
+// WithTLS enables HTTP/2 on the server, which must then be started with ListenAndServeTLS(certFile, keyFile).
+// It returns an error if the certificate and key files can't be loaded.
+func WithTLS(certFile, keyFile string) ServerOption {
+	return func(s *http.Server) error {
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return errors.Join(ErrTLSConfig, err)
+		}
+
+		if err := http2.ConfigureServer(s, nil); err != nil {
+			return errors.Join(ErrTLSConfig, err)
+		}
+
+		return nil
+	}
+}
+
 // Create sets up an HTTP server with all the app routes mounted.
-func Create(ctx context.Context, jobService jobservice, igservice igservice, logger *slog.Logger) (*http.Server, error) {
-	// wrapped := WrapInstagramClient(igClient)
-	relay := DefaultPicturesRelay(logger)
+// The health check is served at `/health`, outside of the user-defined middlewares, so that probes need no credentials.
+// Webhooks can be nil, in which case their registration endpoint is not mounted.
+// LogLevels can be nil, in which case the debug endpoint to change the log level at runtime is not mounted.
+// Relay can be nil, in which case pictures are served by a DefaultPicturesRelay.
+func Create(
+	ctx context.Context,
+	jobService jobservice,
+	igservice igservice,
+	webhooks *WebhookManager,
+	logLevels loglevelsetter,
+	relay *PicturesRelay,
+	logger *slog.Logger,
+	opts ...ServerOption,
+) (*http.Server, error) {
+	if relay == nil {
+		relay = DefaultPicturesRelay(logger)
+	}
 
 	mux := &http.ServeMux{}
+	maxBodySize := MaxBodySizeMiddleware(DefaultMaxBodySize)
 
 	mux.Handle("GET /instaman/instagram/me", Handle(logger, igservice.GetAccount))
 	mux.Handle("GET /instaman/instagram/account/{name}", HandleWithInput(logger, igservice.GetUser))
 	mux.Handle("GET /instaman/instagram/account-id/{id}", HandleWithInput(logger, igservice.GetUserByID))
 	mux.Handle("GET /instaman/instagram/followers/{id}", HandleWithInput(logger, igservice.GetFollowers))
 	mux.Handle("GET /instaman/instagram/following/{id}", HandleWithInput(logger, igservice.GetFollowing))
+	mux.Handle("GET /instaman/instagram/media-count/{id}", HandleWithInput(logger, igservice.GetMediaCount))
 
 	mux.Handle("GET /instaman/instagram/picture", relay)
 
 	mux.Handle("GET /instaman/jobs/all", HandleWithInput(logger, jobService.FindJobs))
-	mux.Handle("GET /instaman/jobs/copy", HandleWithInput(logger, jobService.FindCopyJob))
+	mux.Handle("GET /instaman/jobs/archive", HandleWithInput(logger, jobService.FindArchivedJobs))
+	mux.Handle("GET /instaman/jobs/copy", HandleFindCopyJob(logger, jobService))
 	mux.Handle("GET /instaman/jobs", HandleWithInput(logger, jobService.FindJob))
-	mux.Handle("POST /instaman/jobs/copy", HandleWithInput(logger, jobService.NewCopyJob))
+	mux.Handle("GET /instaman/jobs/{id}/events", HandleFindJobEvents(logger, jobService))
+	mux.Handle("POST /instaman/jobs/copy", maxBodySize(HandleWithInput(logger, jobService.NewCopyJob)))
+	mux.Handle("POST /instaman/jobs/copy/import", MaxBodySizeMiddleware(MaxImportSize)(HandleImportCopyJob(logger, jobService)))
+	mux.Handle("POST /instaman/jobs/{id}/archive", maxBodySize(HandleArchiveJob(logger, jobService)))
+	mux.Handle("DELETE /instaman/jobs/{id}", maxBodySize(HandleDeleteJob(logger, jobService)))
+	mux.Handle("PATCH /instaman/jobs/{id}", maxBodySize(HandleUpdateJob(logger, jobService)))
+
+	mux.Handle("PATCH /instaman/users/{userID}/picture", maxBodySize(HandleUpdateUserPicture(logger, jobService)))
+
+	mux.Handle("GET /instaman/ui", HandleUI(logger, jobService))
+
+	if webhooks != nil {
+		mux.Handle("POST /instaman/webhooks", maxBodySize(HandleWithInput(logger, webhooks.Register)))
+	}
+
+	if logLevels != nil {
+		mux.Handle("POST /instaman/debug/log-level", maxBodySize(HandleSetLogLevel(logger, logLevels)))
+	}
 
 	relay.Watch(ctx, FlushFrequency)
 
-	return &http.Server{ //nolint:exhaustruct // Defaults are ok
-		Addr:              ":10000",
+	server := &http.Server{ //nolint:exhaustruct // Defaults are ok
+		Addr:              DefaultAddr,
 		Handler:           mux,
 		IdleTimeout:       serverIdleTimeout * time.Second,
 		ReadHeaderTimeout: serverReadTimeout * time.Second,
@@ -67,5 +174,20 @@ func Create(ctx context.Context, jobService jobservice, igservice igservice, log
 		BaseContext: func(_ net.Listener) context.Context {
 			return ctx
 		},
-	}, nil
+	}
+
+	for _, opt := range opts {
+		if err := opt(server); err != nil {
+			return nil, err
+		}
+	}
+
+	root := &http.ServeMux{}
+	root.Handle("GET /health", HandleHealth(logger, jobService, igservice))
+	root.Handle("/", server.Handler)
+
+	// Built-in middlewares wrap any user-defined one.
+	server.Handler = TimingMiddleware(logger)(SecurityHeadersMiddleware(root))
+
+	return server, nil
 }
@@ -29,11 +29,14 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/luca-arch/instaman/database"
+	"github.com/luca-arch/instaman/database/models"
 	"github.com/luca-arch/instaman/webserver"
 	"github.com/stretchr/testify/assert"
 )
 
 type args struct {
+	body     string // Request body of POST requests, defaults to "{}".
 	endpoint string
 	method   string
 }
@@ -48,7 +51,7 @@ func TestEndpointsResponses(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.TODO())
 
-	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, webserver.NewWebhookManager(&webhookstore{}, slog.New(slog.NewTextHandler(io.Discard, nil))), &loglevels{}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
 	testServer := httptest.NewServer(server.Handler)
 
 	t.Cleanup(testServer.Close)
@@ -93,6 +96,13 @@ func TestEndpointsResponses(t *testing.T) {
 				status: http.StatusOK,
 			},
 		},
+		"GET /instaman/instagram/media-count/{id}": {
+			args{endpoint: "/instaman/instagram/media-count/123"},
+			wants{
+				body:   fixture(t, "testdata/instagram-media-count.json"),
+				status: http.StatusOK,
+			},
+		},
 		"GET /instaman/jobs": {
 			args{endpoint: "/instaman/jobs"},
 			wants{
@@ -100,6 +110,13 @@ func TestEndpointsResponses(t *testing.T) {
 				status: http.StatusOK,
 			},
 		},
+		"GET /instaman/jobs (not found)": {
+			args{endpoint: "/instaman/jobs?id=404"},
+			wants{
+				body:   expectedErr(t, "job not found"),
+				status: http.StatusNotFound,
+			},
+		},
 		"GET /instaman/jobs/copy (followers)": {
 			args{endpoint: "/instaman/jobs/copy?direction=followers&userID=123"},
 			wants{
@@ -114,10 +131,17 @@ func TestEndpointsResponses(t *testing.T) {
 				status: http.StatusOK,
 			},
 		},
+		"GET /instaman/jobs/copy (paginated)": {
+			args{endpoint: "/instaman/jobs/copy?direction=followers&userID=123&page=2"},
+			wants{
+				body:   fixture(t, "testdata/jobs-copy-page.json"),
+				status: http.StatusOK,
+			},
+		},
 		"GET /instaman/jobs/copy (error, no direction)": {
 			args{endpoint: "/instaman/jobs/copy"},
 			wants{
-				body:   expectedErr(t, "missing required field: direction"),
+				body:   expectedErr(t, "missing required field: direction; missing required field: userID"),
 				status: http.StatusBadRequest,
 			},
 		},
@@ -128,6 +152,20 @@ func TestEndpointsResponses(t *testing.T) {
 				status: http.StatusBadRequest,
 			},
 		},
+		"GET /instaman/jobs/{id}/events": {
+			args{endpoint: "/instaman/jobs/456/events"},
+			wants{
+				body:   fixture(t, "testdata/jobs-events.json"),
+				status: http.StatusOK,
+			},
+		},
+		"GET /instaman/jobs/{id}/events (error, invalid id)": {
+			args{endpoint: "/instaman/jobs/abc/events"},
+			wants{
+				body:   expectedErr(t, "invalid number for field: id"),
+				status: http.StatusBadRequest,
+			},
+		},
 		"GET /instaman/jobs/all": {
 			args{endpoint: "/instaman/jobs/all"},
 			wants{
@@ -135,8 +173,96 @@ func TestEndpointsResponses(t *testing.T) {
 				status: http.StatusOK,
 			},
 		},
+		"POST /instaman/debug/log-level (error, invalid level)": {
+			args{
+				endpoint: "/instaman/debug/log-level",
+				method:   http.MethodPost,
+			},
+			wants{
+				body:   expectedErr(t, "log level must be one of debug, info, warn, error"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"GET /instaman/jobs/archive": {
+			args{endpoint: "/instaman/jobs/archive"},
+			wants{
+				body:   fixture(t, "testdata/jobs-archive.json"),
+				status: http.StatusOK,
+			},
+		},
+		"POST /instaman/jobs/{id}/archive": {
+			args{
+				endpoint: "/instaman/jobs/123/archive",
+				method:   http.MethodPost,
+			},
+			wants{
+				body:   fixture(t, "testdata/jobs-archive-job.json"),
+				status: http.StatusOK,
+			},
+		},
+		"POST /instaman/jobs/{id}/archive (not found)": {
+			args{
+				endpoint: "/instaman/jobs/404/archive",
+				method:   http.MethodPost,
+			},
+			wants{
+				body:   expectedErr(t, "job not found"),
+				status: http.StatusNotFound,
+			},
+		},
+		"POST /instaman/jobs/{id}/archive (error, invalid id)": {
+			args{
+				endpoint: "/instaman/jobs/abc/archive",
+				method:   http.MethodPost,
+			},
+			wants{
+				body:   expectedErr(t, "invalid number for field: id"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"DELETE /instaman/jobs/{id}": {
+			args{
+				endpoint: "/instaman/jobs/123",
+				method:   http.MethodDelete,
+			},
+			wants{
+				body:   fixture(t, "testdata/jobs-delete-job.json"),
+				status: http.StatusOK,
+			},
+		},
+		"DELETE /instaman/jobs/{id} (not found)": {
+			args{
+				endpoint: "/instaman/jobs/404",
+				method:   http.MethodDelete,
+			},
+			wants{
+				body:   expectedErr(t, "job not found"),
+				status: http.StatusNotFound,
+			},
+		},
+		"DELETE /instaman/jobs/{id} (error, invalid id)": {
+			args{
+				endpoint: "/instaman/jobs/abc",
+				method:   http.MethodDelete,
+			},
+			wants{
+				body:   expectedErr(t, "invalid number for field: id"),
+				status: http.StatusBadRequest,
+			},
+		},
+		"POST /instaman/webhooks (error, invalid url)": {
+			args{
+				endpoint: "/instaman/webhooks",
+				method:   http.MethodPost,
+			},
+			wants{
+				body:   expectedErr(t, "webhook URL must be absolute and use http or https"),
+				status: http.StatusBadRequest,
+			},
+		},
 		"POST /instaman/jobs/copy": {
 			args{
+				body:     `{"label":"Test label"}`,
 				endpoint: "/instaman/jobs/copy",
 				method:   http.MethodPost,
 			},
@@ -145,6 +271,16 @@ func TestEndpointsResponses(t *testing.T) {
 				status: http.StatusOK,
 			},
 		},
+		"POST /instaman/jobs/copy (error, empty label)": {
+			args{
+				endpoint: "/instaman/jobs/copy",
+				method:   http.MethodPost,
+			},
+			wants{
+				body:   expectedErr(t, "label is required"),
+				status: http.StatusBadRequest,
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -159,10 +295,21 @@ func TestEndpointsResponses(t *testing.T) {
 			//nolint:noctx // Ok when testing
 			switch test.args.method {
 			case http.MethodPost:
-				// Empty body as the webserver's services are mocked in common_test.go.
-				b := bytes.NewReader([]byte("{}"))
+				// Empty body by default, as the webserver's services are mocked in common_test.go.
+				reqBody := test.args.body
+				if reqBody == "" {
+					reqBody = "{}"
+				}
+
+				b := bytes.NewReader([]byte(reqBody))
 				//nolint:bodyclose // False positive.
 				res, err = http.Post(testServer.URL+test.args.endpoint, "application/json", b)
+			case http.MethodDelete:
+				req, reqErr := http.NewRequestWithContext(context.TODO(), http.MethodDelete, testServer.URL+test.args.endpoint, nil)
+				assert.NoError(t, reqErr)
+
+				//nolint:bodyclose // False positive.
+				res, err = http.DefaultClient.Do(req)
 			default:
 				//nolint:bodyclose // False positive.
 				res, err = http.Get(testServer.URL + test.args.endpoint)
@@ -181,6 +328,263 @@ func TestEndpointsResponses(t *testing.T) {
 	}
 }
 
+func TestNDJSONResponses(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testServer := httptest.NewServer(server.Handler)
+
+	t.Cleanup(testServer.Close)
+	t.Cleanup(cancel)
+
+	type args struct {
+		accept   string
+		endpoint string
+	}
+
+	type wants struct {
+		body        []byte
+		contentType string
+		nextCursor  string
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"followers - json": {
+			args{
+				endpoint: "/instaman/instagram/followers/123",
+			},
+			wants{
+				body:        fixture(t, "testdata/instagram-followers.json"),
+				contentType: "application/json",
+			},
+		},
+		"followers - ndjson": {
+			args{
+				accept:   "application/x-ndjson",
+				endpoint: "/instaman/instagram/followers/123",
+			},
+			wants{
+				body:        fixture(t, "testdata/instagram-followers.ndjson"),
+				contentType: "application/x-ndjson",
+				nextCursor:  "next-cursor-001",
+			},
+		},
+		"followers - ndjson among others": {
+			args{
+				accept:   "text/html, application/x-ndjson;q=0.9",
+				endpoint: "/instaman/instagram/followers/123",
+			},
+			wants{
+				body:        fixture(t, "testdata/instagram-followers.ndjson"),
+				contentType: "application/x-ndjson",
+				nextCursor:  "next-cursor-001",
+			},
+		},
+		"user - ndjson not supported": {
+			args{
+				accept:   "application/x-ndjson",
+				endpoint: "/instaman/instagram/account-id/123",
+			},
+			wants{
+				body:        fixture(t, "testdata/instagram-account-id.json"),
+				contentType: "application/json",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+test.args.endpoint, nil)
+			assert.NoError(t, err)
+
+			if test.args.accept != "" {
+				req.Header.Set("Accept", test.args.accept)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, test.wants.contentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, test.wants.nextCursor, res.Header.Get(webserver.NextCursorHeader))
+			assert.Equal(t, test.wants.body, body, "Expected: "+string(test.wants.body)+"\nActual: "+string(body))
+		})
+	}
+}
+
+// TestRouting ensures that each request is served by the most specific route, and that routes sharing a prefix
+// don't capture each other's requests.
+func TestRouting(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, webserver.NewWebhookManager(&webhookstore{}, slog.New(slog.NewTextHandler(io.Discard, nil))), &loglevels{}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testServer := httptest.NewServer(server.Handler)
+
+	t.Cleanup(testServer.Close)
+	t.Cleanup(cancel)
+
+	type wants struct {
+		body   []byte // Not checked when nil.
+		status int
+	}
+
+	tests := map[string]struct {
+		args
+		wants
+	}{
+		"GET /instaman/jobs is not captured by /instaman/jobs/all": {
+			args{endpoint: "/instaman/jobs", method: http.MethodGet},
+			wants{body: fixture(t, "testdata/jobs-job.json"), status: http.StatusOK},
+		},
+		"GET /instaman/jobs/all is not captured by /instaman/jobs": {
+			args{endpoint: "/instaman/jobs/all", method: http.MethodGet},
+			wants{body: fixture(t, "testdata/jobs-all.json"), status: http.StatusOK},
+		},
+		"GET /instaman/jobs/copy is not captured by /instaman/jobs/all": {
+			args{endpoint: "/instaman/jobs/copy?direction=followers&userID=123", method: http.MethodGet},
+			wants{body: fixture(t, "testdata/jobs-copy.json"), status: http.StatusOK},
+		},
+		"GET /instaman/jobs/{id} is not allowed": {
+			args{endpoint: "/instaman/jobs/123", method: http.MethodGet},
+			wants{body: nil, status: http.StatusMethodNotAllowed},
+		},
+		"DELETE /instaman/jobs/{id}/events is not allowed": {
+			args{endpoint: "/instaman/jobs/123/events", method: http.MethodDelete},
+			wants{body: nil, status: http.StatusMethodNotAllowed},
+		},
+		"GET /instaman/jobs/all/events is served by /instaman/jobs/{id}/events": {
+			args{endpoint: "/instaman/jobs/all/events", method: http.MethodGet},
+			wants{body: expectedErr(t, "invalid number for field: id"), status: http.StatusBadRequest},
+		},
+		"GET /instaman/jobs/copy/events is served by /instaman/jobs/{id}/events": {
+			args{endpoint: "/instaman/jobs/copy/events", method: http.MethodGet},
+			wants{body: expectedErr(t, "invalid number for field: id"), status: http.StatusBadRequest},
+		},
+		"GET /instaman/jobs/{id}/events": {
+			args{endpoint: "/instaman/jobs/456/events", method: http.MethodGet},
+			wants{body: fixture(t, "testdata/jobs-events.json"), status: http.StatusOK},
+		},
+		"GET /instaman/instagram/account-id/{id} is not captured by /instaman/instagram/account/{name}": {
+			args{endpoint: "/instaman/instagram/account-id/123", method: http.MethodGet},
+			wants{body: fixture(t, "testdata/instagram-account-id.json"), status: http.StatusOK},
+		},
+		"GET /instaman/instagram/account/{name}": {
+			args{endpoint: "/instaman/instagram/account/name", method: http.MethodGet},
+			wants{body: fixture(t, "testdata/instagram-account-name.json"), status: http.StatusOK},
+		},
+		"POST /instaman/jobs/all is not allowed": {
+			args{endpoint: "/instaman/jobs/all", method: http.MethodPost},
+			wants{body: nil, status: http.StatusMethodNotAllowed},
+		},
+		"POST /instaman/jobs/copy": {
+			args{body: `{"label":"Test label"}`, endpoint: "/instaman/jobs/copy", method: http.MethodPost},
+			wants{body: fixture(t, "testdata/jobs-copy-new.json"), status: http.StatusOK},
+		},
+		"GET /instaman/webhooks is not allowed": {
+			args{endpoint: "/instaman/webhooks", method: http.MethodGet},
+			wants{body: nil, status: http.StatusMethodNotAllowed},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			reqBody := test.args.body
+			if reqBody == "" {
+				reqBody = "{}"
+			}
+
+			req, err := http.NewRequestWithContext(ctx, test.args.method, testServer.URL+test.args.endpoint, bytes.NewReader([]byte(reqBody)))
+			assert.NoError(t, err)
+
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			res.Body.Close()
+
+			assert.Equal(t, test.wants.status, res.StatusCode)
+
+			if test.wants.body != nil {
+				assert.Equal(t, test.wants.body, body, "Expected: "+string(test.wants.body)+"\nActual: "+string(body))
+			}
+		})
+	}
+}
+
+func TestWithAddr(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := map[string]struct {
+		addr string
+		want string
+	}{
+		"default address": {
+			addr: "",
+			want: webserver.DefaultAddr,
+		},
+		"configured address": {
+			addr: "127.0.0.1:8080",
+			want: "127.0.0.1:8080",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, logger, webserver.WithAddr(test.addr))
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, server.Addr)
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	server, _ := webserver.Create(ctx, &jobsvc{}, &igservice{}, webserver.NewWebhookManager(&webhookstore{}, slog.New(slog.NewTextHandler(io.Discard, nil))), &loglevels{}, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testServer := httptest.NewServer(server.Handler)
+
+	t.Cleanup(testServer.Close)
+	t.Cleanup(cancel)
+
+	res, err := http.Get(testServer.URL + "/instaman/jobs") //nolint:noctx // Ok when testing
+	assert.NoError(t, err)
+
+	res.Body.Close()
+
+	assert.Equal(t, "default-src 'none'", res.Header.Get("Content-Security-Policy"))
+	assert.Equal(t, "no-referrer", res.Header.Get("Referrer-Policy"))
+	assert.Equal(t, "nosniff", res.Header.Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", res.Header.Get("X-Frame-Options"))
+}
+
 func expectedErr(t *testing.T, msg string) []byte {
 	t.Helper()
 
@@ -195,3 +599,252 @@ func expectedErr(t *testing.T, msg string) []byte {
 
 	return append(b, byte(0xa)) // Append newline!
 }
+
+func TestWithMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var order []string
+
+	tag := func(name string) webserver.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				w.Header().Add("X-Middleware", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, logger,
+		webserver.WithMiddleware(tag("first"), tag("second")))
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"first", "second"}, order)
+	assert.Equal(t, []string{"first", "second"}, w.Header().Values("X-Middleware"))
+
+	// Built-in middlewares still apply.
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.NotEmpty(t, w.Header().Get("X-Response-Time"))
+}
+
+func TestAPIKeyRoutes(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, logger,
+		webserver.WithMiddleware(
+			webserver.WithCORS([]string{"https://dashboard.example.com"}),
+			webserver.WithAPIKey("secret"),
+		))
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, expectedErr(t, "missing or invalid API key"), w.Body.Bytes())
+
+	r := httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil)
+	r.Header.Set("X-Api-Key", "secret")
+
+	w = httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Preflight requests don't need the key.
+	r = httptest.NewRequest(http.MethodOptions, "/instaman/jobs/all", nil)
+	r.Header.Set("Origin", "https://dashboard.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	r.Header.Set("Access-Control-Request-Headers", "X-Api-Key")
+
+	w = httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Api-Key")
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("instaman_jobs_started_total 1\n")) //nolint:errcheck // Test
+	})
+
+	tests := map[string]struct {
+		guard  webserver.Middleware
+		key    string
+		status int
+	}{
+		"no guard": {
+			guard:  nil,
+			key:    "",
+			status: http.StatusOK,
+		},
+		"guard without key": {
+			guard:  webserver.WithAPIKey("metrics"),
+			key:    "",
+			status: http.StatusUnauthorized,
+		},
+		"guard with the API key": {
+			guard:  webserver.WithAPIKey("metrics"),
+			key:    "secret",
+			status: http.StatusUnauthorized,
+		},
+		"guard with the metrics key": {
+			guard:  webserver.WithAPIKey("metrics"),
+			key:    "metrics",
+			status: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			t.Cleanup(cancel)
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+			server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, logger,
+				webserver.WithMiddleware(webserver.WithAPIKey("secret")),
+				webserver.WithMetrics(metrics, test.guard))
+			assert.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if test.key != "" {
+				r.Header.Set("X-Api-Key", test.key)
+			}
+
+			w := httptest.NewRecorder()
+			server.Handler.ServeHTTP(w, r)
+
+			assert.Equal(t, test.status, w.Code)
+
+			if test.status == http.StatusOK {
+				assert.Equal(t, "instaman_jobs_started_total 1\n", w.Body.String())
+			}
+
+			// Other routes still go through the API key middleware.
+			w = httptest.NewRecorder()
+			server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil))
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestCORSRoutes(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	t.Cleanup(cancel)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	server, err := webserver.Create(ctx, &jobsvc{}, &igservice{}, nil, nil, nil, logger,
+		webserver.WithMiddleware(webserver.WithCORS([]string{"https://dashboard.example.com"})))
+	assert.NoError(t, err)
+
+	// Preflight requests are answered without reaching the routes, which don't accept OPTIONS.
+	r := httptest.NewRequest(http.MethodOptions, "/instaman/jobs/1", nil)
+	r.Header.Set("Origin", "https://dashboard.example.com")
+	r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://dashboard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, webserver.CORSAllowedMethods, w.Header().Get("Access-Control-Allow-Methods"))
+
+	r = httptest.NewRequest(http.MethodGet, "/instaman/jobs/all", nil)
+	r.Header.Set("Origin", "https://dashboard.example.com")
+
+	w = httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://dashboard.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Built-in middlewares still apply.
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+}
+
+// findJobsSpy is a jobsvc that records the parameters FindJobs is called with.
+type findJobsSpy struct {
+	*jobsvc
+
+	params []database.FindJobsParams
+}
+
+func (s *findJobsSpy) FindJobs(ctx context.Context, params database.FindJobsParams) ([]models.Job, error) {
+	s.params = append(s.params, params)
+
+	return s.jobsvc.FindJobs(ctx, params)
+}
+
+func TestFindJobsQueryString(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		query string
+		wants database.FindJobsParams
+	}{
+		"no query string": {
+			query: "",
+			wants: database.FindJobsParams{},
+		},
+		"all fields": {
+			query: "?type=copy-followers&state=active&order=-last_run&page=1",
+			wants: database.FindJobsParams{
+				Order: "-last_run",
+				Page:  1,
+				State: "active",
+				Type:  "copy-followers",
+			},
+		},
+		"multiple states": {
+			query: "?states=new,error",
+			wants: database.FindJobsParams{
+				States: []string{"new", "error"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.TODO())
+			t.Cleanup(cancel)
+
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			spy := &findJobsSpy{jobsvc: &jobsvc{}}
+
+			server, err := webserver.Create(ctx, spy, &igservice{}, nil, nil, nil, logger)
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/instaman/jobs/all"+test.query, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, []database.FindJobsParams{test.wants}, spy.params)
+		})
+	}
+}
@@ -17,6 +17,20 @@ ALTER TABLE jobs ADD CONSTRAINT jobs_unique_checksum UNIQUE (checksum);
 CREATE INDEX jobs_type_idx
     ON jobs USING HASH (job_type);
 
+--
+-- Table `jobs_archive` contains archived jobs. Its columns must match the `jobs` table's.
+--
+CREATE TABLE IF NOT EXISTS jobs_archive (
+    id       INTEGER        PRIMARY KEY,
+    checksum VARCHAR(64)    NOT NULL,
+    job_type VARCHAR(16)    NOT NULL,
+    label    TEXT           NOT NULL,
+    last_run TIMESTAMP,
+    metadata JSONB          NOT NULL,
+    next_run TIMESTAMP,
+    state    VARCHAR(16)    NOT NULL
+);
+
 --
 -- Table `jobs_events` contains jobs' audit logs.
 --
@@ -53,4 +67,60 @@ CREATE TABLE IF NOT EXISTS user_following (
     user_id    BIGINT       NOT NULL,
 
     PRIMARY KEY (account_id, user_id)
-);
\ No newline at end of file
+);
+
+--
+-- Migration: add users' full name to the connections tables.
+--
+ALTER TABLE user_followers ADD COLUMN IF NOT EXISTS full_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_following ADD COLUMN IF NOT EXISTS full_name TEXT NOT NULL DEFAULT '';
+
+--
+-- Migration: add the jobs' owner, to filter them once multi-tenancy is supported.
+--
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS created_by TEXT NOT NULL DEFAULT '';
+
+--
+-- Migration: add the hostname of the worker that generated each event, NULL for older events.
+--
+ALTER TABLE jobs_events ADD COLUMN IF NOT EXISTS worker_host VARCHAR(255);
+
+--
+-- Migration: soft-delete jobs, so that their audit logs are kept. NULL for jobs that were not deleted.
+-- Checksums are only unique among the jobs that were not deleted, so that a deleted job can be created again.
+--
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+ALTER TABLE jobs_archive ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_unique_checksum;
+CREATE UNIQUE INDEX IF NOT EXISTS jobs_unique_checksum_idx
+    ON jobs (checksum) WHERE deleted_at IS NULL;
+
+--
+-- Migration: keep the audit logs of archived jobs. `jobs_events.job_id` becomes a soft reference, because archived jobs
+-- are moved to `jobs_archive` with their ID and deleting them from `jobs` used to cascade to their events.
+--
+ALTER TABLE jobs_events DROP CONSTRAINT IF EXISTS jobs_events_job_id_fkey;
+CREATE INDEX IF NOT EXISTS jobs_events_job_id_idx
+    ON jobs_events (job_id);
+
+--
+-- Table `workers` contains the worker processes' heartbeat and the job they are currently running.
+-- A worker that runs several polling loops has one row per loop, its hostname suffixed with the loop's slot (`host#2`).
+--
+CREATE TABLE IF NOT EXISTS workers (
+    hostname       TEXT         PRIMARY KEY,
+    current_job_id BIGINT,
+    last_seen      TIMESTAMP    NOT NULL
+);
+
+--
+-- Table `webhooks` contains the URLs that are notified about jobs' events.
+--
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         SERIAL PRIMARY KEY,
+    created_at TIMESTAMP    NOT NULL,
+    events     TEXT[]       NOT NULL,
+    secret     TEXT         NOT NULL,
+    url        TEXT         NOT NULL
+);
@@ -31,7 +31,7 @@ export type CopyJob = {
  * CopyJob metadata.
  */
 export type CopyJobMetadata = {
-  frequency: "daily" | "weekly";
+  frequency: "daily" | "weekly" | "monthly";
   userID: number;
 };
 
@@ -15,7 +15,7 @@ import { JobType } from "src/api/types";
 import { useState } from "react";
 
 type FormData = {
-  freq: "daily" | "weekly";
+  freq: "daily" | "weekly" | "monthly";
   label: string;
   startNow: boolean;
   type: JobType;
@@ -115,6 +115,7 @@ const NewCopyJobModal = ({ onCancel, onCreate, open }: Props) => {
           <Select>
             <Select.Option value="daily">Daily</Select.Option>
             <Select.Option value="weekly">Weekly</Select.Option>
+            <Select.Option value="monthly">Monthly</Select.Option>
           </Select>
         </Form.Item>
 